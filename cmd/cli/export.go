@@ -0,0 +1,63 @@
+package main
+
+import (
+	"backend-layout/internal/adapter/catalog"
+	"backend-layout/internal/domain"
+	_bookRepository "backend-layout/internal/module/book/repository"
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// runExport writes the catalog to a file or stdout, using the same filters as GET /books
+func runExport(ctx context.Context, dbpool *pgxpool.Pool, args []string) error {
+	var filter domain.BookExportFilter
+
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", domain.ExportFormatCSV, "output format: csv, ndjson or marc")
+	output := fs.String("o", "", "output file, defaults to stdout")
+	fs.StringVar(&filter.Keyword, "q", "", "only books whose title contains this keyword")
+	fs.Int64Var(&filter.MinPrice, "min-price", 0, "minimum price")
+	fs.Int64Var(&filter.MaxPrice, "max-price", 0, "maximum price")
+	fs.StringVar(&filter.SortBy, "sort-by", "", "highest_price, lowest_price, highest_rating, most_rated or newest (default)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if !catalog.IsExportFormat(*format) {
+		return fmt.Errorf("unsupported format %q", *format)
+	}
+
+	var out io.Writer = os.Stdout
+
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		out = file
+	}
+
+	buf := bufio.NewWriter(out)
+
+	total, err := catalog.ExportBooks(ctx, _bookRepository.NewPostgresBookRepository(dbpool), *format, filter, buf)
+	if err != nil {
+		return err
+	}
+
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "exported %d books\n", total)
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"backend-layout/internal/config"
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const usage = `usage: cli [command]
+
+commands:
+  seed     seed the database with sample books (default)
+  export   export the catalog, run "cli export -h" for the flags`
+
+func main() {
+	command := "seed"
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+
+	cfg, err := config.NewConfig("./../../.env")
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	pgxConfig, err := pgxpool.ParseConfig(cfg.DB.DSN)
+
+	if err != nil {
+		log.Fatalf("failed to parse pgx config: %v", err)
+	}
+
+	ctx := context.Background()
+	dbpool, err := pgxpool.NewWithConfig(ctx, pgxConfig)
+
+	if err != nil {
+		log.Fatalf("failed to create pgx pool: %v", err)
+	}
+	defer dbpool.Close()
+
+	switch command {
+	case "seed":
+		runSeed(ctx, dbpool)
+	case "export":
+		if err := runExport(ctx, dbpool, os.Args[2:]); err != nil {
+			log.Fatalf("failed to export: %v", err)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
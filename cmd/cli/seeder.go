@@ -1,40 +1,20 @@
 package main
 
 import (
-	"backend-layout/internal/config"
 	"backend-layout/internal/domain"
 	"context"
 	"fmt"
-	"log"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func main() {
+func runSeed(ctx context.Context, dbpool *pgxpool.Pool) {
 	fmt.Println("start seeder...")
 
-	cfg, err := config.NewConfig("./../../.env")
-	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
-	}
-
-	pgxConfig, err := pgxpool.ParseConfig(cfg.DB.DSN)
-
-	if err != nil {
-		fmt.Println("failed to parse pgx config: %w", err)
-	}
-
-	ctx := context.Background()
-	dbpool, err := pgxpool.NewWithConfig(ctx, pgxConfig)
-
-	if err != nil {
-		fmt.Println("failed to create pgx pool: %w", err.Error())
-	}
-
 	seeder := new(dbpool)
 
-	err = seeder.Run(ctx)
+	err := seeder.Run(ctx)
 
 	if err != nil {
 		fmt.Println("failed to seed %w", err.Error())
@@ -7,8 +7,9 @@ import (
 
 	errorHandler "backend-layout/internal/adapter/errors"
 	"backend-layout/internal/adapter/oauth"
-	paymentgateway "backend-layout/internal/adapter/payment_gateway"
+	"backend-layout/internal/adapter/storage"
 	"backend-layout/internal/config"
+	"backend-layout/internal/domain"
 	"backend-layout/internal/middleware"
 	authHttpDelivery "backend-layout/internal/module/auth/delivery/http"
 	_authUsecase "backend-layout/internal/module/auth/usecase"
@@ -18,8 +19,23 @@ import (
 	cartHttpDelivery "backend-layout/internal/module/cart/delivery/http"
 	_cartReposiotry "backend-layout/internal/module/cart/repository"
 	_cartUsecase "backend-layout/internal/module/cart/usecase"
+	fineHttpDelivery "backend-layout/internal/module/fine/delivery/http"
+	_fineRepository "backend-layout/internal/module/fine/repository"
+	_fineUsecase "backend-layout/internal/module/fine/usecase"
+	holdHttpDelivery "backend-layout/internal/module/hold/delivery/http"
+	_holdRepository "backend-layout/internal/module/hold/repository"
+	_holdUsecase "backend-layout/internal/module/hold/usecase"
+	lendingPolicyHttpDelivery "backend-layout/internal/module/lendingpolicy/delivery/http"
+	_lendingPolicyRepository "backend-layout/internal/module/lendingpolicy/repository"
+	_lendingPolicyUsecase "backend-layout/internal/module/lendingpolicy/usecase"
 	_rbacReposiotry "backend-layout/internal/module/rbac/repository"
 	_rbacUsecase "backend-layout/internal/module/rbac/usecase"
+	readingListHttpDelivery "backend-layout/internal/module/readinglist/delivery/http"
+	_readingListRepository "backend-layout/internal/module/readinglist/repository"
+	_readingListUsecase "backend-layout/internal/module/readinglist/usecase"
+	reviewHttpDelivery "backend-layout/internal/module/review/delivery/http"
+	_reviewRepository "backend-layout/internal/module/review/repository"
+	_reviewUsecase "backend-layout/internal/module/review/usecase"
 	userHttpDelivery "backend-layout/internal/module/user/delivery/http"
 	_userRepository "backend-layout/internal/module/user/repository"
 	_userUsecase "backend-layout/internal/module/user/usecase"
@@ -35,6 +51,10 @@ import (
 
 	paymentHttpDelivery "backend-layout/internal/module/payment/delivery/http"
 
+	promotionHttpDelivery "backend-layout/internal/module/promotion/delivery/http"
+	_promotionRepository "backend-layout/internal/module/promotion/repository"
+	_promotionUsecase "backend-layout/internal/module/promotion/usecase"
+
 	"backend-layout/internal/tasks"
 	"context"
 
@@ -49,22 +69,24 @@ import (
 type APIServer struct {
 	Pool            *pgxpool.Pool
 	TaskDistributor tasks.TaskDistributor
-	// S3              storage.Uploader
-	Conf           *config.Config
-	OAuth          *oauth.Oauth
-	rdb            *redis.Client
-	MidtransClient *paymentgateway.MidtransClient
+	Storage         storage.Uploader
+	Conf            *config.Config
+	OAuth           *oauth.Oauth
+	rdb             *redis.Client
+	PaymentGateway  domain.PaymentGateway
+	BookMetadata    domain.BookMetadataProvider
 }
 
-func NewAPIServer(pool *pgxpool.Pool, taskDistributor tasks.TaskDistributor, conf *config.Config, oauth *oauth.Oauth, rdb *redis.Client, midtransClient *paymentgateway.MidtransClient) *APIServer {
+func NewAPIServer(pool *pgxpool.Pool, taskDistributor tasks.TaskDistributor, storage storage.Uploader, conf *config.Config, oauth *oauth.Oauth, rdb *redis.Client, paymentGateway domain.PaymentGateway, bookMetadata domain.BookMetadataProvider) *APIServer {
 	return &APIServer{
 		Pool:            pool,
 		TaskDistributor: taskDistributor,
-		// S3:              s3,
-		Conf:           conf,
-		OAuth:          oauth,
-		rdb:            rdb,
-		MidtransClient: midtransClient,
+		Storage:         storage,
+		Conf:            conf,
+		OAuth:           oauth,
+		rdb:             rdb,
+		PaymentGateway:  paymentGateway,
+		BookMetadata:    bookMetadata,
 	}
 }
 
@@ -96,11 +118,16 @@ func (s *APIServer) Run(ctx context.Context) error {
 
 	e.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
 
+	if local, ok := s.Storage.(*storage.LocalStorage); ok {
+		e.Static("/storage", local.Root())
+	}
+
 	r.Use(middleware.JWTAuthenticator())
 
 	rbacRepository := _rbacReposiotry.NewRBACRepository(s.Pool)
 	rbacUsecase := _rbacUsecase.NewRBACUsecase(rbacRepository)
 	middlewareRBAC := middleware.NewRBACMiddleware(rbacUsecase)
+	middlewareIdempotency := middleware.NewIdempotencyMiddleware(s.rdb, 24*time.Hour)
 
 	userRepository := _userRepository.NewPostgresUserRepository(s.Pool)
 	userUsecase := _userUsecase.NewUserUsecase(userRepository, s.TaskDistributor)
@@ -110,20 +137,65 @@ func (s *APIServer) Run(ctx context.Context) error {
 	authHttpDelivery.NewAuthHandler(p, authUsecase, s.OAuth, s.rdb)
 
 	bookRepository := _bookRepository.NewPostgresBookRepository(s.Pool)
-	bookUsecase := _bookUsecase.NewBookUsecase(bookRepository)
+	bookRevisionRepository := _bookRepository.NewPostgresBookRevisionRepository(s.Pool)
+	bookUsecase := _bookUsecase.NewBookUsecase(bookRepository, bookRevisionRepository, s.Storage, s.TaskDistributor)
 	bookHttpDelivery.NewBookHandler(p, r, bookUsecase, middlewareRBAC)
 
+	bookCopyRepository := _bookRepository.NewPostgresBookCopyRepository(s.Pool)
+	bookImportRepository := _bookRepository.NewPostgresBookImportRepository(s.Pool)
+	bookImportUsecase := _bookUsecase.NewBookImportUsecase(bookImportRepository, bookRepository, bookRevisionRepository, bookCopyRepository, s.Storage, s.TaskDistributor)
+	bookHttpDelivery.NewBookImportHandler(r, bookImportUsecase, middlewareRBAC)
+
+	bookExportRepository := _bookRepository.NewPostgresBookExportRepository(s.Pool)
+	bookExportUsecase := _bookUsecase.NewBookExportUsecase(bookExportRepository, bookRepository, s.Storage, s.TaskDistributor)
+	bookHttpDelivery.NewBookExportHandler(r, bookExportUsecase, middlewareRBAC)
+
+	bookCopyUsecase := _bookUsecase.NewBookCopyUsecase(bookCopyRepository, bookRepository, s.TaskDistributor)
+	bookHttpDelivery.NewBookCopyHandler(r, bookCopyUsecase, middlewareRBAC)
+
+	bookMetadataUsecase := _bookUsecase.NewBookMetadataUsecase(bookRepository, bookRevisionRepository, s.BookMetadata, s.TaskDistributor)
+	bookHttpDelivery.NewBookMetadataHandler(r, bookMetadataUsecase, middlewareRBAC)
+
+	holdRepository := _holdRepository.NewPostgresBookHoldRepository(s.Pool)
+	holdUsecase := _holdUsecase.NewBookHoldUsecase(holdRepository, bookCopyRepository, bookRepository, s.TaskDistributor, s.Conf.Hold.PickupWindow)
+	holdHttpDelivery.NewBookHoldHandler(r, holdUsecase)
+
+	reviewRepository := _reviewRepository.NewPostgresReviewRepository(s.Pool)
+	reviewUsecase := _reviewUsecase.NewReviewUsecase(reviewRepository)
+	reviewHttpDelivery.NewReviewHandler(p, r, reviewUsecase, middlewareRBAC)
+
 	cartRepository := _cartReposiotry.NewCartRepository(s.Pool)
 	cartUsecase := _cartUsecase.NewCartUsecase(cartRepository)
 	cartHttpDelivery.NewCartHandler(r, cartUsecase)
 
-	orderRepository := _orderRepository.NewPostgresOrderRepository(s.Pool)
-	orderUsecase := _orderUsecase.NewOrderUsecase(orderRepository)
-	orderHttpDelivery.NewOrderHandler(r, orderUsecase)
+	readingListRepository := _readingListRepository.NewPostgresReadingListRepository(s.Pool)
+	readingListUsecase := _readingListUsecase.NewReadingListUsecase(readingListRepository, cartUsecase, s.TaskDistributor)
+	readingListHttpDelivery.NewReadingListHandler(p, r, readingListUsecase)
+
+	fineRepository := _fineRepository.NewPostgresFineRepository(s.Pool)
+	fineUsecase := _fineUsecase.NewFineUsecase(fineRepository)
+	fineHttpDelivery.NewFineHandler(r, fineUsecase, middlewareRBAC)
+
+	lendingPolicyRepository := _lendingPolicyRepository.NewPostgresLendingPolicyRepository(s.Pool)
+	lendingPolicyUsecase := _lendingPolicyUsecase.NewLendingPolicyUsecase(lendingPolicyRepository)
+	lendingPolicyHttpDelivery.NewLendingPolicyHandler(r, lendingPolicyUsecase, middlewareRBAC)
+
+	promotionRepository := _promotionRepository.NewPostgresPromotionRepository(s.Pool)
+	promotionUsecase := _promotionUsecase.NewPromotionUsecase(promotionRepository)
+	promotionHttpDelivery.NewPromotionHandler(r, promotionUsecase, middlewareRBAC)
 
 	paymentRepository := _paymentRepository.NewPostgresPaymentRepository(s.Pool)
-	paymentUsecase := _paymentUsecase.NewPaymentUsecase(paymentRepository, orderRepository, s.MidtransClient)
-	paymentHttpDelivery.NewPaymentHandler(r, paymentUsecase)
+
+	orderRepository := _orderRepository.NewPostgresOrderRepository(s.Pool)
+	orderUsecase := _orderUsecase.NewOrderUsecase(orderRepository, bookCopyRepository, holdRepository, fineRepository, lendingPolicyRepository, paymentRepository, promotionRepository, s.PaymentGateway, s.TaskDistributor, domain.FinePolicy{
+		BlockThreshold: s.Conf.Fine.BlockThreshold,
+		ReminderBefore: s.Conf.Fine.ReminderBefore,
+	}, s.Conf.Order.PaymentWindow)
+	orderHttpDelivery.NewOrderHandler(r, orderUsecase, middlewareRBAC, middlewareIdempotency)
+
+	paymentUsecase := _paymentUsecase.NewPaymentUsecase(paymentRepository, orderRepository, orderUsecase, lendingPolicyRepository,
+		_paymentRepository.NewPostgresPaymentReconciliationRepository(s.Pool), s.PaymentGateway, s.Storage, s.Conf.Payment.ReconcileLookback)
+	paymentHttpDelivery.NewPaymentHandler(p, r, paymentUsecase, middlewareRBAC, middlewareIdempotency)
 
 	go func() {
 		<-ctx.Done()
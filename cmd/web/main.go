@@ -4,10 +4,28 @@ import (
 	"backend-layout/cmd/web/api"
 	"backend-layout/internal/adapter/db"
 	"backend-layout/internal/adapter/instrumentation"
+	"backend-layout/internal/adapter/metadata"
 	"backend-layout/internal/adapter/oauth"
 	paymentgateway "backend-layout/internal/adapter/payment_gateway"
+	"backend-layout/internal/adapter/storage"
 	"backend-layout/internal/adapter/worker"
 	"backend-layout/internal/config"
+	"backend-layout/internal/domain"
+	_bookRepository "backend-layout/internal/module/book/repository"
+	_bookUsecase "backend-layout/internal/module/book/usecase"
+	_cartRepository "backend-layout/internal/module/cart/repository"
+	_cartUsecase "backend-layout/internal/module/cart/usecase"
+	_fineRepository "backend-layout/internal/module/fine/repository"
+	_holdRepository "backend-layout/internal/module/hold/repository"
+	_holdUsecase "backend-layout/internal/module/hold/usecase"
+	_lendingPolicyRepository "backend-layout/internal/module/lendingpolicy/repository"
+	_orderRepository "backend-layout/internal/module/order/repository"
+	_orderUsecase "backend-layout/internal/module/order/usecase"
+	_paymentRepository "backend-layout/internal/module/payment/repository"
+	_paymentUsecase "backend-layout/internal/module/payment/usecase"
+	_promotionRepository "backend-layout/internal/module/promotion/repository"
+	_readingListRepository "backend-layout/internal/module/readinglist/repository"
+	_readingListUsecase "backend-layout/internal/module/readinglist/usecase"
 	"backend-layout/internal/tasks"
 	"context"
 	"fmt"
@@ -16,6 +34,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/hibiken/asynq"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -64,19 +83,97 @@ func main() {
 	}
 	defer redisTaskDistributor.Close()
 
-	// s3, err := storage.NewS3Client(cfg.AWS)
-	// if err != nil {
-	// 	logger.Fatal().Err(err).Msg("error initializing S3 client")
-	// 	return
-	// }
+	fileStorage, err := initStorage(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize storage")
+		return
+	}
 
-	midtransClient := paymentgateway.InitMidtrans(cfg.Midtrans)
+	bookMetadataProvider, err := metadata.NewProvider(cfg.Metadata)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize book metadata provider")
+		return
+	}
+
+	paymentGateway := paymentgateway.NewGateway(cfg.Payment, cfg.Midtrans)
 
-	srv := api.NewAPIServer(dbpool, redisTaskDistributor, cfg, oauth2, rdb, midtransClient)
+	srv := api.NewAPIServer(dbpool, redisTaskDistributor, fileStorage, cfg, oauth2, rdb, paymentGateway, bookMetadataProvider)
 
 	waitGroup, ctx := errgroup.WithContext(ctx)
 
-	runTaskProcessor(ctx, waitGroup)
+	if fake, ok := paymentGateway.(*paymentgateway.FakeGateway); ok {
+		runFakePaymentGateway(ctx, waitGroup, fake, cfg.Payment.FakeAddr)
+	}
+
+	bookRepository := _bookRepository.NewPostgresBookRepository(dbpool)
+	bookRevisionRepository := _bookRepository.NewPostgresBookRevisionRepository(dbpool)
+	bookCopyRepository := _bookRepository.NewPostgresBookCopyRepository(dbpool)
+	bookUsecase := _bookUsecase.NewBookUsecase(bookRepository, bookRevisionRepository, fileStorage, redisTaskDistributor)
+	bookImportUsecase := _bookUsecase.NewBookImportUsecase(_bookRepository.NewPostgresBookImportRepository(dbpool), bookRepository, bookRevisionRepository, bookCopyRepository, fileStorage, redisTaskDistributor)
+	bookExportUsecase := _bookUsecase.NewBookExportUsecase(_bookRepository.NewPostgresBookExportRepository(dbpool), bookRepository, fileStorage, redisTaskDistributor)
+	bookMetadataUsecase := _bookUsecase.NewBookMetadataUsecase(bookRepository, bookRevisionRepository, bookMetadataProvider, redisTaskDistributor)
+
+	holdRepository := _holdRepository.NewPostgresBookHoldRepository(dbpool)
+	holdUsecase := _holdUsecase.NewBookHoldUsecase(holdRepository, bookCopyRepository, bookRepository, redisTaskDistributor, cfg.Hold.PickupWindow)
+
+	orderRepository := _orderRepository.NewPostgresOrderRepository(dbpool)
+	lendingPolicyRepository := _lendingPolicyRepository.NewPostgresLendingPolicyRepository(dbpool)
+	paymentRepository := _paymentRepository.NewPostgresPaymentRepository(dbpool)
+
+	orderUsecase := _orderUsecase.NewOrderUsecase(orderRepository, bookCopyRepository, holdRepository,
+		_fineRepository.NewPostgresFineRepository(dbpool), lendingPolicyRepository, paymentRepository, _promotionRepository.NewPostgresPromotionRepository(dbpool), paymentGateway, redisTaskDistributor, domain.FinePolicy{
+			BlockThreshold: cfg.Fine.BlockThreshold,
+			ReminderBefore: cfg.Fine.ReminderBefore,
+		}, cfg.Order.PaymentWindow)
+
+	paymentUsecase := _paymentUsecase.NewPaymentUsecase(paymentRepository, orderRepository, orderUsecase, lendingPolicyRepository,
+		_paymentRepository.NewPostgresPaymentReconciliationRepository(dbpool), paymentGateway, fileStorage, cfg.Payment.ReconcileLookback)
+
+	cartUsecase := _cartUsecase.NewCartUsecase(_cartRepository.NewCartRepository(dbpool))
+	readingListUsecase := _readingListUsecase.NewReadingListUsecase(_readingListRepository.NewPostgresReadingListRepository(dbpool), cartUsecase, redisTaskDistributor)
+
+	taskProcessor := worker.NewTaskProcessor()
+	taskProcessor.Handle(tasks.TaskProcessBookImage, tasks.NewProcessBookImageHandler(bookRepository, fileStorage))
+	taskProcessor.Handle(tasks.TaskImportBooks, tasks.NewImportBooksHandler(bookImportUsecase))
+	taskProcessor.Handle(tasks.TaskExportBooks, tasks.NewExportBooksHandler(bookExportUsecase))
+	taskProcessor.Handle(tasks.TaskEnrichBookMetadata, tasks.NewEnrichBookMetadataHandler(bookMetadataUsecase))
+	taskProcessor.Handle(tasks.TaskPurgeDeletedBooks, tasks.NewPurgeDeletedBooksHandler(bookUsecase, cfg.Book.PurgeAfter))
+	taskProcessor.Handle(tasks.TaskNotifyBookRestocked, tasks.NewNotifyBookRestockedHandler(readingListUsecase))
+	taskProcessor.Handle(tasks.TaskProcessBookHolds, tasks.NewProcessBookHoldsHandler(holdUsecase))
+	taskProcessor.Handle(tasks.TaskExpireBookHolds, tasks.NewExpireBookHoldsHandler(holdUsecase))
+	taskProcessor.Handle(tasks.TaskProcessOverdueLoans, tasks.NewProcessOverdueLoansHandler(orderUsecase))
+	taskProcessor.Handle(tasks.TaskExpireUnpaidOrders, tasks.NewExpireUnpaidOrdersHandler(orderUsecase))
+	taskProcessor.Handle(tasks.TaskReconcilePayments, tasks.NewReconcilePaymentsHandler(paymentUsecase))
+
+	runTaskProcessor(ctx, waitGroup, taskProcessor)
+
+	taskScheduler := worker.NewTaskScheduler()
+	if err := taskScheduler.Register(cfg.Book.PurgeSchedule, tasks.TaskPurgeDeletedBooks, time.Hour); err != nil {
+		log.Fatal().Err(err).Msg("failed to schedule book purge")
+		return
+	}
+
+	if err := taskScheduler.Register(cfg.Hold.ExpirySchedule, tasks.TaskExpireBookHolds, time.Minute); err != nil {
+		log.Fatal().Err(err).Msg("failed to schedule hold expiry")
+		return
+	}
+
+	if err := taskScheduler.Register(cfg.Fine.Schedule, tasks.TaskProcessOverdueLoans, 30*time.Minute); err != nil {
+		log.Fatal().Err(err).Msg("failed to schedule overdue loan processing")
+		return
+	}
+
+	if err := taskScheduler.Register(cfg.Order.ExpirySchedule, tasks.TaskExpireUnpaidOrders, 5*time.Minute); err != nil {
+		log.Fatal().Err(err).Msg("failed to schedule unpaid order expiry")
+		return
+	}
+
+	if err := taskScheduler.Register(cfg.Payment.ReconcileSchedule, tasks.TaskReconcilePayments, time.Hour); err != nil {
+		log.Fatal().Err(err).Msg("failed to schedule payment reconciliation")
+		return
+	}
+
+	runTaskScheduler(ctx, waitGroup, taskScheduler)
 
 	if err := srv.Run(ctx); err != nil {
 		if err == http.ErrServerClosed {
@@ -104,10 +201,17 @@ func initRedisTaskDistributor(cfg *config.Config) (tasks.TaskDistributor, error)
 	return tasks.NewRedisTaskDistributor(redisOpt), nil
 }
 
+func initStorage(cfg *config.Config) (storage.Uploader, error) {
+	if cfg.Storage.Driver == "s3" {
+		return storage.NewS3Client(cfg.AWS)
+	}
+
+	return storage.NewLocalStorage(cfg.Storage)
+}
+
 func runTaskProcessor(ctx context.Context,
-	waitGroup *errgroup.Group) {
+	waitGroup *errgroup.Group, taskProcessor *worker.RedisTaskProcessor) {
 
-	taskProcessor := worker.NewTaskProcessor()
 	waitGroup.Go(func() error {
 		if err := taskProcessor.Start(); err != nil {
 			log.Fatal().Err(err).Msg("failed to start task processor")
@@ -126,3 +230,48 @@ func runTaskProcessor(ctx context.Context,
 		return nil
 	})
 }
+
+func runTaskScheduler(ctx context.Context,
+	waitGroup *errgroup.Group, taskScheduler *worker.RedisTaskScheduler) {
+
+	waitGroup.Go(func() error {
+		if err := taskScheduler.Start(); err != nil {
+			log.Fatal().Err(err).Msg("failed to start task scheduler")
+			return err
+		}
+		return nil
+	})
+
+	waitGroup.Go(func() error {
+		<-ctx.Done()
+		log.Info().Msg("graceful shutdown task scheduler")
+
+		taskScheduler.Shutdown()
+		log.Info().Msg("task scheduler is stopped")
+
+		return nil
+	})
+}
+
+// runFakePaymentGateway serves the checkout pages of the fake gateway next to the API
+func runFakePaymentGateway(ctx context.Context,
+	waitGroup *errgroup.Group, fake *paymentgateway.FakeGateway, addr string) {
+
+	server := &http.Server{Addr: addr, Handler: fake.Handler()}
+
+	waitGroup.Go(func() error {
+		log.Info().Str("addr", addr).Msg("fake payment gateway checkout is running")
+
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Error().Err(err).Msg("fake payment gateway stopped")
+			return err
+		}
+		return nil
+	})
+
+	waitGroup.Go(func() error {
+		<-ctx.Done()
+
+		return server.Shutdown(context.Background())
+	})
+}
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE books
+    ADD COLUMN IF NOT EXISTS "image_url" VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS "thumbnail_url" VARCHAR(255);
+
+CREATE TABLE IF NOT EXISTS book_image_renditions (
+    "id" SERIAL NOT NULL PRIMARY KEY,
+    "book_id" INT NOT NULL,
+    "variant" VARCHAR(50) NOT NULL,
+    "format" VARCHAR(10) NOT NULL,
+    "width" INT NOT NULL,
+    "height" INT NOT NULL,
+    "url" VARCHAR(255) NOT NULL,
+    "created_at" TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE(book_id, variant, format),
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS book_image_renditions;
+ALTER TABLE books
+    DROP COLUMN IF EXISTS "thumbnail_url",
+    DROP COLUMN IF EXISTS "image_url";
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS book_imports (
+    "id" SERIAL NOT NULL PRIMARY KEY,
+    "user_id" INT NOT NULL,
+    "filename" VARCHAR(255) NOT NULL,
+    "format" VARCHAR(10) NOT NULL CHECK (format IN ('csv', 'onix')),
+    "status" VARCHAR(20) NOT NULL CHECK (status IN ('pending', 'processing', 'completed', 'failed')),
+    "source_key" VARCHAR(255) NOT NULL,
+    "total_rows" INT NOT NULL DEFAULT 0,
+    "processed_rows" INT NOT NULL DEFAULT 0,
+    "success_rows" INT NOT NULL DEFAULT 0,
+    "failed_rows" INT NOT NULL DEFAULT 0,
+    "report_key" VARCHAR(255),
+    "error_message" TEXT,
+    "created_at" TIMESTAMPTZ DEFAULT NOW(),
+    "updated_at" TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+INSERT INTO permissions (name, display_name, description)
+VALUES ('book:import', 'Import Books', 'Bulk import books from CSV or ONIX files')
+ON CONFLICT (name) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM permissions WHERE name = 'book:import';
+DROP TABLE book_imports;
+-- +goose StatementEnd
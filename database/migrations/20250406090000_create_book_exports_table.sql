@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS book_exports (
+    "id" SERIAL NOT NULL PRIMARY KEY,
+    "user_id" INT NOT NULL,
+    "format" VARCHAR(10) NOT NULL CHECK (format IN ('csv', 'ndjson', 'marc')),
+    "status" VARCHAR(20) NOT NULL CHECK (status IN ('pending', 'processing', 'completed', 'failed')),
+    "filter" JSONB NOT NULL DEFAULT '{}',
+    "file_key" VARCHAR(255),
+    "total_rows" INT NOT NULL DEFAULT 0,
+    "error_message" TEXT,
+    "created_at" TIMESTAMPTZ DEFAULT NOW(),
+    "updated_at" TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+INSERT INTO permissions (name, display_name, description)
+VALUES ('book:export', 'Export Books', 'Export the catalog as CSV, JSON Lines or MARC 21')
+ON CONFLICT (name) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM permissions WHERE name = 'book:export';
+DROP TABLE book_exports;
+-- +goose StatementEnd
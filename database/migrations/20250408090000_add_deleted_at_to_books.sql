@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE books ADD COLUMN IF NOT EXISTS "deleted_at" TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_books_deleted_at ON books (deleted_at) WHERE deleted_at IS NOT NULL;
+
+INSERT INTO permissions (name, display_name, description)
+VALUES ('book:restore', 'Restore Books', 'View and restore deleted books')
+ON CONFLICT (name) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM permissions WHERE name = 'book:restore';
+DROP INDEX IF EXISTS idx_books_deleted_at;
+ALTER TABLE books DROP COLUMN deleted_at;
+-- +goose StatementEnd
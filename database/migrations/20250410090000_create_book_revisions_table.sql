@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS book_revisions (
+    "id" SERIAL NOT NULL PRIMARY KEY,
+    "book_id" INT NOT NULL,
+    "action" VARCHAR(20) NOT NULL CHECK (action IN ('create', 'update', 'delete', 'restore', 'revert')),
+    "actor_id" INT,
+    "changes" JSONB NOT NULL DEFAULT '{}',
+    "created_at" TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+    FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_book_revisions_book_id ON book_revisions (book_id, id);
+
+INSERT INTO permissions (name, display_name, description)
+VALUES ('book:revert', 'Revert Books', 'Revert a book to an earlier revision')
+ON CONFLICT (name) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM permissions WHERE name = 'book:revert';
+DROP TABLE book_revisions;
+-- +goose StatementEnd
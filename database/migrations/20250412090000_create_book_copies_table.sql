@@ -0,0 +1,54 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS book_copies (
+    "id" SERIAL NOT NULL PRIMARY KEY,
+    "book_id" INT NOT NULL,
+    "barcode" VARCHAR(50) NOT NULL UNIQUE,
+    "condition" VARCHAR(20) NOT NULL DEFAULT 'good' CHECK (condition IN ('new', 'good', 'fair', 'poor', 'damaged')),
+    "status" VARCHAR(20) NOT NULL DEFAULT 'available' CHECK (status IN ('available', 'on_loan', 'lost', 'repair')),
+    "location" VARCHAR(100),
+    "created_at" TIMESTAMPTZ DEFAULT NOW(),
+    "updated_at" TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_book_copies_book_id_status ON book_copies (book_id, status);
+
+ALTER TABLE order_details ADD COLUMN IF NOT EXISTS "book_copy_id" INT REFERENCES book_copies(id);
+
+-- the counters are derived from the copies from now on, a book without copies has no stock
+ALTER TABLE books DROP CONSTRAINT IF EXISTS books_total_stock_check;
+ALTER TABLE books ADD CONSTRAINT books_total_stock_check CHECK (total_stock >= 0);
+ALTER TABLE books ALTER COLUMN total_stock SET DEFAULT 0;
+ALTER TABLE books ALTER COLUMN in_stock SET DEFAULT 0;
+
+-- every book gets one copy per counted unit, labelled LIB-<book id>-<n>
+INSERT INTO book_copies (book_id, barcode)
+SELECT b.id, format('LIB-%s-%s', lpad(b.id::text, 6, '0'), lpad(n::text, 3, '0'))
+FROM books b
+CROSS JOIN LATERAL generate_series(1, b.total_stock) AS n
+ON CONFLICT (barcode) DO NOTHING;
+
+UPDATE books b
+SET in_stock = GREATEST(
+        (SELECT COUNT(1) FROM book_copies c WHERE c.book_id = b.id AND c.status = 'available')
+        - (SELECT COUNT(1) FROM order_details od JOIN orders o ON o.id = od.order_id
+           WHERE od.book_id = b.id AND od.book_copy_id IS NULL AND o.payment_status IN ('Pending', 'Paid')),
+        0);
+
+INSERT INTO permissions (name, display_name, description)
+VALUES ('book:copies', 'Manage Book Copies', 'Add, update and remove the physical copies of a book'),
+       ('order:fulfill', 'Fulfill Orders', 'Hand out a physical copy for a paid order')
+ON CONFLICT (name) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM permissions WHERE name IN ('book:copies', 'order:fulfill');
+ALTER TABLE order_details DROP COLUMN book_copy_id;
+DROP TABLE book_copies;
+ALTER TABLE books ALTER COLUMN total_stock DROP DEFAULT;
+ALTER TABLE books ALTER COLUMN in_stock DROP DEFAULT;
+ALTER TABLE books DROP CONSTRAINT IF EXISTS books_total_stock_check;
+ALTER TABLE books ADD CONSTRAINT books_total_stock_check CHECK (total_stock >= 1) NOT VALID;
+-- +goose StatementEnd
@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS book_reviews (
+    "id" SERIAL NOT NULL PRIMARY KEY,
+    "book_id" INT NOT NULL,
+    "user_id" INT NOT NULL,
+    "rating" SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    "body" TEXT,
+    "hidden_at" TIMESTAMPTZ,
+    "hidden_by" INT,
+    "created_at" TIMESTAMPTZ DEFAULT NOW(),
+    "updated_at" TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (book_id, user_id),
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (hidden_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_book_reviews_book_id ON book_reviews (book_id, id) WHERE hidden_at IS NULL;
+
+-- visible reviews only, kept up to date by every review change instead of being recounted
+ALTER TABLE books ADD COLUMN IF NOT EXISTS "rating_count" INT NOT NULL DEFAULT 0;
+ALTER TABLE books ADD COLUMN IF NOT EXISTS "rating_sum" INT NOT NULL DEFAULT 0;
+ALTER TABLE books ADD COLUMN IF NOT EXISTS "average_rating" NUMERIC(3, 2) NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_books_average_rating ON books (average_rating DESC, rating_count DESC);
+CREATE INDEX IF NOT EXISTS idx_books_rating_count ON books (rating_count DESC);
+
+INSERT INTO permissions (name, display_name, description)
+VALUES ('review:moderate', 'Moderate Reviews', 'Hide and unhide book reviews')
+ON CONFLICT (name) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM permissions WHERE name = 'review:moderate';
+DROP INDEX IF EXISTS idx_books_rating_count;
+DROP INDEX IF EXISTS idx_books_average_rating;
+ALTER TABLE books DROP COLUMN average_rating;
+ALTER TABLE books DROP COLUMN rating_sum;
+ALTER TABLE books DROP COLUMN rating_count;
+DROP TABLE book_reviews;
+-- +goose StatementEnd
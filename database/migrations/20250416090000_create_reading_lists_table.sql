@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS reading_lists (
+    "id" SERIAL NOT NULL PRIMARY KEY,
+    "user_id" INT NOT NULL,
+    "name" VARCHAR(100) NOT NULL,
+    "is_public" BOOLEAN NOT NULL DEFAULT FALSE,
+    "is_default" BOOLEAN NOT NULL DEFAULT FALSE,
+    "created_at" TIMESTAMPTZ DEFAULT NOW(),
+    "updated_at" TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (user_id, name),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- the wishlist, every user has at most one
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reading_lists_default ON reading_lists (user_id) WHERE is_default;
+
+CREATE TABLE IF NOT EXISTS reading_list_items (
+    "id" SERIAL NOT NULL PRIMARY KEY,
+    "reading_list_id" INT NOT NULL,
+    "book_id" INT NOT NULL,
+    "position" INT NOT NULL,
+    "created_at" TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (reading_list_id, book_id),
+    FOREIGN KEY (reading_list_id) REFERENCES reading_lists(id) ON DELETE CASCADE,
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_reading_list_items_book_id ON reading_list_items (book_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE reading_list_items;
+DROP TABLE reading_lists;
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE book_copies DROP CONSTRAINT IF EXISTS book_copies_status_check;
+ALTER TABLE book_copies ADD CONSTRAINT book_copies_status_check CHECK (status IN ('available', 'on_loan', 'on_hold', 'lost', 'repair'));
+
+CREATE TABLE IF NOT EXISTS book_holds (
+    "id" SERIAL NOT NULL PRIMARY KEY,
+    "book_id" INT NOT NULL,
+    "user_id" INT NOT NULL,
+    "status" VARCHAR(20) NOT NULL DEFAULT 'waiting' CHECK (status IN ('waiting', 'ready', 'claimed', 'expired', 'cancelled')),
+    "book_copy_id" INT,
+    "ready_at" TIMESTAMPTZ,
+    "expires_at" TIMESTAMPTZ,
+    "created_at" TIMESTAMPTZ DEFAULT NOW(),
+    "updated_at" TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (book_copy_id) REFERENCES book_copies(id)
+);
+
+-- a user stands in the queue for a book at most once
+CREATE UNIQUE INDEX IF NOT EXISTS idx_book_holds_active ON book_holds (book_id, user_id) WHERE status IN ('waiting', 'ready');
+CREATE INDEX IF NOT EXISTS idx_book_holds_queue ON book_holds (book_id, status, created_at);
+CREATE INDEX IF NOT EXISTS idx_book_holds_expires_at ON book_holds (expires_at) WHERE status = 'ready';
+CREATE INDEX IF NOT EXISTS idx_book_holds_user_id ON book_holds (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE book_holds;
+
+UPDATE book_copies SET status = 'available' WHERE status = 'on_hold';
+
+ALTER TABLE book_copies DROP CONSTRAINT IF EXISTS book_copies_status_check;
+ALTER TABLE book_copies ADD CONSTRAINT book_copies_status_check CHECK (status IN ('available', 'on_loan', 'lost', 'repair'));
+-- +goose StatementEnd
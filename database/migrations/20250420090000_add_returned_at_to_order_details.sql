@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE order_details ADD COLUMN IF NOT EXISTS "returned_at" TIMESTAMPTZ;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS "completed_at" TIMESTAMPTZ;
+
+-- a copy is out on at most one loan at a time
+CREATE UNIQUE INDEX IF NOT EXISTS idx_order_details_open_loan ON order_details (book_copy_id)
+    WHERE book_copy_id IS NOT NULL AND returned_at IS NULL;
+
+INSERT INTO permissions (name, display_name, description)
+VALUES ('order:checkin', 'Check In Order Items', 'Take back borrowed copies and close their loans')
+ON CONFLICT (name) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM permissions WHERE name = 'order:checkin';
+
+DROP INDEX IF EXISTS idx_order_details_open_loan;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS "completed_at";
+ALTER TABLE order_details DROP COLUMN IF EXISTS "returned_at";
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE order_details ADD COLUMN IF NOT EXISTS "renewal_count" INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS order_detail_renewals (
+    "id" SERIAL NOT NULL PRIMARY KEY,
+    "order_detail_id" INT NOT NULL,
+    "previous_return_date" TIMESTAMPTZ NOT NULL,
+    "new_return_date" TIMESTAMPTZ NOT NULL,
+    "created_at" TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (order_detail_id) REFERENCES order_details(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_detail_renewals_order_detail_id ON order_detail_renewals (order_detail_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE order_detail_renewals;
+ALTER TABLE order_details DROP COLUMN IF EXISTS "renewal_count";
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE order_details ADD COLUMN IF NOT EXISTS "reminded_at" TIMESTAMPTZ;
+ALTER TABLE order_details ADD COLUMN IF NOT EXISTS "overdue_at" TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_order_details_open_return_date ON order_details (return_date) WHERE returned_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS fines (
+    "id" SERIAL NOT NULL PRIMARY KEY,
+    "user_id" INT NOT NULL,
+    "order_detail_id" INT NOT NULL,
+    "kind" VARCHAR(20) NOT NULL DEFAULT 'late_fee' CHECK (kind IN ('late_fee')),
+    "amount" NUMERIC(12, 2) NOT NULL CHECK (amount > 0),
+    -- the day the fee was charged for, one fee per loan and day
+    "accrued_for" DATE NOT NULL,
+    "paid_at" TIMESTAMPTZ,
+    "created_at" TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (order_detail_id, accrued_for),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (order_detail_id) REFERENCES order_details(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_fines_user_id_outstanding ON fines (user_id) WHERE paid_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE fines;
+
+DROP INDEX IF EXISTS idx_order_details_open_return_date;
+
+ALTER TABLE order_details DROP COLUMN IF EXISTS "overdue_at";
+ALTER TABLE order_details DROP COLUMN IF EXISTS "reminded_at";
+-- +goose StatementEnd
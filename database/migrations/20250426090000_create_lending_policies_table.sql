@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS lending_policies (
+    "id" SERIAL NOT NULL PRIMARY KEY,
+    -- NULL is the default policy for users whose roles have none
+    "role_id" INT UNIQUE,
+    -- a user with several roles gets the policy with the highest priority
+    "priority" INT NOT NULL DEFAULT 0,
+    "max_loans" INT NOT NULL CHECK (max_loans > 0),
+    "loan_days" INT NOT NULL CHECK (loan_days > 0),
+    "max_renewals" INT NOT NULL CHECK (max_renewals >= 0),
+    "daily_fine" NUMERIC(12, 2) NOT NULL CHECK (daily_fine >= 0),
+    "price_multiplier" NUMERIC(5, 2) NOT NULL DEFAULT 1 CHECK (price_multiplier >= 0),
+    "created_at" TIMESTAMPTZ DEFAULT NOW(),
+    "updated_at" TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_lending_policies_default ON lending_policies ((role_id IS NULL)) WHERE role_id IS NULL;
+
+-- the limits that used to be hard coded
+INSERT INTO lending_policies (role_id, priority, max_loans, loan_days, max_renewals, daily_fine, price_multiplier)
+VALUES (NULL, 0, 3, 7, 2, 1000, 1);
+
+INSERT INTO permissions (name, display_name, description)
+VALUES ('lending_policy:manage', 'Manage Lending Policies', 'Set loan limits, durations, renewals and fines per role')
+ON CONFLICT (name) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM permissions WHERE name = 'lending_policy:manage';
+DROP TABLE lending_policies;
+-- +goose StatementEnd
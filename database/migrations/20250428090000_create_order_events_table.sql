@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+-- payment_status keeps the outcome of the payment, status is where the order is in its lifecycle
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS "status" VARCHAR(25) NOT NULL DEFAULT 'pending_payment'
+    CHECK (status IN ('pending_payment', 'paid', 'ready_for_pickup', 'on_loan', 'returned', 'cancelled', 'expired'));
+
+UPDATE orders o
+SET status = CASE
+    WHEN o.completed_at IS NOT NULL THEN 'returned'
+    WHEN o.payment_status = 'Failed' THEN 'cancelled'
+    WHEN o.payment_status = 'Paid' AND NOT EXISTS (
+        SELECT 1 FROM order_details od WHERE od.order_id = o.id AND od.book_copy_id IS NULL
+    ) THEN 'on_loan'
+    WHEN o.payment_status = 'Paid' THEN 'paid'
+    ELSE 'pending_payment'
+END;
+
+CREATE TABLE IF NOT EXISTS order_events (
+    "id" SERIAL NOT NULL PRIMARY KEY,
+    "order_id" INT NOT NULL,
+    "from_status" VARCHAR(25),
+    "to_status" VARCHAR(25) NOT NULL,
+    -- NULL when the change came from the system, e.g. the payment gateway or a scheduled job
+    "actor_id" INT,
+    "reason" TEXT NOT NULL,
+    "created_at" TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_events_order_id ON order_events (order_id, created_at);
+
+INSERT INTO order_events (order_id, from_status, to_status, actor_id, reason, created_at)
+SELECT id, NULL, status, NULL, 'status recorded when the order lifecycle was introduced', COALESCE(updated_at, created_at)
+FROM orders;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE order_events;
+ALTER TABLE orders DROP COLUMN IF EXISTS "status";
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+-- unit_price is the price of the book when it was ordered, fee what the borrower pays for it under their lending policy
+ALTER TABLE order_details ADD COLUMN IF NOT EXISTS "unit_price" NUMERIC(12, 2) NOT NULL DEFAULT 0;
+ALTER TABLE order_details ADD COLUMN IF NOT EXISTS "fee" NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (fee >= 0);
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS "subtotal" NUMERIC(12, 2) NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS "discount" NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (discount >= 0);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS "total" NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (total >= 0);
+
+UPDATE order_details od
+SET unit_price = COALESCE(b.price, 0), fee = COALESCE(b.price, 0)
+FROM books b
+WHERE b.id = od.book_id;
+
+UPDATE orders o
+SET subtotal = t.subtotal, total = t.subtotal
+FROM (SELECT order_id, SUM(fee) AS subtotal FROM order_details GROUP BY order_id) t
+WHERE t.order_id = o.id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE orders DROP COLUMN IF EXISTS "total";
+ALTER TABLE orders DROP COLUMN IF EXISTS "discount";
+ALTER TABLE orders DROP COLUMN IF EXISTS "subtotal";
+ALTER TABLE order_details DROP COLUMN IF EXISTS "fee";
+ALTER TABLE order_details DROP COLUMN IF EXISTS "unit_price";
+-- +goose StatementEnd
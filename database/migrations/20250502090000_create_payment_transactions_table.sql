@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+-- every exchange with a payment gateway about an order, rows are only ever added
+CREATE TABLE IF NOT EXISTS payment_transactions (
+    "id" BIGSERIAL PRIMARY KEY,
+    "order_id" BIGINT NOT NULL,
+    "gateway" VARCHAR(32) NOT NULL,
+    "source" VARCHAR(32) NOT NULL CHECK (source IN ('create', 'check', 'notification')),
+    "external_id" VARCHAR(255),
+    "amount" NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    "currency" VARCHAR(3) NOT NULL DEFAULT 'IDR',
+    "status" VARCHAR(32) NOT NULL,
+    "raw_payload" JSONB,
+    "created_at" TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_transactions_order_id ON payment_transactions (order_id, created_at);
+
+INSERT INTO permissions (name, display_name, description)
+VALUES ('payment:view', 'View Payments', 'See every payment attempt of an order as the gateway reported it')
+ON CONFLICT (name) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM permissions WHERE name = 'payment:view';
+DROP TABLE payment_transactions;
+-- +goose StatementEnd
@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+ALTER TABLE orders ADD CONSTRAINT orders_status_check
+    CHECK (status IN ('pending_payment', 'paid', 'ready_for_pickup', 'on_loan', 'returned', 'cancelled', 'expired', 'refunded'));
+
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_payment_status_check;
+ALTER TABLE orders ADD CONSTRAINT orders_payment_status_check
+    CHECK (payment_status IN ('Pending', 'Paid', 'Failed', 'Refunded'));
+
+-- refunds are ledger rows too, what was refunded so far is their sum
+ALTER TABLE payment_transactions DROP CONSTRAINT IF EXISTS payment_transactions_source_check;
+ALTER TABLE payment_transactions ADD CONSTRAINT payment_transactions_source_check
+    CHECK (source IN ('create', 'check', 'notification', 'refund'));
+
+INSERT INTO permissions (name, display_name, description)
+VALUES ('payment:refund', 'Refund Payments', 'Give back all or part of what was paid for an order')
+ON CONFLICT (name) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM permissions WHERE name = 'payment:refund';
+
+DELETE FROM payment_transactions WHERE source = 'refund';
+ALTER TABLE payment_transactions DROP CONSTRAINT IF EXISTS payment_transactions_source_check;
+ALTER TABLE payment_transactions ADD CONSTRAINT payment_transactions_source_check
+    CHECK (source IN ('create', 'check', 'notification'));
+
+UPDATE orders SET status = 'cancelled' WHERE status = 'refunded';
+UPDATE orders SET payment_status = 'Paid' WHERE payment_status = 'Refunded';
+
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_payment_status_check;
+ALTER TABLE orders ADD CONSTRAINT orders_payment_status_check
+    CHECK (payment_status IN ('Pending', 'Paid', 'Failed'));
+
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+ALTER TABLE orders ADD CONSTRAINT orders_status_check
+    CHECK (status IN ('pending_payment', 'paid', 'ready_for_pickup', 'on_loan', 'returned', 'cancelled', 'expired'));
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- a run comparing recent orders with the gateway, the discrepancies found are in the report at file_key
+CREATE TABLE IF NOT EXISTS payment_reconciliations (
+    "id" BIGSERIAL PRIMARY KEY,
+    "gateway" VARCHAR(32) NOT NULL,
+    "status" VARCHAR(20) NOT NULL CHECK (status IN ('processing', 'completed', 'failed')),
+    "since" TIMESTAMPTZ NOT NULL,
+    "checked" INT NOT NULL DEFAULT 0,
+    "fixed" INT NOT NULL DEFAULT 0,
+    "discrepancies" INT NOT NULL DEFAULT 0,
+    "file_key" VARCHAR(255),
+    "error_message" TEXT,
+    "created_at" TIMESTAMPTZ DEFAULT NOW(),
+    "updated_at" TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_created_at ON orders (created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_orders_created_at;
+DROP TABLE payment_reconciliations;
+-- +goose StatementEnd
@@ -0,0 +1,68 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS promotions (
+    "id" BIGSERIAL PRIMARY KEY,
+    -- codes are kept upper case, users may type them any way they like
+    "code" VARCHAR(50) NOT NULL UNIQUE,
+    "description" TEXT NOT NULL DEFAULT '',
+    "discount_type" VARCHAR(20) NOT NULL CHECK (discount_type IN ('percentage', 'fixed')),
+    "discount_value" NUMERIC(12, 2) NOT NULL CHECK (discount_value > 0),
+    "min_subtotal" NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (min_subtotal >= 0),
+    "starts_at" TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    "ends_at" TIMESTAMPTZ,
+    -- NULL is no limit, usage_limit counts every user and per_user_limit each one
+    "usage_limit" INT CHECK (usage_limit > 0),
+    "per_user_limit" INT CHECK (per_user_limit > 0),
+    "first_order_only" BOOLEAN NOT NULL DEFAULT FALSE,
+    "active" BOOLEAN NOT NULL DEFAULT TRUE,
+    "created_at" TIMESTAMPTZ DEFAULT NOW(),
+    "updated_at" TIMESTAMPTZ DEFAULT NOW(),
+    CHECK (discount_type <> 'percentage' OR discount_value <= 100),
+    CHECK (ends_at IS NULL OR ends_at > starts_at)
+);
+
+-- a promotion without categories and books applies to the whole order, otherwise only to the books in scope
+CREATE TABLE IF NOT EXISTS promotion_categories (
+    "promotion_id" BIGINT NOT NULL,
+    "category_id" INT NOT NULL,
+    PRIMARY KEY (promotion_id, category_id),
+    FOREIGN KEY (promotion_id) REFERENCES promotions(id) ON DELETE CASCADE,
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS promotion_books (
+    "promotion_id" BIGINT NOT NULL,
+    "book_id" INT NOT NULL,
+    PRIMARY KEY (promotion_id, book_id),
+    FOREIGN KEY (promotion_id) REFERENCES promotions(id) ON DELETE CASCADE,
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
+);
+
+-- an order takes at most one promotion, the redemptions of cancelled and expired orders do not count
+CREATE TABLE IF NOT EXISTS promotion_redemptions (
+    "id" BIGSERIAL PRIMARY KEY,
+    "promotion_id" BIGINT NOT NULL,
+    "order_id" INT NOT NULL UNIQUE,
+    "user_id" INT NOT NULL,
+    "discount" NUMERIC(12, 2) NOT NULL CHECK (discount >= 0),
+    "created_at" TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (promotion_id) REFERENCES promotions(id) ON DELETE RESTRICT,
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_promotion_redemptions_promotion_user ON promotion_redemptions (promotion_id, user_id);
+
+INSERT INTO permissions (name, display_name, description)
+VALUES ('promotion:manage', 'Manage Promotions', 'Create, change and retire discount codes')
+ON CONFLICT (name) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM permissions WHERE name = 'promotion:manage';
+DROP TABLE promotion_redemptions;
+DROP TABLE promotion_books;
+DROP TABLE promotion_categories;
+DROP TABLE promotions;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the one place a user's lending policy is picked: the policy of their role with the highest priority, or the
+-- default policy when none of their roles has one
+CREATE OR REPLACE FUNCTION lending_policy_for_user(p_user_id INT) RETURNS INT
+LANGUAGE sql STABLE AS $$
+    SELECT lp.id
+    FROM lending_policies lp
+    WHERE lp.role_id IS NULL
+        OR lp.role_id IN (SELECT ur.role_id FROM user_role ur WHERE ur.user_id = p_user_id)
+    ORDER BY lp.role_id IS NULL, lp.priority DESC, lp.id
+    LIMIT 1
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION IF EXISTS lending_policy_for_user(INT);
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- paid_at is when a fine stopped counting as outstanding, settlement tells whether it was paid at the desk or waived
+ALTER TABLE fines ADD COLUMN IF NOT EXISTS "settlement" VARCHAR(10) CHECK (settlement IN ('paid', 'waived'));
+ALTER TABLE fines ADD COLUMN IF NOT EXISTS "settled_by" INT REFERENCES users(id) ON DELETE SET NULL;
+
+INSERT INTO permissions (name, display_name, description)
+VALUES ('fine:settle', 'Settle Fines', 'Record fines as paid or waive them')
+ON CONFLICT (name) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM permissions WHERE name = 'fine:settle';
+
+ALTER TABLE fines DROP COLUMN IF EXISTS "settled_by";
+ALTER TABLE fines DROP COLUMN IF EXISTS "settlement";
+-- +goose StatementEnd
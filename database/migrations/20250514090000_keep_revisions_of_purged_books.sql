@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- purging a book keeps its history, the revisions lose their book but still carry the title and isbn it had
+ALTER TABLE book_revisions DROP CONSTRAINT IF EXISTS book_revisions_book_id_fkey;
+ALTER TABLE book_revisions ALTER COLUMN "book_id" DROP NOT NULL;
+ALTER TABLE book_revisions
+    ADD CONSTRAINT book_revisions_book_id_fkey FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE SET NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM book_revisions WHERE book_id IS NULL;
+
+ALTER TABLE book_revisions DROP CONSTRAINT IF EXISTS book_revisions_book_id_fkey;
+ALTER TABLE book_revisions ALTER COLUMN "book_id" SET NOT NULL;
+ALTER TABLE book_revisions
+    ADD CONSTRAINT book_revisions_book_id_fkey FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE;
+-- +goose StatementEnd
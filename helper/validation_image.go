@@ -6,6 +6,7 @@ import (
 	"image"
 	_ "image/jpeg" // Registrasi format gambar
 	_ "image/png"
+	"io"
 	"mime/multipart"
 	"path/filepath"
 	"strings"
@@ -41,6 +42,11 @@ func ValidateImageFile(file *multipart.FileHeader) error {
 
 	contentType, _ := mimetype.DetectReader(src)
 
+	// Kembalikan posisi baca ke awal sebelum decode
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("gagal membaca ulang file: %w", err)
+	}
+
 	if !strings.HasPrefix(contentType.String(), "image/") {
 		errMsg := fmt.Sprintf("tipe MIME file tidak sesuai: %s", contentType.String())
 		log.Error().Str("filename", file.Filename).Str("mime_type", contentType.String()).Msg(errMsg)
@@ -0,0 +1,135 @@
+package catalog
+
+import (
+	"backend-layout/internal/domain"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CSVColumns are the columns understood by the CSV importer, categories are separated by ";"
+var CSVColumns = []string{"title", "author", "publisher", "publish_year", "total_page", "price", "isbn", "description", "categories"}
+
+// stock is read when the file has it but is not exported, the stock of a book is counted from its copies
+var requiredCSVColumns = []string{"title", "author", "publisher", "publish_year", "total_page", "price", "isbn", "categories"}
+
+type csvReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+	line    int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+
+	for _, name := range requiredCSVColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing csv column: %s", name)
+		}
+	}
+
+	return &csvReader{reader: reader, columns: columns, line: 1}, nil
+}
+
+func (c *csvReader) Next() (*domain.ImportBookRow, error) {
+	record, err := c.reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+
+		// a malformed line is reported as a row error, the reader can carry on with the next one
+		if _, ok := err.(*csv.ParseError); ok {
+			c.line++
+			return &domain.ImportBookRow{Line: c.line, ParseErrors: []string{err.Error()}}, nil
+		}
+
+		return nil, err
+	}
+
+	c.line++
+
+	row := &domain.ImportBookRow{
+		Line:        c.line,
+		Title:       c.value(record, "title"),
+		AuthorName:  c.value(record, "author"),
+		Publisher:   c.value(record, "publisher"),
+		Isbn:        normalizeISBN(c.value(record, "isbn")),
+		Description: c.value(record, "description"),
+		Categories:  splitList(c.value(record, "categories")),
+	}
+
+	row.PublishYear = c.parseInt(row, record, "publish_year")
+	row.TotalPage = c.parseInt(row, record, "total_page")
+	row.Price = c.parseFloat(row, record, "price")
+	row.Stock = c.parseInt(row, record, "stock")
+
+	return row, nil
+}
+
+func (c *csvReader) value(record []string, column string) string {
+	i, ok := c.columns[column]
+	if !ok || i >= len(record) {
+		return ""
+	}
+
+	return strings.TrimSpace(record[i])
+}
+
+func (c *csvReader) parseInt(row *domain.ImportBookRow, record []string, column string) int {
+	v := c.value(record, column)
+	if v == "" {
+		return 0
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		row.ParseErrors = append(row.ParseErrors, fmt.Sprintf("%s must be a whole number", column))
+	}
+
+	return n
+}
+
+func (c *csvReader) parseFloat(row *domain.ImportBookRow, record []string, column string) float64 {
+	v := c.value(record, column)
+	if v == "" {
+		return 0
+	}
+
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		row.ParseErrors = append(row.ParseErrors, fmt.Sprintf("%s must be a number", column))
+	}
+
+	return n
+}
+
+func splitList(v string) []string {
+	result := make([]string, 0)
+
+	for _, item := range strings.Split(v, ";") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
+func normalizeISBN(isbn string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(isbn)
+}
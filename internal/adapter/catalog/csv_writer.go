@@ -0,0 +1,43 @@
+package catalog
+
+import (
+	"backend-layout/internal/domain"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// csvWriter writes the same columns the CSV importer reads, so an export can be imported again
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(CSVColumns); err != nil {
+		return nil, err
+	}
+
+	return &csvWriter{writer: writer}, nil
+}
+
+func (c *csvWriter) Write(b *domain.Book) error {
+	return c.writer.Write([]string{
+		b.Title,
+		b.Author.Name,
+		b.Publisher.Name,
+		strconv.Itoa(b.PublishYear),
+		strconv.Itoa(b.TotalPage),
+		strconv.FormatFloat(b.Price, 'f', -1, 64),
+		b.Isbn,
+		b.Description,
+		strings.Join(categoryNames(b), ";"),
+	})
+}
+
+func (c *csvWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
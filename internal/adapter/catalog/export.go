@@ -0,0 +1,111 @@
+package catalog
+
+import (
+	"backend-layout/internal/domain"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// exportPageSize is how many books are fetched per query while exporting
+const exportPageSize = 500
+
+// RecordWriter writes exported books one at a time, Close flushes anything still buffered
+type RecordWriter interface {
+	Write(book *domain.Book) error
+	Close() error
+}
+
+func NewRecordWriter(format string, w io.Writer) (RecordWriter, error) {
+	switch format {
+	case domain.ExportFormatCSV:
+		return newCSVWriter(w)
+	case domain.ExportFormatNDJSON:
+		return newNDJSONWriter(w), nil
+	case domain.ExportFormatMARC:
+		return newMARCWriter(w), nil
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+func IsExportFormat(format string) bool {
+	switch format {
+	case domain.ExportFormatCSV, domain.ExportFormatNDJSON, domain.ExportFormatMARC:
+		return true
+	default:
+		return false
+	}
+}
+
+func ExportContentType(format string) string {
+	switch format {
+	case domain.ExportFormatCSV:
+		return "text/csv"
+	case domain.ExportFormatNDJSON:
+		return "application/x-ndjson"
+	case domain.ExportFormatMARC:
+		return "application/marc"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+func ExportExtension(format string) string {
+	switch format {
+	case domain.ExportFormatNDJSON:
+		return ".ndjson"
+	case domain.ExportFormatMARC:
+		return ".mrc"
+	default:
+		return ".csv"
+	}
+}
+
+// ExportBooks pages through BookRepository.Fetch and writes every matching book to w,
+// so only a single page of books is held in memory at a time
+func ExportBooks(ctx context.Context, repo domain.BookRepository, format string, filter domain.BookExportFilter, w io.Writer) (total int64, err error) {
+	writer, err := NewRecordWriter(format, w)
+	if err != nil {
+		return 0, err
+	}
+
+	for page := int64(1); ; page++ {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+
+		books, _, err := repo.Fetch(ctx, filter.ToParams(page, exportPageSize))
+		if err != nil {
+			return total, fmt.Errorf("failed to fetch books page %d: %w", page, err)
+		}
+
+		for i := range books {
+			if err := writer.Write(&books[i]); err != nil {
+				return total, fmt.Errorf("failed to write book %d: %w", books[i].Id, err)
+			}
+
+			total++
+		}
+
+		if len(books) < exportPageSize {
+			break
+		}
+	}
+
+	return total, writer.Close()
+}
+
+// categoryNames splits the comma separated names aggregated by the book queries
+func categoryNames(b *domain.Book) []string {
+	result := make([]string, 0)
+
+	for _, name := range strings.Split(b.CategoryName, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			result = append(result, name)
+		}
+	}
+
+	return result
+}
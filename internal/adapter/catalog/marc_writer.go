@@ -0,0 +1,180 @@
+package catalog
+
+import (
+	"backend-layout/internal/domain"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// MARC 21 (ISO 2709) delimiters
+const (
+	marcSubfieldDelimiter = 0x1F
+	marcFieldTerminator   = 0x1E
+	marcRecordTerminator  = 0x1D
+
+	marcLeaderLength   = 24
+	marcDirEntryLength = 12
+
+	// a directory entry only has four digits for the field length
+	marcMaxFieldLength = 9999
+)
+
+type marcSubfield struct {
+	code  byte
+	value string
+}
+
+type marcField struct {
+	tag        string
+	control    string
+	indicators string
+	subfields  []marcSubfield
+}
+
+func (f marcField) bytes() []byte {
+	var buf bytes.Buffer
+
+	if f.subfields == nil {
+		buf.WriteString(marcValue(f.control, marcMaxFieldLength-1))
+		buf.WriteByte(marcFieldTerminator)
+		return buf.Bytes()
+	}
+
+	buf.WriteString(f.indicators)
+
+	for _, sf := range f.subfields {
+		buf.WriteByte(marcSubfieldDelimiter)
+		buf.WriteByte(sf.code)
+		buf.WriteString(marcValue(sf.value, marcMaxFieldLength-buf.Len()-2))
+	}
+
+	buf.WriteByte(marcFieldTerminator)
+
+	return buf.Bytes()
+}
+
+// marcWriter writes UTF-8 encoded MARC 21 bibliographic records in ISO 2709 transmission format
+type marcWriter struct {
+	buf *bufio.Writer
+}
+
+func newMARCWriter(w io.Writer) *marcWriter {
+	return &marcWriter{buf: bufio.NewWriter(w)}
+}
+
+func (m *marcWriter) Write(b *domain.Book) error {
+	fields := marcFields(b)
+
+	var (
+		directory bytes.Buffer
+		data      bytes.Buffer
+	)
+
+	for _, f := range fields {
+		field := f.bytes()
+		fmt.Fprintf(&directory, "%s%04d%05d", f.tag, len(field), data.Len())
+		data.Write(field)
+	}
+
+	directory.WriteByte(marcFieldTerminator)
+
+	baseAddress := marcLeaderLength + directory.Len()
+	recordLength := baseAddress + data.Len() + 1
+
+	if recordLength > 99999 {
+		return fmt.Errorf("marc record for book %d is too long", b.Id)
+	}
+
+	// new record, language material, monograph, UTF-8, full level
+	leader := fmt.Sprintf("%05dnam a22%05d   4500", recordLength, baseAddress)
+
+	m.buf.WriteString(leader)
+	m.buf.Write(directory.Bytes())
+	m.buf.Write(data.Bytes())
+
+	return m.buf.WriteByte(marcRecordTerminator)
+}
+
+func (m *marcWriter) Close() error {
+	return m.buf.Flush()
+}
+
+func marcFields(b *domain.Book) []marcField {
+	fields := []marcField{
+		{tag: "001", control: strconv.FormatInt(b.Id, 10)},
+		{tag: "008", control: marcFixedData(b)},
+	}
+
+	isbn := []marcSubfield{{'a', b.Isbn}}
+	if b.Price > 0 {
+		isbn = append(isbn, marcSubfield{'c', strconv.FormatFloat(b.Price, 'f', 2, 64)})
+	}
+	fields = append(fields, marcField{tag: "020", indicators: "  ", subfields: isbn})
+
+	// the first indicator of 245 tells whether the title has a main entry in 100
+	titleIndicators := "00"
+	if b.Author.Name != "" {
+		fields = append(fields, marcField{tag: "100", indicators: "1 ", subfields: []marcSubfield{{'a', b.Author.Name}}})
+		titleIndicators = "10"
+	}
+
+	fields = append(fields, marcField{tag: "245", indicators: titleIndicators, subfields: []marcSubfield{{'a', b.Title}}})
+
+	fields = append(fields, marcField{tag: "264", indicators: " 1", subfields: []marcSubfield{
+		{'b', b.Publisher.Name},
+		{'c', strconv.Itoa(b.PublishYear)},
+	}})
+
+	if b.TotalPage > 0 {
+		fields = append(fields, marcField{tag: "300", indicators: "  ", subfields: []marcSubfield{{'a', fmt.Sprintf("%d pages", b.TotalPage)}}})
+	}
+
+	if b.Description != "" {
+		fields = append(fields, marcField{tag: "520", indicators: "  ", subfields: []marcSubfield{{'a', b.Description}}})
+	}
+
+	for _, name := range categoryNames(b) {
+		fields = append(fields, marcField{tag: "650", indicators: " 4", subfields: []marcSubfield{{'a', name}}})
+	}
+
+	return fields
+}
+
+// marcFixedData builds the 40 character 008 field, "|" marks positions that are not coded
+func marcFixedData(b *domain.Book) string {
+	return fmt.Sprintf("%ss%04d    xx %sund d",
+		b.CreatedAt.Format("060102"),
+		b.PublishYear%10000,
+		strings.Repeat("|", 17),
+	)
+}
+
+// marcValue strips the ISO 2709 delimiters from v and truncates it to max bytes without splitting a rune
+func marcValue(v string, max int) string {
+	v = strings.Map(func(r rune) rune {
+		if r == marcSubfieldDelimiter || r == marcFieldTerminator || r == marcRecordTerminator {
+			return -1
+		}
+		return r
+	}, v)
+
+	if max < 0 {
+		return ""
+	}
+
+	if len(v) <= max {
+		return v
+	}
+
+	v = v[:max]
+	for !utf8.ValidString(v) {
+		v = v[:len(v)-1]
+	}
+
+	return v
+}
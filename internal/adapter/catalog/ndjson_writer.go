@@ -0,0 +1,60 @@
+package catalog
+
+import (
+	"backend-layout/internal/domain"
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+type ndjsonRecord struct {
+	Id            int64    `json:"id"`
+	Title         string   `json:"title"`
+	Slug          string   `json:"slug"`
+	AuthorName    string   `json:"author_name"`
+	PublisherName string   `json:"publisher_name"`
+	PublishYear   int      `json:"publish_year"`
+	TotalPage     int      `json:"total_page"`
+	Description   string   `json:"description"`
+	Sku           string   `json:"sku"`
+	Stock         int64    `json:"stock"`
+	Isbn          string   `json:"isbn"`
+	Price         float64  `json:"price"`
+	Categories    []string `json:"categories"`
+	ImageUrl      string   `json:"image_url,omitempty"`
+}
+
+// ndjsonWriter writes one JSON object per line
+type ndjsonWriter struct {
+	buf     *bufio.Writer
+	encoder *json.Encoder
+}
+
+func newNDJSONWriter(w io.Writer) *ndjsonWriter {
+	buf := bufio.NewWriter(w)
+
+	return &ndjsonWriter{buf: buf, encoder: json.NewEncoder(buf)}
+}
+
+func (n *ndjsonWriter) Write(b *domain.Book) error {
+	return n.encoder.Encode(ndjsonRecord{
+		Id:            b.Id,
+		Title:         b.Title,
+		Slug:          b.Slug,
+		AuthorName:    b.Author.Name,
+		PublisherName: b.Publisher.Name,
+		PublishYear:   b.PublishYear,
+		TotalPage:     b.TotalPage,
+		Description:   b.Description,
+		Sku:           b.Sku,
+		Stock:         b.Stock,
+		Isbn:          b.Isbn,
+		Price:         b.Price,
+		Categories:    categoryNames(b),
+		ImageUrl:      b.ImageUrl,
+	})
+}
+
+func (n *ndjsonWriter) Close() error {
+	return n.buf.Flush()
+}
@@ -0,0 +1,199 @@
+package catalog
+
+import (
+	"backend-layout/internal/domain"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ONIX 3.0 code list values used by the importer
+const (
+	onixProductIDTypeISBN13 = "15"
+	onixContributorAuthor   = "A01"
+	onixExtentMainContent   = "00"
+	onixTextTypeDescription = "03"
+	onixPublishingDateOfPub = "01"
+)
+
+type onixProduct struct {
+	Identifiers []struct {
+		Type  string `xml:"ProductIDType"`
+		Value string `xml:"IDValue"`
+	} `xml:"ProductIdentifier"`
+	Detail struct {
+		Titles []struct {
+			Elements []struct {
+				Text string `xml:"TitleText"`
+			} `xml:"TitleElement"`
+		} `xml:"TitleDetail"`
+		Contributors []struct {
+			Role       string `xml:"ContributorRole"`
+			PersonName string `xml:"PersonName"`
+			Corporate  string `xml:"CorporateName"`
+		} `xml:"Contributor"`
+		Extents []struct {
+			Type  string `xml:"ExtentType"`
+			Value string `xml:"ExtentValue"`
+		} `xml:"Extent"`
+		Subjects []struct {
+			Heading string `xml:"SubjectHeadingText"`
+		} `xml:"Subject"`
+	} `xml:"DescriptiveDetail"`
+	Collateral struct {
+		Texts []struct {
+			Type string `xml:"TextType"`
+			Text string `xml:"Text"`
+		} `xml:"TextContent"`
+	} `xml:"CollateralDetail"`
+	Publishing struct {
+		Publishers []struct {
+			Name string `xml:"PublisherName"`
+		} `xml:"Publisher"`
+		Dates []struct {
+			Role string `xml:"PublishingDateRole"`
+			Date string `xml:"Date"`
+		} `xml:"PublishingDate"`
+	} `xml:"PublishingDetail"`
+	Supply []struct {
+		Details []struct {
+			Prices []struct {
+				Amount string `xml:"PriceAmount"`
+			} `xml:"Price"`
+			Stocks []struct {
+				OnHand string `xml:"OnHand"`
+			} `xml:"Stock"`
+		} `xml:"SupplyDetail"`
+	} `xml:"ProductSupply"`
+}
+
+// onixReader streams <Product> records so large ONIX feeds are never held in memory at once
+type onixReader struct {
+	decoder *xml.Decoder
+	product int
+}
+
+func newONIXReader(r io.Reader) *onixReader {
+	return &onixReader{decoder: xml.NewDecoder(r)}
+}
+
+func (o *onixReader) Next() (*domain.ImportBookRow, error) {
+	for {
+		token, err := o.decoder.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil, io.EOF
+			}
+
+			return nil, fmt.Errorf("failed to read onix: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "Product" {
+			continue
+		}
+
+		o.product++
+
+		var p onixProduct
+		if err := o.decoder.DecodeElement(&p, &start); err != nil {
+			return nil, fmt.Errorf("failed to decode onix product %d: %w", o.product, err)
+		}
+
+		return p.toRow(o.product), nil
+	}
+}
+
+func (p *onixProduct) toRow(line int) *domain.ImportBookRow {
+	row := &domain.ImportBookRow{Line: line, Categories: make([]string, 0)}
+
+	for _, id := range p.Identifiers {
+		if id.Type == onixProductIDTypeISBN13 {
+			row.Isbn = normalizeISBN(strings.TrimSpace(id.Value))
+		}
+	}
+
+	for _, t := range p.Detail.Titles {
+		if len(t.Elements) > 0 && row.Title == "" {
+			row.Title = strings.TrimSpace(t.Elements[0].Text)
+		}
+	}
+
+	for _, c := range p.Detail.Contributors {
+		if c.Role != onixContributorAuthor || row.AuthorName != "" {
+			continue
+		}
+
+		row.AuthorName = strings.TrimSpace(c.PersonName)
+		if row.AuthorName == "" {
+			row.AuthorName = strings.TrimSpace(c.Corporate)
+		}
+	}
+
+	for _, e := range p.Detail.Extents {
+		if e.Type != onixExtentMainContent {
+			continue
+		}
+
+		pages, err := strconv.Atoi(strings.TrimSpace(e.Value))
+		if err != nil {
+			row.ParseErrors = append(row.ParseErrors, "page extent must be a whole number")
+		}
+		row.TotalPage = pages
+	}
+
+	for _, s := range p.Detail.Subjects {
+		if heading := strings.TrimSpace(s.Heading); heading != "" {
+			row.Categories = append(row.Categories, heading)
+		}
+	}
+
+	for _, t := range p.Collateral.Texts {
+		if t.Type == onixTextTypeDescription && row.Description == "" {
+			row.Description = strings.TrimSpace(t.Text)
+		}
+	}
+
+	if len(p.Publishing.Publishers) > 0 {
+		row.Publisher = strings.TrimSpace(p.Publishing.Publishers[0].Name)
+	}
+
+	for _, d := range p.Publishing.Dates {
+		date := strings.TrimSpace(d.Date)
+		if d.Role != onixPublishingDateOfPub || len(date) < 4 {
+			continue
+		}
+
+		year, err := strconv.Atoi(date[:4])
+		if err != nil {
+			row.ParseErrors = append(row.ParseErrors, "publishing date must start with a year")
+		}
+		row.PublishYear = year
+	}
+
+	for _, s := range p.Supply {
+		for _, d := range s.Details {
+			for _, st := range d.Stocks {
+				onHand, err := strconv.Atoi(strings.TrimSpace(st.OnHand))
+				if err != nil {
+					row.ParseErrors = append(row.ParseErrors, "stock on hand must be a whole number")
+				}
+				row.Stock += onHand
+			}
+
+			if len(d.Prices) == 0 || row.Price > 0 {
+				continue
+			}
+
+			price, err := strconv.ParseFloat(strings.TrimSpace(d.Prices[0].Amount), 64)
+			if err != nil {
+				row.ParseErrors = append(row.ParseErrors, "price amount must be a number")
+			}
+			row.Price = price
+		}
+	}
+
+	return row
+}
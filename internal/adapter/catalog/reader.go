@@ -0,0 +1,25 @@
+package catalog
+
+import (
+	"backend-layout/internal/domain"
+	"fmt"
+	"io"
+)
+
+// RowReader reads import rows one at a time, returning io.EOF once the input is exhausted.
+// Values that cannot be parsed are reported through ImportBookRow.ParseErrors instead of
+// failing the whole file.
+type RowReader interface {
+	Next() (*domain.ImportBookRow, error)
+}
+
+func NewRowReader(format string, r io.Reader) (RowReader, error) {
+	switch format {
+	case domain.ImportFormatCSV:
+		return newCSVReader(r)
+	case domain.ImportFormatONIX:
+		return newONIXReader(r), nil
+	default:
+		return nil, fmt.Errorf("unsupported import format: %s", format)
+	}
+}
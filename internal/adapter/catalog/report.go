@@ -0,0 +1,38 @@
+package catalog
+
+import (
+	"backend-layout/internal/domain"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ImportReportWriter writes the per-row error report of an import as CSV
+type ImportReportWriter struct {
+	writer *csv.Writer
+}
+
+func NewImportReportWriter(w io.Writer) (*ImportReportWriter, error) {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write([]string{"line", "isbn", "title", "errors"}); err != nil {
+		return nil, err
+	}
+
+	return &ImportReportWriter{writer: writer}, nil
+}
+
+func (r *ImportReportWriter) Write(rowErr domain.ImportRowError) error {
+	return r.writer.Write([]string{
+		strconv.Itoa(rowErr.Line),
+		rowErr.Isbn,
+		rowErr.Title,
+		strings.Join(rowErr.Errors, "; "),
+	})
+}
+
+func (r *ImportReportWriter) Flush() error {
+	r.writer.Flush()
+	return r.writer.Error()
+}
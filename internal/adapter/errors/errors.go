@@ -90,3 +90,7 @@ func NewUnauthorized(message string) BaseError {
 func NewInternalServerError(message string) BaseError {
 	return newBaseError(http.StatusInternalServerError, message)
 }
+
+func NewBadGatewayError(message string) BaseError {
+	return newBaseError(http.StatusBadGateway, message)
+}
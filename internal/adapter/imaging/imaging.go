@@ -0,0 +1,133 @@
+package imaging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"os/exec"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+)
+
+var ErrWebPUnavailable = errors.New("webp encoder not available")
+
+// Variant describes a resized rendition of an image. A zero Width keeps the original size.
+type Variant struct {
+	Name  string
+	Width int
+}
+
+// Decode reads an image. Only pixel data is kept, so metadata such as EXIF is dropped
+// once the image is encoded again.
+func Decode(r io.Reader) (image.Image, string, error) {
+	img, format, err := image.Decode(r)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	return img, format, nil
+}
+
+// Fit scales img down to the given width while keeping its aspect ratio.
+// Images narrower than width are returned unchanged.
+func Fit(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+
+	if width <= 0 || bounds.Dx() <= width {
+		return img
+	}
+
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+
+	return dst
+}
+
+// Encode writes img in the requested format
+func Encode(ctx context.Context, img image.Image, format string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch format {
+	case FormatJPEG:
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+			return nil, fmt.Errorf("failed to encode jpeg: %w", err)
+		}
+	case FormatPNG:
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, fmt.Errorf("failed to encode png: %w", err)
+		}
+	case FormatWebP:
+		return encodeWebP(ctx, img)
+	default:
+		return nil, fmt.Errorf("unsupported image format: %s", format)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ContentType returns the MIME type for a format
+func ContentType(format string) string {
+	return "image/" + format
+}
+
+// Extension returns the file extension for a format
+func Extension(format string) string {
+	if format == FormatJPEG {
+		return ".jpg"
+	}
+
+	return "." + format
+}
+
+// WebPAvailable reports whether the cwebp binary is installed
+func WebPAvailable() bool {
+	_, err := exec.LookPath("cwebp")
+	return err == nil
+}
+
+// encodeWebP shells out to cwebp since there is no pure Go WebP encoder
+func encodeWebP(ctx context.Context, img image.Image) ([]byte, error) {
+	bin, err := exec.LookPath("cwebp")
+	if err != nil {
+		return nil, ErrWebPUnavailable
+	}
+
+	src, err := os.CreateTemp("", "rendition-*.png")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(src.Name())
+
+	if err := png.Encode(src, img); err != nil {
+		src.Close()
+		return nil, fmt.Errorf("failed to encode png: %w", err)
+	}
+	src.Close()
+
+	dst := src.Name() + ".webp"
+	defer os.Remove(dst)
+
+	cmd := exec.CommandContext(ctx, bin, "-quiet", "-q", "80", "-metadata", "none", src.Name(), "-o", dst)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("cwebp failed: %w: %s", err, out)
+	}
+
+	return os.ReadFile(dst)
+}
@@ -0,0 +1,79 @@
+package metadata
+
+import (
+	"backend-layout/internal/domain"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+//go:embed fixtures/books.json
+var defaultFixtures []byte
+
+type fixtureBook struct {
+	Title       string   `json:"title"`
+	Authors     []string `json:"authors"`
+	Publishers  []string `json:"publishers"`
+	PublishYear int      `json:"publish_year"`
+	TotalPage   int      `json:"total_page"`
+	Description string   `json:"description"`
+	Subjects    []string `json:"subjects"`
+	CoverUrl    string   `json:"cover_url"`
+}
+
+// FixtureProvider answers lookups from a JSON file keyed by ISBN, so local development
+// and tests never depend on the network
+type FixtureProvider struct {
+	books map[string]fixtureBook
+}
+
+// NewFixtureProvider loads the fixtures at path, or the bundled fixtures when path is empty
+func NewFixtureProvider(path string) (*FixtureProvider, error) {
+	data := defaultFixtures
+
+	if path != "" {
+		var err error
+
+		data, err = os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read metadata fixtures: %w", err)
+		}
+	}
+
+	books := make(map[string]fixtureBook)
+	if err := json.Unmarshal(data, &books); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata fixtures: %w", err)
+	}
+
+	normalized := make(map[string]fixtureBook, len(books))
+	for isbn, b := range books {
+		normalized[normalizeISBN(isbn)] = b
+	}
+
+	return &FixtureProvider{books: normalized}, nil
+}
+
+// LookupISBN implements domain.BookMetadataProvider.
+func (f *FixtureProvider) LookupISBN(ctx context.Context, isbn string) (*domain.BookMetadata, error) {
+	isbn = normalizeISBN(isbn)
+
+	b, ok := f.books[isbn]
+	if !ok {
+		return nil, domain.ErrBookMetadataNotFound
+	}
+
+	return &domain.BookMetadata{
+		Isbn:        isbn,
+		Title:       b.Title,
+		Authors:     b.Authors,
+		Publishers:  b.Publishers,
+		PublishYear: b.PublishYear,
+		TotalPage:   b.TotalPage,
+		Description: b.Description,
+		Subjects:    b.Subjects,
+		CoverUrl:    b.CoverUrl,
+		Source:      "fixture",
+	}, nil
+}
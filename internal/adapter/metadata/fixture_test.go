@@ -0,0 +1,73 @@
+package metadata
+
+import (
+	"backend-layout/internal/domain"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFixtureProviderLookupISBN(t *testing.T) {
+	provider, err := NewFixtureProvider("")
+	if err != nil {
+		t.Fatalf("load bundled fixtures: %v", err)
+	}
+
+	m, err := provider.LookupISBN(context.Background(), "978-0-13-235088-4")
+	if err != nil {
+		t.Fatalf("lookup: %v", err)
+	}
+
+	if m.Isbn != "9780132350884" || m.Title != "Clean Code" || m.TotalPage != 431 || m.Source != "fixture" {
+		t.Errorf("metadata = %+v, want Clean Code with 431 pages from the fixture", m)
+	}
+}
+
+func TestFixtureProviderLookupISBNNotFound(t *testing.T) {
+	provider, err := NewFixtureProvider("")
+	if err != nil {
+		t.Fatalf("load bundled fixtures: %v", err)
+	}
+
+	if _, err := provider.LookupISBN(context.Background(), "0000000000000"); !errors.Is(err, domain.ErrBookMetadataNotFound) {
+		t.Errorf("err = %v, want %v", err, domain.ErrBookMetadataNotFound)
+	}
+}
+
+func TestFixtureProviderLoadsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "books.json")
+
+	if err := os.WriteFile(path, []byte(`{"979-8-88-888888-8": {"title": "Local", "total_page": 12}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	provider, err := NewFixtureProvider(path)
+	if err != nil {
+		t.Fatalf("load fixtures: %v", err)
+	}
+
+	m, err := provider.LookupISBN(context.Background(), "9798888888888")
+	if err != nil || m.Title != "Local" {
+		t.Errorf("lookup = %+v, %v, want the title Local", m, err)
+	}
+}
+
+func TestFixtureProviderLoadError(t *testing.T) {
+	dir := t.TempDir()
+	malformed := filepath.Join(dir, "malformed.json")
+
+	if err := os.WriteFile(malformed, []byte(`[`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, path := range map[string]string{
+		"missing":   filepath.Join(dir, "missing.json"),
+		"malformed": malformed,
+	} {
+		if _, err := NewFixtureProvider(path); err == nil {
+			t.Errorf("%s fixtures loaded without an error", name)
+		}
+	}
+}
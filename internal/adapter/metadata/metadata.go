@@ -0,0 +1,19 @@
+package metadata
+
+import (
+	"backend-layout/internal/config"
+	"backend-layout/internal/domain"
+	"strings"
+)
+
+func NewProvider(conf config.MetadataConfig) (domain.BookMetadataProvider, error) {
+	if conf.Provider == "fixture" {
+		return NewFixtureProvider(conf.FixturePath)
+	}
+
+	return NewOpenLibraryProvider(conf), nil
+}
+
+func normalizeISBN(isbn string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(isbn)
+}
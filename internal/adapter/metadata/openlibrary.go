@@ -0,0 +1,191 @@
+package metadata
+
+import (
+	"backend-layout/internal/config"
+	"backend-layout/internal/domain"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const openLibraryUserAgent = "backend-layout-catalog/1.0"
+
+var publishYearPattern = regexp.MustCompile(`\b[12]\d{3}\b`)
+
+type openLibraryNamed struct {
+	Name string `json:"name"`
+}
+
+type openLibraryBook struct {
+	Key           string             `json:"key"`
+	Title         string             `json:"title"`
+	Subtitle      string             `json:"subtitle"`
+	Authors       []openLibraryNamed `json:"authors"`
+	Publishers    []openLibraryNamed `json:"publishers"`
+	PublishDate   string             `json:"publish_date"`
+	NumberOfPages int                `json:"number_of_pages"`
+	Subjects      []openLibraryNamed `json:"subjects"`
+	Cover         struct {
+		Large string `json:"large"`
+	} `json:"cover"`
+}
+
+// openLibraryText is either a plain string or a {"type": "/type/text", "value": "..."} object
+type openLibraryText string
+
+func (t *openLibraryText) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*t = openLibraryText(s)
+		return nil
+	}
+
+	var obj struct {
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+
+	*t = openLibraryText(obj.Value)
+
+	return nil
+}
+
+type openLibraryRecord struct {
+	Description openLibraryText `json:"description"`
+	Works       []struct {
+		Key string `json:"key"`
+	} `json:"works"`
+}
+
+// OpenLibraryProvider looks ISBNs up through the Open Library books API
+type OpenLibraryProvider struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewOpenLibraryProvider(conf config.MetadataConfig) *OpenLibraryProvider {
+	return &OpenLibraryProvider{
+		baseURL: strings.TrimRight(conf.OpenLibraryBaseURL, "/"),
+		client:  &http.Client{Timeout: conf.Timeout},
+	}
+}
+
+// LookupISBN implements domain.BookMetadataProvider. The books API has no description,
+// so it is read from the edition and, failing that, from the work the edition belongs to.
+func (o *OpenLibraryProvider) LookupISBN(ctx context.Context, isbn string) (*domain.BookMetadata, error) {
+	isbn = normalizeISBN(isbn)
+	bibkey := "ISBN:" + isbn
+
+	query := url.Values{}
+	query.Set("bibkeys", bibkey)
+	query.Set("format", "json")
+	query.Set("jscmd", "data")
+
+	var result map[string]openLibraryBook
+	if err := o.get(ctx, "/api/books?"+query.Encode(), &result); err != nil {
+		return nil, err
+	}
+
+	book, ok := result[bibkey]
+	if !ok {
+		return nil, domain.ErrBookMetadataNotFound
+	}
+
+	m := &domain.BookMetadata{
+		Isbn:       isbn,
+		Title:      book.Title,
+		Authors:    names(book.Authors),
+		Publishers: names(book.Publishers),
+		TotalPage:  book.NumberOfPages,
+		Subjects:   names(book.Subjects),
+		CoverUrl:   book.Cover.Large,
+		Source:     "openlibrary",
+	}
+
+	if book.Subtitle != "" {
+		m.Title = book.Title + ": " + book.Subtitle
+	}
+
+	if year := publishYearPattern.FindString(book.PublishDate); year != "" {
+		m.PublishYear, _ = strconv.Atoi(year)
+	}
+
+	if book.Key != "" {
+		description, err := o.description(ctx, book.Key)
+		if err != nil && !errors.Is(err, domain.ErrBookMetadataNotFound) {
+			return nil, err
+		}
+
+		m.Description = description
+	}
+
+	return m, nil
+}
+
+func (o *OpenLibraryProvider) description(ctx context.Context, editionKey string) (string, error) {
+	var edition openLibraryRecord
+	if err := o.get(ctx, editionKey+".json", &edition); err != nil {
+		return "", err
+	}
+
+	if edition.Description != "" || len(edition.Works) == 0 {
+		return strings.TrimSpace(string(edition.Description)), nil
+	}
+
+	var work openLibraryRecord
+	if err := o.get(ctx, edition.Works[0].Key+".json", &work); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(work.Description)), nil
+}
+
+func (o *OpenLibraryProvider) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, o.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", openLibraryUserAgent)
+
+	res, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("open library request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return domain.ErrBookMetadataNotFound
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("open library returned status %d for %s", res.StatusCode, path)
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode open library response: %w", err)
+	}
+
+	return nil
+}
+
+func names(items []openLibraryNamed) []string {
+	result := make([]string, 0, len(items))
+
+	for _, item := range items {
+		if name := strings.TrimSpace(item.Name); name != "" {
+			result = append(result, name)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,430 @@
+package paymentgateway
+
+import (
+	"backend-layout/internal/config"
+	"backend-layout/internal/domain"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const fakePaymentType = "fake"
+
+// fakeAction is a button on the checkout page, it moves a transaction in one of the from statuses to status
+type fakeAction struct {
+	Name        string
+	Label       string
+	from        []string
+	status      string
+	fraudStatus string
+	paymentType string
+}
+
+var fakeActions = []fakeAction{
+	{Name: "settle", Label: "Pay (settlement)", from: []string{domain.TransactionPending}, status: domain.TransactionSettlement, fraudStatus: domain.FraudAccept, paymentType: fakePaymentType},
+	{Name: "challenge", Label: "Pay by card, flagged for review (fraud challenge)", from: []string{domain.TransactionPending}, status: domain.TransactionCapture, fraudStatus: domain.FraudChallenge, paymentType: "credit_card"},
+	{Name: "accept", Label: "Accept the challenged payment", from: []string{domain.TransactionCapture}, status: domain.TransactionCapture, fraudStatus: domain.FraudAccept},
+	{Name: "deny", Label: "Deny", from: []string{domain.TransactionPending, domain.TransactionCapture}, status: domain.TransactionDeny, fraudStatus: domain.FraudDeny},
+	{Name: "cancel", Label: "Cancel", from: []string{domain.TransactionPending, domain.TransactionCapture}, status: domain.TransactionCancel},
+	{Name: "expire", Label: "Let it expire", from: []string{domain.TransactionPending}, status: domain.TransactionExpire},
+}
+
+func (a fakeAction) allowedFrom(t *fakeTransaction) bool {
+	for _, status := range a.from {
+		if t.TransactionStatus == status {
+			// only a challenged capture can still be reviewed
+			return status != domain.TransactionCapture || t.FraudStatus == domain.FraudChallenge
+		}
+	}
+
+	return false
+}
+
+type fakeTransaction struct {
+	domain.GatewayTransaction
+	Token            string
+	Email            string
+	Discount         int64
+	Items            []domain.GatewayItem
+	Refunded         int64
+	LastNotification string
+
+	refundKeys map[string]bool
+}
+
+// FakeGateway is a payment gateway that lives in memory, for running the payment flow without Midtrans
+// credentials. Its checkout page lets whoever opens it decide how the payment ends, and every change is posted
+// to the notification URL the same way Midtrans would, signed with the fake server key.
+type FakeGateway struct {
+	mu           sync.Mutex
+	transactions map[string]*fakeTransaction
+	tokens       map[string]string
+
+	baseURL         string
+	serverKey       string
+	notificationURL string
+	client          *http.Client
+}
+
+func NewFakeGateway(conf config.PaymentConfig) *FakeGateway {
+	return &FakeGateway{
+		transactions:    make(map[string]*fakeTransaction),
+		tokens:          make(map[string]string),
+		baseURL:         conf.FakeBaseURL,
+		serverKey:       conf.FakeServerKey,
+		notificationURL: conf.FakeNotificationURL,
+		client:          &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name implements domain.PaymentGateway.
+func (f *FakeGateway) Name() string {
+	return "fake"
+}
+
+// CreateTransaction implements domain.PaymentGateway. Asking again for an order that was not paid yet hands out
+// the same checkout.
+func (f *FakeGateway) CreateTransaction(ctx context.Context, charge *domain.GatewayCharge) (*domain.GatewayCheckout, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if t, ok := f.transactions[charge.OrderNumber]; ok {
+		if t.TransactionStatus != domain.TransactionPending {
+			return nil, fmt.Errorf("transaction of order %s is already %s", charge.OrderNumber, t.TransactionStatus)
+		}
+
+		return f.checkout(t), nil
+	}
+
+	token, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+
+	transactionID, err := randomHex(8)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &fakeTransaction{
+		GatewayTransaction: domain.GatewayTransaction{
+			TransactionID:     transactionID,
+			OrderNumber:       charge.OrderNumber,
+			TransactionStatus: domain.TransactionPending,
+			GrossAmount:       charge.Amount,
+			Currency:          "IDR",
+		},
+		Token:    token,
+		Email:    charge.Email,
+		Discount: charge.Discount,
+		Items:    charge.Items,
+	}
+
+	f.transactions[charge.OrderNumber] = t
+	f.tokens[token] = charge.OrderNumber
+
+	return f.checkout(t), nil
+}
+
+func (f *FakeGateway) checkout(t *fakeTransaction) *domain.GatewayCheckout {
+	checkout := &domain.GatewayCheckout{
+		Token:       t.Token,
+		RedirectURL: f.baseURL + "/checkout/" + t.Token,
+	}
+
+	checkout.Raw = raw(map[string]string{"token": checkout.Token, "redirect_url": checkout.RedirectURL})
+
+	return checkout
+}
+
+// snapshot copies the state of a transaction for a caller
+func (t *fakeTransaction) snapshot() *domain.GatewayTransaction {
+	status := t.GatewayTransaction
+	status.Raw = raw(t)
+
+	return &status
+}
+
+// CheckTransaction implements domain.PaymentGateway.
+func (f *FakeGateway) CheckTransaction(ctx context.Context, orderNumber string) (*domain.GatewayTransaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t, ok := f.transactions[orderNumber]
+	if !ok {
+		return nil, domain.ErrGatewayTransactionNotFound
+	}
+
+	return t.snapshot(), nil
+}
+
+// CancelTransaction implements domain.PaymentGateway. Like Midtrans, money that was taken can only be refunded.
+func (f *FakeGateway) CancelTransaction(ctx context.Context, orderNumber string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t, ok := f.transactions[orderNumber]
+	if !ok || t.TransactionStatus == domain.TransactionCancel {
+		return nil
+	}
+
+	cancel := fakeActionByName("cancel")
+	if !cancel.allowedFrom(t) {
+		return fmt.Errorf("transaction of order %s is %s and cannot be cancelled", orderNumber, t.TransactionStatus)
+	}
+
+	t.TransactionStatus = cancel.status
+
+	return nil
+}
+
+// RefundTransaction implements domain.PaymentGateway. A refund key that was used before gives back nothing more.
+func (f *FakeGateway) RefundTransaction(ctx context.Context, orderNumber string, refund *domain.GatewayRefund) (*domain.GatewayTransaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t, ok := f.transactions[orderNumber]
+	if !ok {
+		return nil, domain.ErrGatewayTransactionNotFound
+	}
+
+	if refund.RefundKey != "" && t.refundKeys[refund.RefundKey] {
+		return t.snapshot(), nil
+	}
+
+	switch t.TransactionStatus {
+	case domain.TransactionSettlement, domain.TransactionPartialRefund:
+	case domain.TransactionCapture:
+		if t.FraudStatus != domain.FraudAccept {
+			return nil, fmt.Errorf("transaction of order %s is still under review", orderNumber)
+		}
+	default:
+		return nil, fmt.Errorf("transaction of order %s is %s and cannot be refunded", orderNumber, t.TransactionStatus)
+	}
+
+	if refund.Amount <= 0 || t.Refunded+refund.Amount > t.GrossAmount {
+		return nil, fmt.Errorf("refund of %d is more than the %d left on order %s", refund.Amount, t.GrossAmount-t.Refunded, orderNumber)
+	}
+
+	t.Refunded += refund.Amount
+	t.TransactionStatus = domain.TransactionPartialRefund
+
+	if refund.RefundKey != "" {
+		if t.refundKeys == nil {
+			t.refundKeys = make(map[string]bool)
+		}
+
+		t.refundKeys[refund.RefundKey] = true
+	}
+
+	if t.Refunded == t.GrossAmount {
+		t.TransactionStatus = domain.TransactionRefund
+	}
+
+	return t.snapshot(), nil
+}
+
+// VerifyNotification implements domain.PaymentGateway.
+func (f *FakeGateway) VerifyNotification(n *domain.PaymentNotification) bool {
+	return verifySignature(n, f.serverKey)
+}
+
+// Handler serves the checkout pages handed out by CreateTransaction
+func (f *FakeGateway) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /checkout/{token}", f.showCheckout)
+	mux.HandleFunc("POST /checkout/{token}", f.submitCheckout)
+
+	return mux
+}
+
+type fakeCheckoutPage struct {
+	Transaction fakeTransaction
+	Actions     []fakeAction
+}
+
+func (f *FakeGateway) showCheckout(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+
+	t, ok := f.byToken(r.PathValue("token"))
+	if !ok {
+		f.mu.Unlock()
+		http.NotFound(w, r)
+
+		return
+	}
+
+	page := fakeCheckoutPage{Transaction: *t}
+
+	for _, a := range fakeActions {
+		if a.allowedFrom(t) {
+			page.Actions = append(page.Actions, a)
+		}
+	}
+
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	if err := fakeCheckoutTemplate.Execute(w, page); err != nil {
+		log.Error().Err(err).Str("layer", "adapter").Msg("failed to render fake checkout")
+	}
+}
+
+func (f *FakeGateway) submitCheckout(w http.ResponseWriter, r *http.Request) {
+	token := r.PathValue("token")
+	action := fakeActionByName(r.FormValue("action"))
+
+	f.mu.Lock()
+
+	t, ok := f.byToken(token)
+	if !ok {
+		f.mu.Unlock()
+		http.NotFound(w, r)
+
+		return
+	}
+
+	if action == nil || !action.allowedFrom(t) {
+		f.mu.Unlock()
+		http.Error(w, fmt.Sprintf("cannot %s a transaction that is %s", r.FormValue("action"), t.TransactionStatus), http.StatusConflict)
+
+		return
+	}
+
+	t.TransactionStatus = action.status
+	t.FraudStatus = action.fraudStatus
+
+	if action.paymentType != "" {
+		t.PaymentType = action.paymentType
+	}
+
+	notification := f.notification(t)
+
+	f.mu.Unlock()
+
+	result := f.notify(r.Context(), notification)
+
+	f.mu.Lock()
+	t.LastNotification = result
+	f.mu.Unlock()
+
+	http.Redirect(w, r, "/checkout/"+token, http.StatusSeeOther)
+}
+
+func (f *FakeGateway) byToken(token string) (*fakeTransaction, bool) {
+	orderNumber, ok := f.tokens[token]
+	if !ok {
+		return nil, false
+	}
+
+	t, ok := f.transactions[orderNumber]
+
+	return t, ok
+}
+
+func (f *FakeGateway) notification(t *fakeTransaction) domain.PaymentNotification {
+	n := domain.PaymentNotification{
+		OrderID:           t.OrderNumber,
+		StatusCode:        notificationStatusCode(t.TransactionStatus, t.FraudStatus),
+		GrossAmount:       fmt.Sprintf("%d.00", t.GrossAmount),
+		TransactionID:     t.TransactionID,
+		TransactionStatus: t.TransactionStatus,
+		FraudStatus:       t.FraudStatus,
+		PaymentType:       t.PaymentType,
+	}
+
+	n.SignatureKey = signNotification(n.OrderID, n.StatusCode, n.GrossAmount, f.serverKey)
+
+	return n
+}
+
+// notify posts the notification and describes how it went for the checkout page
+func (f *FakeGateway) notify(ctx context.Context, n domain.PaymentNotification) string {
+	if f.notificationURL == "" {
+		return "no notification URL configured"
+	}
+
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err.Error()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.notificationURL, bytes.NewReader(body))
+	if err != nil {
+		return err.Error()
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "adapter").Str("orderNumber", n.OrderID).Msg("failed to post fake payment notification")
+
+		return err.Error()
+	}
+	defer resp.Body.Close()
+
+	return fmt.Sprintf("%s notification answered %s", n.TransactionStatus, resp.Status)
+}
+
+// notificationStatusCode is the status_code Midtrans sends along with a transaction status
+func notificationStatusCode(transactionStatus, fraudStatus string) string {
+	switch {
+	case transactionStatus == domain.TransactionPending, fraudStatus == domain.FraudChallenge:
+		return "201"
+	case transactionStatus == domain.TransactionDeny, transactionStatus == domain.TransactionCancel, transactionStatus == domain.TransactionExpire:
+		return "202"
+	default:
+		return "200"
+	}
+}
+
+func fakeActionByName(name string) *fakeAction {
+	for i := range fakeActions {
+		if fakeActions[i].Name == name {
+			return &fakeActions[i]
+		}
+	}
+
+	return nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+var fakeCheckoutTemplate = template.Must(template.New("checkout").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Fake checkout {{.Transaction.OrderNumber}}</title></head>
+<body>
+<h1>Order {{.Transaction.OrderNumber}}</h1>
+<table>
+{{range .Transaction.Items}}<tr><td>{{.Name}}</td><td>{{.Qty}} x {{.Price}}</td></tr>
+{{end}}{{if .Transaction.Discount}}<tr><td>Discount</td><td>-{{.Transaction.Discount}}</td></tr>
+{{end}}<tr><th>Total</th><th>{{.Transaction.GrossAmount}} {{.Transaction.Currency}}</th></tr>
+</table>
+<p>Status: <b>{{.Transaction.TransactionStatus}}</b>{{if .Transaction.FraudStatus}} (fraud: {{.Transaction.FraudStatus}}){{end}}</p>
+{{if .Transaction.Refunded}}<p>Refunded: {{.Transaction.Refunded}}</p>{{end}}
+{{if .Transaction.LastNotification}}<p>Last notification: {{.Transaction.LastNotification}}</p>{{end}}
+{{range .Actions}}<form method="post"><button name="action" value="{{.Name}}">{{.Label}}</button></form>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,14 @@
+package paymentgateway
+
+import (
+	"backend-layout/internal/config"
+	"backend-layout/internal/domain"
+)
+
+func NewGateway(conf config.PaymentConfig, midtransConf config.MidtransConfig) domain.PaymentGateway {
+	if conf.Gateway == "fake" {
+		return NewFakeGateway(conf)
+	}
+
+	return InitMidtrans(midtransConf)
+}
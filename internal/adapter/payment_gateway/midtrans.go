@@ -2,15 +2,27 @@ package paymentgateway
 
 import (
 	"backend-layout/internal/config"
+	"backend-layout/internal/domain"
+	"context"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
 
 	"github.com/midtrans/midtrans-go"
 	"github.com/midtrans/midtrans-go/coreapi"
 	"github.com/midtrans/midtrans-go/snap"
 )
 
+const midtransItemNameMaxLength = 50
+
 type MidtransClient struct {
 	SnapClient *snap.Client
 	Coreapi    *coreapi.Client
+	serverKey  string
 }
 
 func InitMidtrans(conf config.MidtransConfig) *MidtransClient {
@@ -30,5 +42,172 @@ func InitMidtrans(conf config.MidtransConfig) *MidtransClient {
 	return &MidtransClient{
 		SnapClient: &s,
 		Coreapi:    &c,
+		serverKey:  conf.ServerKey,
+	}
+}
+
+// Name implements domain.PaymentGateway.
+func (m *MidtransClient) Name() string {
+	return "midtrans"
+}
+
+// CreateTransaction implements domain.PaymentGateway. The checkout is a Snap page.
+func (m *MidtransClient) CreateTransaction(ctx context.Context, charge *domain.GatewayCharge) (*domain.GatewayCheckout, error) {
+	items := make([]midtrans.ItemDetails, 0, len(charge.Items)+1)
+
+	for _, item := range charge.Items {
+		items = append(items, midtrans.ItemDetails{
+			ID:    item.ID,
+			Name:  truncate(item.Name, midtransItemNameMaxLength),
+			Price: item.Price,
+			Qty:   item.Qty,
+		})
 	}
+
+	// Midtrans wants the items to add up to the gross amount
+	if charge.Discount > 0 {
+		items = append(items, midtrans.ItemDetails{
+			ID:    "discount",
+			Name:  "Discount",
+			Price: -charge.Discount,
+			Qty:   1,
+		})
+	}
+
+	resp, err := m.SnapClient.CreateTransaction(&snap.Request{
+		TransactionDetails: midtrans.TransactionDetails{
+			OrderID:  charge.OrderNumber,
+			GrossAmt: charge.Amount,
+		},
+		Items: &items,
+		CreditCard: &snap.CreditCardDetails{
+			Secure: true,
+		},
+		CustomerDetail: &midtrans.CustomerDetails{
+			Email: charge.Email,
+		},
+		EnabledPayments: snap.AllSnapPaymentType,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.GatewayCheckout{
+		Token:       resp.Token,
+		RedirectURL: resp.RedirectURL,
+		Raw:         raw(resp),
+	}, nil
+}
+
+// CheckTransaction implements domain.PaymentGateway. Midtrans answers 404 until the user picks a payment method.
+func (m *MidtransClient) CheckTransaction(ctx context.Context, orderNumber string) (*domain.GatewayTransaction, error) {
+	resp, err := m.Coreapi.CheckTransaction(orderNumber)
+	if err != nil {
+		if err.StatusCode == http.StatusNotFound {
+			return nil, domain.ErrGatewayTransactionNotFound
+		}
+
+		return nil, err
+	}
+
+	return &domain.GatewayTransaction{
+		TransactionID:     resp.TransactionID,
+		OrderNumber:       resp.OrderID,
+		TransactionStatus: resp.TransactionStatus,
+		FraudStatus:       resp.FraudStatus,
+		PaymentType:       resp.PaymentType,
+		GrossAmount:       parseAmount(resp.GrossAmount),
+		Currency:          resp.Currency,
+		Raw:               raw(resp),
+	}, nil
+}
+
+// CancelTransaction implements domain.PaymentGateway. Midtrans answers 404 for an order that never got as far
+// as choosing a payment method, there is nothing to cancel then.
+func (m *MidtransClient) CancelTransaction(ctx context.Context, orderNumber string) error {
+	if _, err := m.Coreapi.CancelTransaction(orderNumber); err != nil {
+		if err.StatusCode == http.StatusNotFound {
+			return nil
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// RefundTransaction implements domain.PaymentGateway.
+func (m *MidtransClient) RefundTransaction(ctx context.Context, orderNumber string, refund *domain.GatewayRefund) (*domain.GatewayTransaction, error) {
+	resp, err := m.Coreapi.RefundTransaction(orderNumber, &coreapi.RefundReq{
+		RefundKey: refund.RefundKey,
+		Amount:    refund.Amount,
+		Reason:    refund.Reason,
+	})
+
+	if err != nil {
+		if err.StatusCode == http.StatusNotFound {
+			return nil, domain.ErrGatewayTransactionNotFound
+		}
+
+		return nil, err
+	}
+
+	return &domain.GatewayTransaction{
+		TransactionID:     resp.TransactionID,
+		OrderNumber:       resp.OrderID,
+		TransactionStatus: resp.TransactionStatus,
+		FraudStatus:       resp.FraudStatus,
+		PaymentType:       resp.PaymentType,
+		GrossAmount:       parseAmount(resp.GrossAmount),
+		Currency:          resp.Currency,
+		Raw:               raw(resp),
+	}, nil
+}
+
+// VerifyNotification implements domain.PaymentGateway.
+func (m *MidtransClient) VerifyNotification(n *domain.PaymentNotification) bool {
+	return verifySignature(n, m.serverKey)
+}
+
+// verifySignature checks the signature_key of a notification, Midtrans signs
+// sha512(order_id + status_code + gross_amount + server_key).
+func verifySignature(n *domain.PaymentNotification, serverKey string) bool {
+	expected := signNotification(n.OrderID, n.StatusCode, n.GrossAmount, serverKey)
+
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(n.SignatureKey)) == 1
+}
+
+func signNotification(orderID, statusCode, grossAmount, serverKey string) string {
+	sum := sha512.Sum512([]byte(orderID + statusCode + grossAmount + serverKey))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// raw keeps a gateway response as it was received, it is only ever stored for reference
+func raw(v any) json.RawMessage {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+
+	return b
+}
+
+// parseAmount reads amounts like "10000.00", Midtrans only deals in whole rupiah
+func parseAmount(s string) int64 {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+
+	return int64(math.Round(f))
+}
+
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+
+	return s
 }
@@ -1,13 +1,110 @@
 package storage
 
 import (
+	"backend-layout/internal/config"
 	"context"
+	"fmt"
+	"io"
 	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
+// LocalStorage stores files on the local filesystem, meant for development
 type LocalStorage struct {
+	root    string
+	baseURL string
 }
 
-func (l *LocalStorage) Uploader(ctx context.Context, file *multipart.FileHeader) (string, error) {
-	return "", nil
+// NewLocalStorage creates a storage rooted at conf.LocalPath
+func NewLocalStorage(conf config.StorageConfig) (Uploader, error) {
+	if err := os.MkdirAll(conf.LocalPath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	return &LocalStorage{
+		root:    conf.LocalPath,
+		baseURL: strings.TrimRight(conf.BaseURL, "/"),
+	}, nil
+}
+
+// UploadFile stores a multipart file and returns its URL
+func (l *LocalStorage) UploadFile(ctx context.Context, file *multipart.FileHeader) (string, error) {
+	if file == nil {
+		return "", fmt.Errorf("file cannot be nil")
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer src.Close()
+
+	key := fmt.Sprintf("%d_%s", time.Now().UnixNano(), filepath.Base(file.Filename))
+
+	return l.Put(ctx, key, src, "")
+}
+
+// Put writes body to <root>/<key> and returns its URL
+func (l *LocalStorage) Put(_ context.Context, key string, body io.Reader, _ string) (string, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	dst, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create file: %w", err)
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, body); err != nil {
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return l.baseURL + "/" + filepath.ToSlash(key), nil
+}
+
+// Open opens the file stored under key
+func (l *LocalStorage) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Open(path)
+}
+
+// Delete removes the file stored under key
+func (l *LocalStorage) Delete(_ context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+
+	return nil
+}
+
+// Root returns the directory files are served from
+func (l *LocalStorage) Root() string {
+	return l.root
+}
+
+func (l *LocalStorage) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" {
+		return "", fmt.Errorf("invalid storage key: %q", key)
+	}
+
+	return filepath.Join(l.root, clean), nil
 }
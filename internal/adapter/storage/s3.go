@@ -86,11 +86,60 @@ func (b *Bucket) UploadFile(ctx context.Context, file *multipart.FileHeader) (st
 		return "", fmt.Errorf("failed to upload file: %w", err)
 	}
 
+	return b.objectURL(key), nil
+}
+
+// Put uploads body to S3 under key and returns its URL
+func (b *Bucket) Put(ctx context.Context, key string, body io.Reader, contentType string) (string, error) {
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(b.config.Bucket),
+		Key:    aws.String(key),
+		Body:   body,
+	}
+
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+
+	if _, err := b.client.PutObject(ctx, input); err != nil {
+		return "", fmt.Errorf("failed to upload object: %w", err)
+	}
+
+	return b.objectURL(key), nil
+}
+
+// Open downloads the object stored under key
+func (b *Bucket) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := b.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.config.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object: %w", err)
+	}
+
+	return out.Body, nil
+}
+
+// Delete removes the object stored under key
+func (b *Bucket) Delete(ctx context.Context, key string) error {
+	_, err := b.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(b.config.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+
+	return nil
+}
+
+func (b *Bucket) objectURL(key string) string {
 	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s",
 		b.config.Bucket,
 		b.config.Region,
 		key,
-	), nil
+	)
 }
 
 // detectContentType determines the content type of the file
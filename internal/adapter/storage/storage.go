@@ -2,9 +2,15 @@ package storage
 
 import (
 	"context"
+	"io"
 	"mime/multipart"
 )
 
 type Uploader interface {
 	UploadFile(ctx context.Context, file *multipart.FileHeader) (string, error)
+	// Put stores body under the given key and returns its public URL
+	Put(ctx context.Context, key string, body io.Reader, contentType string) (string, error)
+	// Open returns a reader for an object previously stored with Put
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
 }
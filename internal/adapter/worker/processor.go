@@ -2,6 +2,7 @@ package worker
 
 import (
 	"backend-layout/internal/config"
+	"backend-layout/internal/middleware"
 	"backend-layout/internal/tasks"
 	"context"
 	"fmt"
@@ -14,6 +15,7 @@ import (
 
 type RedisTaskProcessor struct {
 	server *asynq.Server
+	mux    *asynq.ServeMux
 }
 
 func NewTaskProcessor() *RedisTaskProcessor {
@@ -34,18 +36,37 @@ func NewTaskProcessor() *RedisTaskProcessor {
 		Logger: logger,
 	})
 
+	mux := asynq.NewServeMux()
+	mux.Use(correlationIDMiddleware)
+	mux.HandleFunc(tasks.TaskSendVerifyEmail, tasks.HandlerVerifyEmail)
+	mux.HandleFunc(tasks.TaskSendNotification, tasks.HandlerSendNotification)
+
 	return &RedisTaskProcessor{
 		server: srv,
+		mux:    mux,
 	}
 
 }
 
+// Handle registers a handler that needs dependencies, it must be called before Start
+func (processor *RedisTaskProcessor) Handle(pattern string, handler asynq.Handler) {
+	processor.mux.Handle(pattern, handler)
+}
+
 func (processor *RedisTaskProcessor) Start() error {
-	mux := asynq.NewServeMux()
+	return processor.server.Start(processor.mux)
+}
 
-	mux.HandleFunc(tasks.TaskSendVerifyEmail, tasks.HandlerVerifyEmail)
+// correlationIDMiddleware tags the task context the same way CorrelationIDMiddleware tags requests,
+// using the task id so log lines from repositories can be traced back to the task
+func correlationIDMiddleware(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		taskID, _ := asynq.GetTaskID(ctx)
 
-	return processor.server.Start(mux)
+		ctx = context.WithValue(ctx, middleware.CorrelationIDKey, taskID)
+
+		return next.ProcessTask(ctx, task)
+	})
 }
 
 func (processor *RedisTaskProcessor) Shutdown() {
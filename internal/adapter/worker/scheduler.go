@@ -0,0 +1,51 @@
+package worker
+
+import (
+	"backend-layout/internal/config"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+type RedisTaskScheduler struct {
+	scheduler *asynq.Scheduler
+}
+
+func NewTaskScheduler() *RedisTaskScheduler {
+	scheduler := asynq.NewScheduler(asynq.RedisClientOpt{
+		Addr: fmt.Sprintf("%s:%d", config.LoadRedisConfig().Host, config.LoadRedisConfig().Port),
+	}, &asynq.SchedulerOpts{
+		Logger:   NewLogger(),
+		Location: time.Local,
+		EnqueueErrorHandler: func(task *asynq.Task, opts []asynq.Option, err error) {
+			log.Error().Err(err).Str("type", task.Type()).Msg("failed to enqueue scheduled task")
+		},
+	})
+
+	return &RedisTaskScheduler{
+		scheduler: scheduler,
+	}
+}
+
+// Register enqueues a task with no payload on a cron spec, it must be called before Start.
+// Scheduled tasks are unique for the given window so several running instances do not run a job twice.
+func (s *RedisTaskScheduler) Register(cronspec string, taskType string, uniqueFor time.Duration) error {
+	entryID, err := s.scheduler.Register(cronspec, asynq.NewTask(taskType, nil), asynq.Unique(uniqueFor))
+	if err != nil {
+		return fmt.Errorf("failed to schedule %s: %w", taskType, err)
+	}
+
+	log.Info().Str("type", taskType).Str("cron", cronspec).Str("entry_id", entryID).Msg("scheduled task")
+
+	return nil
+}
+
+func (s *RedisTaskScheduler) Start() error {
+	return s.scheduler.Start()
+}
+
+func (s *RedisTaskScheduler) Shutdown() {
+	s.scheduler.Shutdown()
+}
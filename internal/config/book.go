@@ -0,0 +1,23 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type BookConfig struct {
+	// PurgeAfter is how long a soft deleted book is kept before the purge job may remove it
+	PurgeAfter    time.Duration
+	PurgeSchedule string
+}
+
+func LoadBookConfig() BookConfig {
+	viper.SetDefault("BOOK_PURGE_AFTER", "720h")
+	viper.SetDefault("BOOK_PURGE_SCHEDULE", "0 3 * * *")
+
+	return BookConfig{
+		PurgeAfter:    viper.GetDuration("BOOK_PURGE_AFTER"),
+		PurgeSchedule: viper.GetString("BOOK_PURGE_SCHEDULE"),
+	}
+}
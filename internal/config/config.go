@@ -14,6 +14,13 @@ type Config struct {
 	AWS      AWSConfig
 	OAuth    OauthConfig
 	Midtrans MidtransConfig
+	Payment  PaymentConfig
+	Storage  StorageConfig
+	Metadata MetadataConfig
+	Book     BookConfig
+	Hold     HoldConfig
+	Fine     FineConfig
+	Order    OrderConfig
 }
 
 func NewConfig(path string) (*Config, error) {
@@ -32,5 +39,12 @@ func NewConfig(path string) (*Config, error) {
 		AWS:      LoadAwsConfig(),
 		OAuth:    LoadOauthConfig(),
 		Midtrans: LoadMidtransConfig(),
+		Payment:  LoadPaymentConfig(),
+		Storage:  LoadStorageConfig(),
+		Metadata: LoadMetadataConfig(),
+		Book:     LoadBookConfig(),
+		Hold:     LoadHoldConfig(),
+		Fine:     LoadFineConfig(),
+		Order:    LoadOrderConfig(),
 	}, nil
 }
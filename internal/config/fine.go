@@ -0,0 +1,26 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type FineConfig struct {
+	// BlockThreshold is the outstanding amount above which a user cannot borrow
+	BlockThreshold float64
+	ReminderBefore time.Duration
+	Schedule       string
+}
+
+func LoadFineConfig() FineConfig {
+	viper.SetDefault("FINE_BLOCK_THRESHOLD", 50000)
+	viper.SetDefault("FINE_REMINDER_BEFORE", "24h")
+	viper.SetDefault("FINE_SCHEDULE", "0 * * * *")
+
+	return FineConfig{
+		BlockThreshold: viper.GetFloat64("FINE_BLOCK_THRESHOLD"),
+		ReminderBefore: viper.GetDuration("FINE_REMINDER_BEFORE"),
+		Schedule:       viper.GetString("FINE_SCHEDULE"),
+	}
+}
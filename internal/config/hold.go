@@ -0,0 +1,23 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type HoldConfig struct {
+	// PickupWindow is how long a copy is set aside for a ready hold before it passes to the next user
+	PickupWindow   time.Duration
+	ExpirySchedule string
+}
+
+func LoadHoldConfig() HoldConfig {
+	viper.SetDefault("HOLD_PICKUP_WINDOW", "48h")
+	viper.SetDefault("HOLD_EXPIRY_SCHEDULE", "*/5 * * * *")
+
+	return HoldConfig{
+		PickupWindow:   viper.GetDuration("HOLD_PICKUP_WINDOW"),
+		ExpirySchedule: viper.GetString("HOLD_EXPIRY_SCHEDULE"),
+	}
+}
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type MetadataConfig struct {
+	Provider           string
+	OpenLibraryBaseURL string
+	FixturePath        string
+	Timeout            time.Duration
+}
+
+func LoadMetadataConfig() MetadataConfig {
+	viper.SetDefault("METADATA_PROVIDER", "openlibrary")
+	viper.SetDefault("METADATA_OPENLIBRARY_BASE_URL", "https://openlibrary.org")
+	viper.SetDefault("METADATA_TIMEOUT", "10s")
+
+	return MetadataConfig{
+		Provider:           viper.GetString("METADATA_PROVIDER"),
+		OpenLibraryBaseURL: viper.GetString("METADATA_OPENLIBRARY_BASE_URL"),
+		FixturePath:        viper.GetString("METADATA_FIXTURE_PATH"),
+		Timeout:            viper.GetDuration("METADATA_TIMEOUT"),
+	}
+}
@@ -0,0 +1,23 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type OrderConfig struct {
+	// PaymentWindow is how long an order may wait for payment before it expires and its books go back on the shelf
+	PaymentWindow  time.Duration
+	ExpirySchedule string
+}
+
+func LoadOrderConfig() OrderConfig {
+	viper.SetDefault("ORDER_PAYMENT_WINDOW", "24h")
+	viper.SetDefault("ORDER_EXPIRY_SCHEDULE", "*/10 * * * *")
+
+	return OrderConfig{
+		PaymentWindow:  viper.GetDuration("ORDER_PAYMENT_WINDOW"),
+		ExpirySchedule: viper.GetString("ORDER_EXPIRY_SCHEDULE"),
+	}
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type PaymentConfig struct {
+	// Gateway is midtrans or fake, the fake one serves its own checkout page on FakeAddr
+	Gateway             string
+	FakeAddr            string
+	FakeBaseURL         string
+	FakeServerKey       string
+	FakeNotificationURL string
+	// ReconcileSchedule is when orders are compared with the gateway, ReconcileLookback how far back a run looks
+	ReconcileSchedule string
+	ReconcileLookback time.Duration
+}
+
+func LoadPaymentConfig() PaymentConfig {
+	viper.SetDefault("PAYMENT_GATEWAY", "midtrans")
+	viper.SetDefault("PAYMENT_FAKE_ADDR", ":8090")
+	viper.SetDefault("PAYMENT_FAKE_BASE_URL", "http://localhost:8090")
+	viper.SetDefault("PAYMENT_FAKE_SERVER_KEY", "fake-server-key")
+	viper.SetDefault("PAYMENT_FAKE_NOTIFICATION_URL", "http://localhost:8080/api/v1/public/payment/notifications/midtrans")
+	viper.SetDefault("PAYMENT_RECONCILE_SCHEDULE", "0 2 * * *")
+	viper.SetDefault("PAYMENT_RECONCILE_LOOKBACK", "72h")
+
+	return PaymentConfig{
+		Gateway:             viper.GetString("PAYMENT_GATEWAY"),
+		FakeAddr:            viper.GetString("PAYMENT_FAKE_ADDR"),
+		FakeBaseURL:         viper.GetString("PAYMENT_FAKE_BASE_URL"),
+		FakeServerKey:       viper.GetString("PAYMENT_FAKE_SERVER_KEY"),
+		FakeNotificationURL: viper.GetString("PAYMENT_FAKE_NOTIFICATION_URL"),
+		ReconcileSchedule:   viper.GetString("PAYMENT_RECONCILE_SCHEDULE"),
+		ReconcileLookback:   viper.GetDuration("PAYMENT_RECONCILE_LOOKBACK"),
+	}
+}
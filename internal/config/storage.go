@@ -0,0 +1,21 @@
+package config
+
+import "github.com/spf13/viper"
+
+type StorageConfig struct {
+	Driver    string
+	LocalPath string
+	BaseURL   string
+}
+
+func LoadStorageConfig() StorageConfig {
+	viper.SetDefault("STORAGE_DRIVER", "local")
+	viper.SetDefault("STORAGE_LOCAL_PATH", "storage")
+	viper.SetDefault("STORAGE_BASE_URL", "http://localhost:8080/storage")
+
+	return StorageConfig{
+		Driver:    viper.GetString("STORAGE_DRIVER"),
+		LocalPath: viper.GetString("STORAGE_LOCAL_PATH"),
+		BaseURL:   viper.GetString("STORAGE_BASE_URL"),
+	}
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"mime/multipart"
 	"strings"
 	"time"
 
@@ -23,26 +24,58 @@ type Book struct {
 	Price        float64
 	CategoryName string
 	CategoryID   []int64
+	ImageUrl     string
+	ThumbnailUrl string
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
+	DeletedAt    *time.Time
+
+	AverageRating float64
+	RatingCount   int64
+}
+
+type BookImageRendition struct {
+	Id        int64
+	BookID    int64
+	Variant   string
+	Format    string
+	Width     int
+	Height    int
+	Url       string
+	CreatedAt time.Time
+}
+
+type BookImageRenditionResponse struct {
+	Variant string `json:"variant"`
+	Format  string `json:"format"`
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+	Url     string `json:"url"`
 }
 
 type BookResponse struct {
-	Id            int64     `json:"id"`
-	Title         string    `json:"title"`
-	Slug          string    `json:"string"`
-	AuthorName    string    `json:"author_name"`
-	PublisherName string    `json:"publisher_name"`
-	PublishYear   int       `json:"publish_year"`
-	TotalPage     int       `json:"total_page"`
-	Description   string    `json:"description"`
-	Sku           string    `json:"sku"`
-	Stock         int64     `json:"stock"`
-	Isbn          string    `json:"isbn"`
-	Price         float64   `json:"price"`
-	CategoryName  []string  `json:"category_name"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	Id            int64      `json:"id"`
+	Title         string     `json:"title"`
+	Slug          string     `json:"string"`
+	AuthorName    string     `json:"author_name"`
+	PublisherName string     `json:"publisher_name"`
+	PublishYear   int        `json:"publish_year"`
+	TotalPage     int        `json:"total_page"`
+	Description   string     `json:"description"`
+	Sku           string     `json:"sku"`
+	Stock         int64      `json:"stock"`
+	Isbn          string     `json:"isbn"`
+	Price         float64    `json:"price"`
+	CategoryName  []string   `json:"category_name"`
+	ImageUrl      string     `json:"image_url"`
+	ThumbnailUrl  string     `json:"thumbnail_url"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
+	AverageRating float64    `json:"average_rating"`
+	RatingCount   int64      `json:"rating_count"`
+
+	Renditions []BookImageRenditionResponse `json:"renditions,omitempty"`
 }
 
 func BookToResponse(b *Book) BookResponse {
@@ -59,8 +92,13 @@ func BookToResponse(b *Book) BookResponse {
 		Isbn:          b.Isbn,
 		Price:         b.Price,
 		CategoryName:  strings.Split(b.CategoryName, ","),
+		ImageUrl:      b.ImageUrl,
+		ThumbnailUrl:  b.ThumbnailUrl,
 		CreatedAt:     b.CreatedAt,
 		UpdatedAt:     b.UpdatedAt,
+		DeletedAt:     b.DeletedAt,
+		AverageRating: b.AverageRating,
+		RatingCount:   b.RatingCount,
 	}
 }
 
@@ -74,6 +112,7 @@ type StoreBookRequest struct {
 	Description string  `json:"description"`
 	Isbn        string  `json:"isbn" validate:"required,isbn"`
 	CategoryID  []int64 `json:"category_id" validate:"required,dive"`
+	ActorID     int64   `json:"-"`
 }
 
 type UpdateBookRequest struct {
@@ -87,21 +126,38 @@ type UpdateBookRequest struct {
 	Description string  `json:"description"`
 	Isbn        string  `json:"isbn" validate:"required,isbn"`
 	CategoryID  []int64 `json:"category_id" validate:"required"`
+	ActorID     int64   `json:"-"`
 }
 
 type BookRepository interface {
 	Fetch(ctx context.Context, params RequestQueryParams) (books []Book, total int64, err error)
 	GetTx(ctx context.Context) (pgx.Tx, error)
-	Store(ctx context.Context, book *Book) (id int64, err error)
+	Store(ctx context.Context, tx pgx.Tx, book *Book) (id int64, err error)
 	GetByID(ctx context.Context, id int64) (*Book, error)
+	GetForUpdate(ctx context.Context, tx pgx.Tx, id int64) (*Book, error)
 	Update(ctx context.Context, tx pgx.Tx, book *Book) error
-	Delete(ctx context.Context, id int64) error
+	Delete(ctx context.Context, tx pgx.Tx, id int64) error
+	Restore(ctx context.Context, tx pgx.Tx, id int64) error
+	PurgeDeleted(ctx context.Context, tx pgx.Tx, deletedBefore time.Time) (ids []int64, err error)
+
+	UpdateImage(ctx context.Context, tx pgx.Tx, id int64, imageUrl string) error
+	SaveImageRenditions(ctx context.Context, tx pgx.Tx, book *Book, renditions []BookImageRendition) error
+	GetImageRenditions(ctx context.Context, bookID int64) ([]BookImageRendition, error)
+
+	FetchMissingMetadata(ctx context.Context, afterID int64, limit int) ([]Book, error)
+	FillMetadata(ctx context.Context, tx pgx.Tx, id int64, description string, totalPage int) (updated bool, err error)
 }
 
 type BookUsecase interface {
 	Fetch(ctx context.Context, params RequestQueryParams) ([]Book, int64, error)
 	Store(ctx context.Context, input *StoreBookRequest) (int64, error)
 	Update(ctx context.Context, input *UpdateBookRequest) error
-	Delete(ctx context.Context, id int64) error
+	Delete(ctx context.Context, id int64, actorID int64) error
+	Restore(ctx context.Context, id int64, actorID int64) error
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time) (purged int, err error)
 	Get(ctx context.Context, id int64) (BookResponse, error)
+	UploadImage(ctx context.Context, id int64, file *multipart.FileHeader) (imageUrl string, err error)
+
+	FetchRevisions(ctx context.Context, id int64, params RequestQueryParams) ([]BookRevisionResponse, int64, error)
+	RevertToRevision(ctx context.Context, id, revisionID, actorID int64) error
 }
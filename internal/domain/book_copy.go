@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	BookCopyAvailable = "available"
+	BookCopyOnLoan    = "on_loan"
+	BookCopyOnHold    = "on_hold"
+	BookCopyLost      = "lost"
+	BookCopyRepair    = "repair"
+)
+
+type BookCopy struct {
+	Id        int64
+	BookID    int64
+	Barcode   string
+	Condition string
+	Status    string
+	Location  *string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type BookCopyResponse struct {
+	Id        int64     `json:"id"`
+	BookID    int64     `json:"book_id"`
+	Barcode   string    `json:"barcode"`
+	Condition string    `json:"condition"`
+	Status    string    `json:"status"`
+	Location  *string   `json:"location"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func BookCopyToResponse(c *BookCopy) BookCopyResponse {
+	return BookCopyResponse{
+		Id:        c.Id,
+		BookID:    c.BookID,
+		Barcode:   c.Barcode,
+		Condition: c.Condition,
+		Status:    c.Status,
+		Location:  c.Location,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
+
+type StoreBookCopyRequest struct {
+	BookID    int64   `json:"-"`
+	Barcode   string  `json:"barcode" validate:"required,max=50"`
+	Condition string  `json:"condition" validate:"omitempty,oneof=new good fair poor damaged"`
+	Location  *string `json:"location" validate:"omitempty,max=100"`
+}
+
+// UpdateBookCopyRequest changes a copy, on_loan is left out of the statuses because only fulfilling an order lends a copy out
+type UpdateBookCopyRequest struct {
+	ID        int64   `json:"-"`
+	BookID    int64   `json:"-"`
+	Barcode   string  `json:"barcode" validate:"required,max=50"`
+	Condition string  `json:"condition" validate:"required,oneof=new good fair poor damaged"`
+	Status    string  `json:"status" validate:"required,oneof=available lost repair"`
+	Location  *string `json:"location" validate:"omitempty,max=100"`
+}
+
+// BookStock is the result of recounting the stock of a book from its copies
+type BookStock struct {
+	// Before is in_stock as it was stored before the recount
+	Before int64
+	// After is the in_stock the recount stored
+	After      int64
+	TotalStock int64
+	// Available counts the copies on the shelf, Reserved the order items still waiting for one and Waiting the
+	// holds queued for the next free copy
+	Available int64
+	Reserved  int64
+	Waiting   int64
+}
+
+// Restocked reports whether the recount made a book that was out of stock available again
+func (s BookStock) Restocked() bool {
+	return s.Before <= 0 && s.After > 0
+}
+
+// HasHoldsToOffer reports whether there are copies on the shelf that should be handed to the hold queue
+func (s BookStock) HasHoldsToOffer() bool {
+	return s.Waiting > 0 && s.Available > s.Reserved
+}
+
+type BookCopyRepository interface {
+	Store(ctx context.Context, tx pgx.Tx, bookCopy *BookCopy) (id int64, err error)
+	GetForUpdate(ctx context.Context, tx pgx.Tx, bookID, id int64) (*BookCopy, error)
+	GetByBarcodeForUpdate(ctx context.Context, tx pgx.Tx, barcode string) (*BookCopy, error)
+	FetchByBookID(ctx context.Context, bookID int64, params RequestQueryParams) (copies []BookCopy, total int64, err error)
+	Update(ctx context.Context, tx pgx.Tx, bookCopy *BookCopy) error
+	SetStatus(ctx context.Context, tx pgx.Tx, id int64, status string) error
+	Delete(ctx context.Context, tx pgx.Tx, id int64) error
+
+	// LockBooks locks the rows of the books in id order, so transactions touching several books queue up the same
+	// way instead of deadlocking
+	LockBooks(ctx context.Context, tx pgx.Tx, bookIDs []int64) error
+	// RefreshStock recounts in_stock and total_stock of a book from its copies, the book row stays locked until tx ends
+	RefreshStock(ctx context.Context, tx pgx.Tx, bookID int64) (BookStock, error)
+}
+
+type BookCopyUsecase interface {
+	Fetch(ctx context.Context, bookID int64, params RequestQueryParams) ([]BookCopyResponse, int64, error)
+	Store(ctx context.Context, input *StoreBookCopyRequest) (BookCopyResponse, error)
+	Update(ctx context.Context, input *UpdateBookCopyRequest) (BookCopyResponse, error)
+	Delete(ctx context.Context, bookID, id int64) error
+}
@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+	ExportFormatMARC   = "marc"
+
+	ExportStatusPending    = "pending"
+	ExportStatusProcessing = "processing"
+	ExportStatusCompleted  = "completed"
+	ExportStatusFailed     = "failed"
+)
+
+// BookExportFilter holds the subset of the book list query params an export can be narrowed with
+type BookExportFilter struct {
+	Keyword  string `json:"q"`
+	MinPrice int64  `json:"min_price"`
+	MaxPrice int64  `json:"max_price"`
+	SortBy   string `json:"sort_by"`
+}
+
+// ToParams builds the params passed to BookRepository.Fetch for the given page
+func (f BookExportFilter) ToParams(page, perPage int64) RequestQueryParams {
+	return RequestQueryParams{
+		Keyword: f.Keyword,
+		Page:    page,
+		PerPage: perPage,
+		SortBy:  f.SortBy,
+		Filters: map[string]interface{}{
+			"min_price": f.MinPrice,
+			"max_price": f.MaxPrice,
+		},
+	}
+}
+
+type CreateBookExportRequest struct {
+	Format string `json:"format" validate:"required,oneof=csv ndjson marc"`
+	BookExportFilter
+}
+
+type BookExport struct {
+	Id           int64
+	UserID       int64
+	Format       string
+	Status       string
+	Filter       BookExportFilter
+	FileKey      *string
+	TotalRows    int64
+	ErrorMessage *string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type BookExportResponse struct {
+	Id           int64            `json:"id"`
+	Format       string           `json:"format"`
+	Status       string           `json:"status"`
+	Filter       BookExportFilter `json:"filter"`
+	TotalRows    int64            `json:"total_rows"`
+	HasFile      bool             `json:"has_file"`
+	ErrorMessage *string          `json:"error_message,omitempty"`
+	CreatedAt    time.Time        `json:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+}
+
+func BookExportToResponse(be *BookExport) BookExportResponse {
+	return BookExportResponse{
+		Id:           be.Id,
+		Format:       be.Format,
+		Status:       be.Status,
+		Filter:       be.Filter,
+		TotalRows:    be.TotalRows,
+		HasFile:      be.FileKey != nil,
+		ErrorMessage: be.ErrorMessage,
+		CreatedAt:    be.CreatedAt,
+		UpdatedAt:    be.UpdatedAt,
+	}
+}
+
+type BookExportRepository interface {
+	Store(ctx context.Context, be *BookExport) (id int64, err error)
+	GetByID(ctx context.Context, id int64) (*BookExport, error)
+	SetStatus(ctx context.Context, id int64, status string, errorMessage *string) error
+	Complete(ctx context.Context, id int64, fileKey string, totalRows int64) error
+}
+
+type BookExportUsecase interface {
+	Stream(ctx context.Context, format string, filter BookExportFilter, w io.Writer) error
+	Create(ctx context.Context, userID int64, format string, filter BookExportFilter) (BookExportResponse, error)
+	Get(ctx context.Context, id int64) (BookExportResponse, error)
+	Download(ctx context.Context, id int64) (io.ReadCloser, error)
+	Process(ctx context.Context, id int64) error
+}
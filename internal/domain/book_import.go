@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"context"
+	"io"
+	"mime/multipart"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	ImportFormatCSV  = "csv"
+	ImportFormatONIX = "onix"
+
+	ImportStatusPending    = "pending"
+	ImportStatusProcessing = "processing"
+	ImportStatusCompleted  = "completed"
+	ImportStatusFailed     = "failed"
+)
+
+type BookImport struct {
+	Id            int64
+	UserID        int64
+	Filename      string
+	Format        string
+	Status        string
+	SourceKey     string
+	TotalRows     int64
+	ProcessedRows int64
+	SuccessRows   int64
+	FailedRows    int64
+	ReportKey     *string
+	ErrorMessage  *string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// ImportBookRow is a single book read from an import file, validated with the same rules as StoreBookRequest
+type ImportBookRow struct {
+	Line        int      `json:"line"`
+	Title       string   `json:"title" validate:"required"`
+	AuthorName  string   `json:"author" validate:"required,max=255"`
+	Publisher   string   `json:"publisher" validate:"required,max=255"`
+	PublishYear int      `json:"publish_year" validate:"required,min=1000"`
+	TotalPage   int      `json:"total_page" validate:"required,min=1"`
+	Price       float64  `json:"price" validate:"required,gt=0"`
+	Isbn        string   `json:"isbn" validate:"required,isbn"`
+	Description string   `json:"description"`
+	Categories  []string `json:"categories" validate:"required,min=1,dive,required"`
+	// Stock is how many copies of the book are put on the shelf, it may be left out of the file
+	Stock int `json:"stock" validate:"min=0"`
+
+	// ParseErrors holds problems found while reading the row, e.g. a non numeric page count
+	ParseErrors []string `json:"-"`
+}
+
+type ImportRowError struct {
+	Line   int
+	Isbn   string
+	Title  string
+	Errors []string
+}
+
+type BookImportResponse struct {
+	Id            int64     `json:"id"`
+	Filename      string    `json:"filename"`
+	Format        string    `json:"format"`
+	Status        string    `json:"status"`
+	TotalRows     int64     `json:"total_rows"`
+	ProcessedRows int64     `json:"processed_rows"`
+	SuccessRows   int64     `json:"success_rows"`
+	FailedRows    int64     `json:"failed_rows"`
+	Progress      float64   `json:"progress"`
+	HasReport     bool      `json:"has_report"`
+	ErrorMessage  *string   `json:"error_message,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func BookImportToResponse(bi *BookImport) BookImportResponse {
+	var progress float64
+	if bi.TotalRows > 0 {
+		progress = float64(bi.ProcessedRows) / float64(bi.TotalRows) * 100
+	}
+
+	return BookImportResponse{
+		Id:            bi.Id,
+		Filename:      bi.Filename,
+		Format:        bi.Format,
+		Status:        bi.Status,
+		TotalRows:     bi.TotalRows,
+		ProcessedRows: bi.ProcessedRows,
+		SuccessRows:   bi.SuccessRows,
+		FailedRows:    bi.FailedRows,
+		Progress:      progress,
+		HasReport:     bi.ReportKey != nil,
+		ErrorMessage:  bi.ErrorMessage,
+		CreatedAt:     bi.CreatedAt,
+		UpdatedAt:     bi.UpdatedAt,
+	}
+}
+
+type BookImportRepository interface {
+	Store(ctx context.Context, bi *BookImport) (id int64, err error)
+	GetByID(ctx context.Context, id int64) (*BookImport, error)
+	// Claim moves a pending import to processing, it reports false when the import was not pending anymore
+	Claim(ctx context.Context, id int64) (claimed bool, err error)
+	SetStatus(ctx context.Context, id int64, status string, errorMessage *string) error
+	SetTotalRows(ctx context.Context, id int64, total int64) error
+	UpdateProgress(ctx context.Context, id int64, processed, success, failed int64) error
+	Complete(ctx context.Context, id int64, reportKey *string) error
+
+	FindOrCreateAuthor(ctx context.Context, tx pgx.Tx, name string) (int64, error)
+	FindOrCreatePublisher(ctx context.Context, tx pgx.Tx, name string) (int64, error)
+	GetCategoryIDsByNames(ctx context.Context, tx pgx.Tx, names []string) (map[string]int64, error)
+}
+
+type BookImportUsecase interface {
+	Create(ctx context.Context, userID int64, format string, file *multipart.FileHeader) (BookImportResponse, error)
+	Get(ctx context.Context, id int64) (BookImportResponse, error)
+	Report(ctx context.Context, id int64) (io.ReadCloser, error)
+	Process(ctx context.Context, id int64) error
+}
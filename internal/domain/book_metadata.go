@@ -0,0 +1,74 @@
+package domain
+
+import "context"
+
+// BookMetadata is bibliographic data about an ISBN as reported by an external source
+type BookMetadata struct {
+	Isbn        string
+	Title       string
+	Authors     []string
+	Publishers  []string
+	PublishYear int
+	TotalPage   int
+	Description string
+	Subjects    []string
+	CoverUrl    string
+	Source      string
+}
+
+// BookMetadataResponse is shaped after StoreBookRequest so a client can prefill the create form
+type BookMetadataResponse struct {
+	Isbn        string   `json:"isbn"`
+	Title       string   `json:"title"`
+	Authors     []string `json:"authors"`
+	Publishers  []string `json:"publishers"`
+	PublishYear int      `json:"publish_year"`
+	TotalPage   int      `json:"total_page"`
+	Description string   `json:"description"`
+	Subjects    []string `json:"subjects"`
+	CoverUrl    string   `json:"cover_url,omitempty"`
+	Source      string   `json:"source"`
+}
+
+func BookMetadataToResponse(m *BookMetadata) BookMetadataResponse {
+	return BookMetadataResponse{
+		Isbn:        m.Isbn,
+		Title:       m.Title,
+		Authors:     m.Authors,
+		Publishers:  m.Publishers,
+		PublishYear: m.PublishYear,
+		TotalPage:   m.TotalPage,
+		Description: m.Description,
+		Subjects:    m.Subjects,
+		CoverUrl:    m.CoverUrl,
+		Source:      m.Source,
+	}
+}
+
+type LookupBookRequest struct {
+	Isbn string `query:"isbn" validate:"required,isbn"`
+}
+
+type EnrichBookMetadataRequest struct {
+	BookIDs []int64 `json:"book_ids" validate:"omitempty,dive,gt=0"`
+}
+
+// EnrichMetadataResult summarises an enrichment run
+type EnrichMetadataResult struct {
+	Checked  int
+	Updated  int
+	NotFound int
+	Failed   int
+}
+
+// BookMetadataProvider looks up an ISBN in an external bibliographic source.
+// It returns ErrBookMetadataNotFound when the source does not know the ISBN.
+type BookMetadataProvider interface {
+	LookupISBN(ctx context.Context, isbn string) (*BookMetadata, error)
+}
+
+type BookMetadataUsecase interface {
+	Lookup(ctx context.Context, isbn string) (BookMetadataResponse, error)
+	RequestEnrichment(ctx context.Context, bookIDs []int64) error
+	Enrich(ctx context.Context, bookIDs []int64) (EnrichMetadataResult, error)
+}
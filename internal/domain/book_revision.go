@@ -0,0 +1,154 @@
+package domain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	BookRevisionCreate  = "create"
+	BookRevisionUpdate  = "update"
+	BookRevisionDelete  = "delete"
+	BookRevisionRestore = "restore"
+	BookRevisionRevert  = "revert"
+
+	// BookFieldDeleted is the pseudo field delete and restore revisions record their change in
+	BookFieldDeleted = "deleted"
+)
+
+// BookState holds the book fields tracked by revisions, the json names are the field names stored in a revision
+type BookState struct {
+	Title       string  `json:"title"`
+	AuthorID    int64   `json:"author_id"`
+	PublisherID int64   `json:"publisher_id"`
+	PublishYear int     `json:"publish_year"`
+	TotalPage   int     `json:"total_page"`
+	Description string  `json:"description"`
+	Isbn        string  `json:"isbn"`
+	Price       float64 `json:"price"`
+	CategoryID  []int64 `json:"category_id"`
+}
+
+func BookToState(b *Book) BookState {
+	categoryID := append([]int64{}, b.CategoryID...)
+	sort.Slice(categoryID, func(i, j int) bool { return categoryID[i] < categoryID[j] })
+
+	return BookState{
+		Title:       b.Title,
+		AuthorID:    b.Author.Id,
+		PublisherID: b.Publisher.Id,
+		PublishYear: b.PublishYear,
+		TotalPage:   b.TotalPage,
+		Description: b.Description,
+		Isbn:        b.Isbn,
+		Price:       b.Price,
+		CategoryID:  categoryID,
+	}
+}
+
+// Fields returns the state keyed by field name, each value JSON encoded
+func (s BookState) Fields() map[string]json.RawMessage {
+	data, _ := json.Marshal(s)
+
+	fields := make(map[string]json.RawMessage)
+	_ = json.Unmarshal(data, &fields)
+
+	return fields
+}
+
+// BookStateFromFields is the inverse of BookState.Fields
+func BookStateFromFields(fields map[string]json.RawMessage) (BookState, error) {
+	var s BookState
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return s, err
+	}
+
+	err = json.Unmarshal(data, &s)
+
+	return s, err
+}
+
+type BookFieldChange struct {
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+// DiffBookState returns the fields that differ between before and after, a nil before records every field as new
+func DiffBookState(before *BookState, after BookState) map[string]BookFieldChange {
+	changes := make(map[string]BookFieldChange)
+
+	var beforeFields map[string]json.RawMessage
+	if before != nil {
+		beforeFields = before.Fields()
+	}
+
+	for field, value := range after.Fields() {
+		old, ok := beforeFields[field]
+		if ok && bytes.Equal(old, value) {
+			continue
+		}
+
+		change := BookFieldChange{After: value}
+		if ok {
+			change.Before = old
+		} else {
+			change.Before = json.RawMessage("null")
+		}
+
+		changes[field] = change
+	}
+
+	return changes
+}
+
+type BookRevision struct {
+	Id        int64
+	BookID    int64
+	Action    string
+	ActorID   *int64
+	Changes   map[string]BookFieldChange
+	CreatedAt time.Time
+}
+
+type BookRevisionResponse struct {
+	Id        int64                      `json:"id"`
+	BookID    int64                      `json:"book_id"`
+	Action    string                     `json:"action"`
+	ActorID   *int64                     `json:"actor_id"`
+	ActorName *string                    `json:"actor_name"`
+	Changes   map[string]BookFieldChange `json:"changes"`
+	CreatedAt time.Time                  `json:"created_at"`
+}
+
+// BookRevisionWithActor is a revision joined with the name of the user who made it
+type BookRevisionWithActor struct {
+	BookRevision
+	ActorName *string
+}
+
+func BookRevisionToResponse(r *BookRevisionWithActor) BookRevisionResponse {
+	return BookRevisionResponse{
+		Id:        r.Id,
+		BookID:    r.BookID,
+		Action:    r.Action,
+		ActorID:   r.ActorID,
+		ActorName: r.ActorName,
+		Changes:   r.Changes,
+		CreatedAt: r.CreatedAt,
+	}
+}
+
+type BookRevisionRepository interface {
+	Store(ctx context.Context, tx pgx.Tx, revision *BookRevision) (id int64, err error)
+	GetByID(ctx context.Context, tx pgx.Tx, bookID, id int64) (*BookRevision, error)
+	FetchByBookID(ctx context.Context, bookID int64, params RequestQueryParams) (revisions []BookRevisionWithActor, total int64, err error)
+	// FetchAfter returns the revisions of a book made after revision id, newest first
+	FetchAfter(ctx context.Context, tx pgx.Tx, bookID, id int64) ([]BookRevision, error)
+}
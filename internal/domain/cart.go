@@ -54,6 +54,7 @@ type CartRepository interface {
 	CartDetails(ctx context.Context, userID int64) (carts []CartDetail, err error)
 	IsItemInCart(ctx context.Context, bookID, userID int64) (bool, error)
 	HasStock(ctx context.Context, bookID int64) (bool, error)
+	HasReadyHold(ctx context.Context, bookID, userID int64) (bool, error)
 }
 
 type CartUsecase interface {
@@ -3,7 +3,10 @@ package domain
 import "errors"
 
 var (
-	ErrEmailDuplicate = errors.New("email already used")
+	ErrEmailDuplicate             = errors.New("email already used")
+	ErrBookMetadataNotFound       = errors.New("book metadata not found")
+	ErrOrderTransition            = errors.New("illegal order status transition")
+	ErrGatewayTransactionNotFound = errors.New("gateway transaction not found")
 )
 
 type ErrResponse struct {
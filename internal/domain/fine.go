@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const FineLateFee = "late_fee"
+
+// a settled fine no longer counts as outstanding, it was either paid at the desk or waived
+const (
+	FineSettlementPaid   = "paid"
+	FineSettlementWaived = "waived"
+)
+
+// FinePolicy is when outstanding fines block new loans and when borrowers are reminded, the daily late fee comes
+// from the borrower's lending policy
+type FinePolicy struct {
+	// BlockThreshold is the outstanding amount above which a user cannot borrow
+	BlockThreshold float64
+	// ReminderBefore is how long before the due date a reminder goes out
+	ReminderBefore time.Duration
+}
+
+type Fine struct {
+	Id            int64
+	UserID        int64
+	OrderDetailID int64
+	Kind          string
+	Amount        float64
+	AccruedFor    time.Time
+	PaidAt        *time.Time
+	CreatedAt     time.Time
+}
+
+type FineWithBook struct {
+	Fine
+	OrderId   int64
+	BookId    int64
+	BookTitle string
+}
+
+type FineResponse struct {
+	Id            int64     `json:"id"`
+	OrderDetailID int64     `json:"order_detail_id"`
+	OrderId       int64     `json:"order_id"`
+	BookId        int64     `json:"book_id"`
+	BookTitle     string    `json:"book_title"`
+	Kind          string    `json:"kind"`
+	Amount        float64   `json:"amount"`
+	AccruedFor    time.Time `json:"accrued_for"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type SettleFinesRequest struct {
+	UserID  int64 `json:"-"`
+	StaffID int64 `json:"-"`
+	// FineIDs are the fines to settle, every outstanding fine of the user when empty
+	FineIDs    []int64 `json:"fine_ids" validate:"omitempty,dive,gt=0"`
+	Settlement string  `json:"settlement" validate:"required,oneof=paid waived"`
+}
+
+type SettleFinesResponse struct {
+	Settled     int     `json:"settled"`
+	Amount      float64 `json:"amount"`
+	Outstanding float64 `json:"outstanding"`
+}
+
+type OutstandingFinesResponse struct {
+	Total float64        `json:"total"`
+	Fines []FineResponse `json:"fines"`
+}
+
+func FineToResponse(f *FineWithBook) FineResponse {
+	return FineResponse{
+		Id:            f.Id,
+		OrderDetailID: f.OrderDetailID,
+		OrderId:       f.OrderId,
+		BookId:        f.BookId,
+		BookTitle:     f.BookTitle,
+		Kind:          f.Kind,
+		Amount:        f.Amount,
+		AccruedFor:    f.AccruedFor,
+		CreatedAt:     f.CreatedAt,
+	}
+}
+
+type FineRepository interface {
+	// AccrueLateFees charges dailyRate for every day up to today an open loan is past its due date, days that are
+	// already charged are skipped and the total per loan never goes above the price of the book, a book without a
+	// price is not fined
+	AccrueLateFees(ctx context.Context, today time.Time) (accrued int64, err error)
+	OutstandingTotal(ctx context.Context, userID int64) (float64, error)
+	FetchOutstanding(ctx context.Context, userID int64) ([]FineWithBook, error)
+	// Settle marks outstanding fines of a user as paid or waived, all of them when fineIDs is empty. Fines that are
+	// already settled or belong to someone else are left out of the count
+	Settle(ctx context.Context, userID int64, fineIDs []int64, settlement string, staffID int64) (settled int, amount float64, err error)
+}
+
+type FineUsecase interface {
+	FetchOutstanding(ctx context.Context, userID int64) (OutstandingFinesResponse, error)
+	// Settle clears fines of a borrower, which lifts the block on borrowing once the rest is under the threshold
+	Settle(ctx context.Context, input *SettleFinesRequest) (SettleFinesResponse, error)
+}
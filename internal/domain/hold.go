@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	HoldWaiting   = "waiting"
+	HoldReady     = "ready"
+	HoldClaimed   = "claimed"
+	HoldExpired   = "expired"
+	HoldCancelled = "cancelled"
+)
+
+type BookHold struct {
+	Id         int64
+	BookID     int64
+	UserID     int64
+	Status     string
+	BookCopyID *int64
+	ReadyAt    *time.Time
+	ExpiresAt  *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type BookHoldWithBook struct {
+	BookHold
+	BookTitle string
+	// Position is the place in the queue counting from 1, only set while the hold is waiting
+	Position *int64
+}
+
+// BookHoldOffer is a hold that just got a copy set aside, with what is needed to tell the user
+type BookHoldOffer struct {
+	HoldID    int64
+	UserID    int64
+	Email     string
+	Name      string
+	BookTitle string
+	ExpiresAt time.Time
+}
+
+type BookHoldResponse struct {
+	Id        int64      `json:"id"`
+	BookID    int64      `json:"book_id"`
+	BookTitle string     `json:"book_title"`
+	Status    string     `json:"status"`
+	Position  *int64     `json:"position,omitempty"`
+	ReadyAt   *time.Time `json:"ready_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func BookHoldToResponse(h *BookHoldWithBook) BookHoldResponse {
+	return BookHoldResponse{
+		Id:        h.Id,
+		BookID:    h.BookID,
+		BookTitle: h.BookTitle,
+		Status:    h.Status,
+		Position:  h.Position,
+		ReadyAt:   h.ReadyAt,
+		ExpiresAt: h.ExpiresAt,
+		CreatedAt: h.CreatedAt,
+	}
+}
+
+type BookHoldRepository interface {
+	GetTx(ctx context.Context) (pgx.Tx, error)
+
+	Store(ctx context.Context, tx pgx.Tx, hold *BookHold) (id int64, err error)
+	GetForUpdate(ctx context.Context, tx pgx.Tx, id int64) (*BookHold, error)
+	GetWithBook(ctx context.Context, id int64) (*BookHoldWithBook, error)
+	FetchActiveByUserID(ctx context.Context, userID int64) ([]BookHoldWithBook, error)
+	SetStatus(ctx context.Context, tx pgx.Tx, id int64, status string) error
+	// HasOthersQueued reports whether anyone but userID has a waiting or ready hold on bookID
+	HasOthersQueued(ctx context.Context, tx pgx.Tx, bookID, userID int64) (bool, error)
+
+	// Claim marks the ready hold of userID on bookID as claimed and returns the copy that was set aside for it
+	Claim(ctx context.Context, tx pgx.Tx, userID, bookID int64) (bookCopyID int64, err error)
+	// Offer sets aside up to limit available copies for the holds at the front of the queue
+	Offer(ctx context.Context, tx pgx.Tx, bookID, limit int64, expiresAt time.Time) ([]BookHoldOffer, error)
+	// ExpireReady expires the ready holds that were not claimed in time, puts their copies back on the shelf and
+	// returns the books that were affected
+	ExpireReady(ctx context.Context, now time.Time) (bookIDs []int64, err error)
+}
+
+type BookHoldUsecase interface {
+	Fetch(ctx context.Context, userID int64) ([]BookHoldResponse, error)
+	Store(ctx context.Context, bookID, userID int64) (BookHoldResponse, error)
+	Cancel(ctx context.Context, id, userID int64) error
+
+	// ProcessQueue hands the free copies of a book to the front of its hold queue
+	ProcessQueue(ctx context.Context, bookID int64) error
+	ExpireReady(ctx context.Context) error
+}
@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// LendingPolicy holds the loan limits for the users of a role, the policy without a role applies to everyone else
+type LendingPolicy struct {
+	Id              int64
+	RoleID          *int64
+	RoleName        *string
+	Priority        int
+	MaxLoans        int
+	LoanDays        int
+	MaxRenewals     int
+	DailyFine       float64
+	PriceMultiplier float64
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// LoanPeriod is how long a copy may be kept, a renewal pushes the due date out by the same
+func (p *LendingPolicy) LoanPeriod() time.Duration {
+	return time.Duration(p.LoanDays) * 24 * time.Hour
+}
+
+type LendingPolicyResponse struct {
+	Id              int64     `json:"id"`
+	RoleID          *int64    `json:"role_id"`
+	RoleName        *string   `json:"role_name"`
+	Priority        int       `json:"priority"`
+	MaxLoans        int       `json:"max_loans"`
+	LoanDays        int       `json:"loan_days"`
+	MaxRenewals     int       `json:"max_renewals"`
+	DailyFine       float64   `json:"daily_fine"`
+	PriceMultiplier float64   `json:"price_multiplier"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+func LendingPolicyToResponse(p *LendingPolicy) LendingPolicyResponse {
+	return LendingPolicyResponse{
+		Id:              p.Id,
+		RoleID:          p.RoleID,
+		RoleName:        p.RoleName,
+		Priority:        p.Priority,
+		MaxLoans:        p.MaxLoans,
+		LoanDays:        p.LoanDays,
+		MaxRenewals:     p.MaxRenewals,
+		DailyFine:       p.DailyFine,
+		PriceMultiplier: p.PriceMultiplier,
+		CreatedAt:       p.CreatedAt,
+		UpdatedAt:       p.UpdatedAt,
+	}
+}
+
+type StoreLendingPolicyRequest struct {
+	RoleID          int64   `json:"role_id" validate:"required"`
+	Priority        int     `json:"priority"`
+	MaxLoans        int     `json:"max_loans" validate:"required,min=1"`
+	LoanDays        int     `json:"loan_days" validate:"required,min=1,max=365"`
+	MaxRenewals     int     `json:"max_renewals" validate:"min=0"`
+	DailyFine       float64 `json:"daily_fine" validate:"min=0"`
+	PriceMultiplier float64 `json:"price_multiplier" validate:"gt=0"`
+}
+
+type UpdateLendingPolicyRequest struct {
+	ID              int64   `json:"-"`
+	Priority        int     `json:"priority"`
+	MaxLoans        int     `json:"max_loans" validate:"required,min=1"`
+	LoanDays        int     `json:"loan_days" validate:"required,min=1,max=365"`
+	MaxRenewals     int     `json:"max_renewals" validate:"min=0"`
+	DailyFine       float64 `json:"daily_fine" validate:"min=0"`
+	PriceMultiplier float64 `json:"price_multiplier" validate:"gt=0"`
+}
+
+type LendingPolicyRepository interface {
+	Fetch(ctx context.Context) ([]LendingPolicy, error)
+	GetByID(ctx context.Context, id int64) (*LendingPolicy, error)
+	Store(ctx context.Context, policy *LendingPolicy) (id int64, err error)
+	Update(ctx context.Context, policy *LendingPolicy) error
+	Delete(ctx context.Context, id int64) error
+
+	// ResolveForUser returns the policy of the user's role with the highest priority, or the default policy
+	ResolveForUser(ctx context.Context, userID int64) (*LendingPolicy, error)
+}
+
+type LendingPolicyUsecase interface {
+	Fetch(ctx context.Context) ([]LendingPolicyResponse, error)
+	Store(ctx context.Context, input *StoreLendingPolicyRequest) (LendingPolicyResponse, error)
+	Update(ctx context.Context, input *UpdateLendingPolicyRequest) (LendingPolicyResponse, error)
+	Delete(ctx context.Context, id int64) error
+	GetForUser(ctx context.Context, userID int64) (LendingPolicyResponse, error)
+}
@@ -2,18 +2,58 @@ package domain
 
 import (
 	"context"
+	"fmt"
+	"slices"
 	"time"
 
 	"github.com/jackc/pgx/v5"
 )
 
+// order lifecycle, every change of Order.Status goes through OrderRepository.Transition
+const (
+	OrderPendingPayment = "pending_payment"
+	OrderPaid           = "paid"
+	OrderReadyForPickup = "ready_for_pickup"
+	OrderOnLoan         = "on_loan"
+	OrderReturned       = "returned"
+	OrderCancelled      = "cancelled"
+	OrderExpired        = "expired"
+	OrderRefunded       = "refunded"
+)
+
+// orderTransitions lists where an order may go from each status, a paid order handed out over the desk skips
+// ready_for_pickup. A paid order ends refunded once all of its payment is given back, expired and refunded are final
+var orderTransitions = map[string][]string{
+	OrderPendingPayment: {OrderPaid, OrderCancelled, OrderExpired},
+	OrderPaid:           {OrderReadyForPickup, OrderOnLoan, OrderCancelled, OrderRefunded},
+	OrderReadyForPickup: {OrderOnLoan, OrderCancelled, OrderRefunded},
+	OrderOnLoan:         {OrderReturned},
+	OrderReturned:       {OrderRefunded},
+	OrderCancelled:      {OrderRefunded},
+}
+
+// CheckOrderTransition returns ErrOrderTransition when an order may not move from one status to the other
+func CheckOrderTransition(from, to string) error {
+	if !slices.Contains(orderTransitions[from], to) {
+		return fmt.Errorf("%w: %s to %s", ErrOrderTransition, from, to)
+	}
+
+	return nil
+}
+
 type Order struct {
 	Id            int64
 	OrderNumber   string
 	UserId        int64
+	Status        string
 	PaymentStatus string
+	// Subtotal is the sum of the item fees, Total what is charged after Discount
+	Subtotal      float64
+	Discount      float64
+	Total         float64
 	PaymentDate   *time.Time
 	PaymentMethod *string
+	CompletedAt   *time.Time
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
 }
@@ -22,19 +62,49 @@ type OrderDetail struct {
 	Id            int64
 	OrderId       int64
 	BookId        int64
+	BookCopyID    *int64
 	BorrowingDate *time.Time
-	ReturnDate    *time.Time
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
+	// ReturnDate is when the copy is due back, ReturnedAt when it actually came back
+	ReturnDate   *time.Time
+	ReturnedAt   *time.Time
+	OverdueAt    *time.Time
+	RenewalCount int
+	// UnitPrice is the price of the book when it was ordered, Fee what the borrower pays for the item
+	UnitPrice float64
+	Fee       float64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// LoanNotice is an open loan with what is needed to remind the borrower of it
+type LoanNotice struct {
+	OrderDetailID int64
+	UserID        int64
+	Email         string
+	Name          string
+	BookTitle     string
+	ReturnDate    time.Time
+}
+
+type OrderDetailRenewal struct {
+	Id                 int64
+	OrderDetailID      int64
+	PreviousReturnDate time.Time
+	NewReturnDate      time.Time
+	CreatedAt          time.Time
 }
 
 type CartItem struct {
-	BookId  int64
-	OrderId int64
+	BookId    int64
+	OrderId   int64
+	BookTitle string
+	Price     float64
+	Fee       float64
 }
 
 type OrderDetailWithBook struct {
 	OrderDetail
+	Barcode       *string
 	BookTitle     string
 	Description   string
 	TotalPage     int
@@ -48,30 +118,144 @@ type OrderWithDetailCount struct {
 	TotalOrderDetail int64
 }
 
+// OrderEvent is one step of an order through its lifecycle, FromStatus is nil for the order being placed
+type OrderEvent struct {
+	Id         int64
+	OrderId    int64
+	FromStatus *string
+	ToStatus   string
+	ActorID    *int64
+	ActorName  *string
+	Reason     string
+	CreatedAt  time.Time
+}
+
+type OrderEventResponse struct {
+	FromStatus *string   `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	ActorID    *int64    `json:"actor_id"`
+	ActorName  *string   `json:"actor_name"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// OrderItemCounts is how far the items of an order got, used to move the whole order along
+type OrderItemCounts struct {
+	Total    int
+	Bound    int
+	Returned int
+}
+
 type OrderResponse struct {
 	Id               int64      `json:"id"`
 	OrderNumber      string     `json:"order_number"`
+	Status           string     `json:"status"`
 	PaymentStatus    string     `json:"payment_status"`
+	Subtotal         float64    `json:"subtotal"`
+	Discount         float64    `json:"discount"`
+	Total            float64    `json:"total"`
 	PaymentDate      *time.Time `json:"payment_date"`
+	CompletedAt      *time.Time `json:"completed_at"`
 	UserId           int64      `json:"user_id"`
 	TotalOrderDetail int64      `json:"total_order_detail,omitempty"`
 	CreatedAt        time.Time  `json:"created_at,omitempty"`
 }
 
 type OrderDetailResponse struct {
-	Id            int64      `json:"id"`
-	OrderId       int64      `json:"order_id"`
-	BookId        int64      `json:"book_id"`
-	BorrowingDate *time.Time `json:"borrowing_date"`
-	ReturnDate    *time.Time `json:"return_date"`
-	CreatedAt     time.Time  `json:"created_at"`
-	UpdatedAt     time.Time  `json:"updated_at"`
-	BookTitle     string     `json:"book_title"`
-	Description   string     `json:"description"`
-	PublisherName string     `json:"publisher_name"`
-	PublishYear   int        `json:"publish_year"`
-	AuthorName    string     `json:"author_name"`
-	TotalPage     int        `json:"total_page"`
+	Id            int64                        `json:"id"`
+	OrderId       int64                        `json:"order_id"`
+	BookId        int64                        `json:"book_id"`
+	BookCopyID    *int64                       `json:"book_copy_id"`
+	Barcode       *string                      `json:"barcode"`
+	BorrowingDate *time.Time                   `json:"borrowing_date"`
+	ReturnDate    *time.Time                   `json:"return_date"`
+	ReturnedAt    *time.Time                   `json:"returned_at"`
+	OverdueAt     *time.Time                   `json:"overdue_at"`
+	RenewalCount  int                          `json:"renewal_count"`
+	UnitPrice     float64                      `json:"unit_price"`
+	Fee           float64                      `json:"fee"`
+	Renewals      []OrderDetailRenewalResponse `json:"renewals"`
+	CreatedAt     time.Time                    `json:"created_at"`
+	UpdatedAt     time.Time                    `json:"updated_at"`
+	BookTitle     string                       `json:"book_title"`
+	Description   string                       `json:"description"`
+	PublisherName string                       `json:"publisher_name"`
+	PublishYear   int                          `json:"publish_year"`
+	AuthorName    string                       `json:"author_name"`
+	TotalPage     int                          `json:"total_page"`
+}
+
+type OrderDetailsResponse struct {
+	OrderResponse
+	Items    []OrderDetailResponse `json:"items"`
+	Timeline []OrderEventResponse  `json:"timeline"`
+}
+
+// CreateOrderRequest places an order for everything in the cart of the user, PromotionCode is optional
+type CreateOrderRequest struct {
+	UserID        int64  `json:"-"`
+	PromotionCode string `json:"promotion_code" validate:"omitempty,max=50"`
+}
+
+type FulfillOrderRequest struct {
+	OrderID int64  `json:"-"`
+	StaffID int64  `json:"-"`
+	Barcode string `json:"barcode" validate:"required"`
+}
+
+type FulfillOrderResponse struct {
+	OrderDetailID int64  `json:"order_detail_id"`
+	BookId        int64  `json:"book_id"`
+	BookCopyID    int64  `json:"book_copy_id"`
+	Barcode       string `json:"barcode"`
+}
+
+type OrderDetailRenewalResponse struct {
+	PreviousReturnDate time.Time `json:"previous_return_date"`
+	NewReturnDate      time.Time `json:"new_return_date"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+type RenewLoanResponse struct {
+	OrderDetailID int64     `json:"order_detail_id"`
+	ReturnDate    time.Time `json:"return_date"`
+	RenewalCount  int       `json:"renewal_count"`
+	RenewalsLeft  int       `json:"renewals_left"`
+}
+
+// CheckInRequest identifies the returned item either by its order detail or by the barcode of the copy
+type CheckInRequest struct {
+	StaffID       int64  `json:"-"`
+	OrderDetailID int64  `json:"order_detail_id" validate:"required_without=Barcode"`
+	Barcode       string `json:"barcode" validate:"required_without=OrderDetailID"`
+}
+
+// RefundOrderRequest gives back Amount of what was paid for an order, all that is left when Amount is nil
+type RefundOrderRequest struct {
+	OrderID int64    `json:"-"`
+	StaffID int64    `json:"-"`
+	Amount  *float64 `json:"amount" validate:"omitempty,gt=0"`
+	Reason  string   `json:"reason" validate:"required,max=255"`
+}
+
+type RefundOrderResponse struct {
+	OrderID       int64   `json:"order_id"`
+	OrderNumber   string  `json:"order_number"`
+	Status        string  `json:"status"`
+	Amount        float64 `json:"amount"`
+	TotalRefunded float64 `json:"total_refunded"`
+	Remaining     float64 `json:"remaining"`
+}
+
+type CheckInResponse struct {
+	OrderDetailID  int64     `json:"order_detail_id"`
+	OrderId        int64     `json:"order_id"`
+	BookId         int64     `json:"book_id"`
+	BookCopyID     int64     `json:"book_copy_id"`
+	Barcode        string    `json:"barcode"`
+	ReturnedAt     time.Time `json:"returned_at"`
+	Late           bool      `json:"late"`
+	OrderCompleted bool      `json:"order_completed"`
 }
 
 type OrderRepository interface {
@@ -81,18 +265,76 @@ type OrderRepository interface {
 	SaveOrderDetailsFromCart(ctx context.Context, tx pgx.Tx, items []*CartItem, orderID, userID int64) error
 
 	GetByIDAndUserID(ctx context.Context, id, userId int64) (*Order, error)
+	GetByID(ctx context.Context, id int64) (*Order, error)
+	GetForUpdate(ctx context.Context, tx pgx.Tx, id int64) (*Order, error)
 	GetCartItems(ctx context.Context, tx pgx.Tx, userID int64) ([]*CartItem, error)
 	GetOrdersByUserID(ctx context.Context, userID int64) ([]OrderWithDetailCount, error)
 	GetOrderDetailWithBook(ctx context.Context, orderID int64) ([]OrderDetailWithBook, error)
-	GetPendingOrder(ctx context.Context, orderNumber string, userId int64) (bool, error)
+	GetOrderDetail(ctx context.Context, tx pgx.Tx, id int64) (*OrderDetail, error)
+	GetOrderDetailForUpdate(ctx context.Context, tx pgx.Tx, id int64) (*OrderDetail, error)
+	FetchRenewals(ctx context.Context, orderID int64) ([]OrderDetailRenewal, error)
+	GetOpenLoanByBarcode(ctx context.Context, tx pgx.Tx, barcode string) (*OrderDetail, error)
+	GetByNumberAndUserID(ctx context.Context, orderNumber string, userId int64) (*Order, error)
+	GetByNumber(ctx context.Context, orderNumber string) (*Order, error)
 
-	UpdateStock(ctx context.Context, tx pgx.Tx, orderID int64) error
-	UpdateBorrowDates(ctx context.Context, tx pgx.Tx, orderId int64) error
-	ClearCart(ctx context.Context, tx pgx.Tx, userID int64) error
+	// BindCopy hands copyID out for the first item of the order that is waiting for a copy of bookID
+	BindCopy(ctx context.Context, tx pgx.Tx, orderID, bookID, copyID int64) (orderDetailID int64, err error)
+	// UpdateBorrowDates starts the loans of a paid order, they are due loanDays from now
+	UpdateBorrowDates(ctx context.Context, tx pgx.Tx, orderId int64, loanDays int) error
+	// CountOpenLoans counts the items of the user's pending and paid orders that are not returned yet
+	CountOpenLoans(ctx context.Context, tx pgx.Tx, userID int64) (int, error)
+	// CloseUnboundDetails clears the due date of the items of an order that were never handed out, so they are
+	// not reminded, flagged or fined
+	CloseUnboundDetails(ctx context.Context, tx pgx.Tx, orderID int64) error
+	// HasPlacedOrder tells whether the user has an order that was not cancelled or expired
+	HasPlacedOrder(ctx context.Context, tx pgx.Tx, userID int64) (bool, error)
+	// MarkReturned closes the loan of an order detail, ErrOrderDetailNotFound when it was already returned
+	MarkReturned(ctx context.Context, tx pgx.Tx, detailID int64) (returnedAt time.Time, err error)
+	// Renew moves the due date of an order detail to returnDate and records the renewal
+	Renew(ctx context.Context, tx pgx.Tx, detailID int64, returnDate time.Time) error
+	// MarkDueSoon flags the open loans due before dueBefore that were not reminded yet and returns them
+	MarkDueSoon(ctx context.Context, dueBefore time.Time) ([]LoanNotice, error)
+	// MarkOverdue flags the open loans past their due date that were not flagged yet and returns them
+	MarkOverdue(ctx context.Context, now time.Time) ([]LoanNotice, error)
+	// FetchBookIDs returns the distinct books of an order in id order
+	FetchBookIDs(ctx context.Context, tx pgx.Tx, orderID int64) ([]int64, error)
+	// FetchUnpaidBefore returns the orders still waiting for payment that were placed before before
+	FetchUnpaidBefore(ctx context.Context, before time.Time) ([]int64, error)
+	// CountItems reports how many items of the order are handed out and returned
+	CountItems(ctx context.Context, tx pgx.Tx, orderID int64) (OrderItemCounts, error)
+	// Transition moves the order to status to and records the event, ErrOrderTransition when that is not allowed
+	// from where the order is. It is the only place the status of an order is changed
+	Transition(ctx context.Context, tx pgx.Tx, orderID int64, to string, actorID *int64, reason string) error
+	FetchEvents(ctx context.Context, orderID int64) ([]OrderEvent, error)
+	// FetchForReconciliation pages through the orders placed since since that are waiting for or have taken a
+	// payment through the gateway, in id order after afterID
+	FetchForReconciliation(ctx context.Context, since time.Time, afterID int64, limit int) ([]Order, error)
+	// GetOwnerContact returns where to reach the user who placed the order
+	GetOwnerContact(ctx context.Context, orderID int64) (email, name string, err error)
+	// ClearCart removes the ordered books from the cart of the user
+	ClearCart(ctx context.Context, tx pgx.Tx, userID int64, bookIDs []int64) error
 }
 
 type OrderUsecase interface {
-	CreateOrder(ctx context.Context, userID int64) (orderResp OrderResponse, err error)
+	CreateOrder(ctx context.Context, input *CreateOrderRequest) (orderResp OrderResponse, err error)
 	GetUserOrderHistory(ctx context.Context, userID int64) ([]OrderResponse, error)
-	GetUserOrderDetails(ctx context.Context, orderID, userID int64) ([]OrderDetailResponse, error)
+	GetUserOrderDetails(ctx context.Context, orderID, userID int64) (OrderDetailsResponse, error)
+	Fulfill(ctx context.Context, input *FulfillOrderRequest) (FulfillOrderResponse, error)
+	CheckIn(ctx context.Context, input *CheckInRequest) (CheckInResponse, error)
+	// Cancel withdraws an order that is still waiting for payment and puts its books back on the shelf
+	Cancel(ctx context.Context, orderID, userID int64) (OrderResponse, error)
+	// CloseByGateway moves an order waiting for payment to cancelled or expired once its gateway transaction was
+	// closed and puts its books back on the shelf. An order that is no longer waiting for payment is left as it is
+	CloseByGateway(ctx context.Context, orderID int64, to, reason string) error
+	// ExpireUnpaid expires the orders left unpaid past the payment window
+	ExpireUnpaid(ctx context.Context) error
+	// MarkReadyForPickup moves a paid order to ready_for_pickup once its copies are set aside at the desk
+	MarkReadyForPickup(ctx context.Context, orderID, staffID int64) (OrderResponse, error)
+	// Refund gives back all or part of what was paid for an order through the gateway, the order is refunded
+	// once nothing is left
+	Refund(ctx context.Context, input *RefundOrderRequest) (RefundOrderResponse, error)
+	Renew(ctx context.Context, orderID, detailID, userID int64) (RenewLoanResponse, error)
+
+	// ProcessOverdueLoans sends due date reminders, flags overdue loans and charges their late fees
+	ProcessOverdueLoans(ctx context.Context) error
 }
@@ -2,21 +2,27 @@ package domain
 
 import (
 	"context"
+	"encoding/json"
+	"io"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 )
 
+// PaymentRequest names the order to pay, what is charged comes from the order itself
 type PaymentRequest struct {
 	OrderNumber string `json:"order_number" validate:"required"`
-	Amount      int64  `json:"amount" validate:"required"`
-	UserId      int64  `json:"user_id" validate:"required"`
-	Email       string `json:"email" validate:"required"`
+	UserId      int64  `json:"-"`
+	Email       string `json:"-"`
 }
 
+// PaymentResponse has the checkout of an order waiting for payment. An order with nothing to pay comes back paid
+// with an amount of 0 and no checkout
 type PaymentResponse struct {
-	Amount     int64  `json:"amount"`
-	Token      string `json:"token"`
-	PaymentURL string `json:"payment_url"`
+	OrderStatus string `json:"order_status"`
+	Amount      int64  `json:"amount"`
+	Token       string `json:"token,omitempty"`
+	PaymentURL  string `json:"payment_url,omitempty"`
 }
 
 type PaymentStatusResponse struct {
@@ -29,13 +35,170 @@ type PaymentStatusRequest struct {
 	UserId  int64
 }
 
+// Transaction and fraud statuses as gateways report them, these are the names Midtrans uses and other gateways
+// translate to them
+const (
+	TransactionPending       = "pending"
+	TransactionCapture       = "capture"
+	TransactionSettlement    = "settlement"
+	TransactionDeny          = "deny"
+	TransactionCancel        = "cancel"
+	TransactionExpire        = "expire"
+	TransactionRefund        = "refund"
+	TransactionPartialRefund = "partial_refund"
+
+	FraudAccept    = "accept"
+	FraudChallenge = "challenge"
+	FraudDeny      = "deny"
+
+	// TransactionError is recorded for a call the gateway did not answer
+	TransactionError = "error"
+)
+
+// Where a payment transaction was learned from
+const (
+	PaymentSourceCreate       = "create"
+	PaymentSourceCheck        = "check"
+	PaymentSourceNotification = "notification"
+	PaymentSourceRefund       = "refund"
+)
+
+// PaymentNotification is the body of the HTTP notification a gateway posts when a transaction changes, in the
+// shape Midtrans sends it
+type PaymentNotification struct {
+	OrderID           string `json:"order_id" validate:"required"`
+	StatusCode        string `json:"status_code" validate:"required"`
+	GrossAmount       string `json:"gross_amount" validate:"required"`
+	SignatureKey      string `json:"signature_key" validate:"required"`
+	TransactionID     string `json:"transaction_id"`
+	TransactionStatus string `json:"transaction_status" validate:"required"`
+	FraudStatus       string `json:"fraud_status"`
+	PaymentType       string `json:"payment_type"`
+
+	// Raw is the body as it was posted
+	Raw json.RawMessage `json:"-"`
+}
+
+// PaymentTransaction is one exchange with the gateway about an order, kept as the gateway answered it
+type PaymentTransaction struct {
+	Id         int64
+	OrderId    int64
+	Gateway    string
+	Source     string
+	ExternalID *string
+	Amount     float64
+	Currency   string
+	Status     string
+	RawPayload json.RawMessage
+	CreatedAt  time.Time
+}
+
+type PaymentTransactionResponse struct {
+	ID         int64           `json:"id"`
+	Gateway    string          `json:"gateway"`
+	Source     string          `json:"source"`
+	ExternalID *string         `json:"external_id"`
+	Amount     float64         `json:"amount"`
+	Currency   string          `json:"currency"`
+	Status     string          `json:"status"`
+	RawPayload json.RawMessage `json:"raw_payload"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+func PaymentTransactionToResponse(t PaymentTransaction) PaymentTransactionResponse {
+	return PaymentTransactionResponse{
+		ID:         t.Id,
+		Gateway:    t.Gateway,
+		Source:     t.Source,
+		ExternalID: t.ExternalID,
+		Amount:     t.Amount,
+		Currency:   t.Currency,
+		Status:     t.Status,
+		RawPayload: t.RawPayload,
+		CreatedAt:  t.CreatedAt,
+	}
+}
+
 type PaymentRepository interface {
 	GetTx(ctx context.Context) (pgx.Tx, error)
 	ProcessPayment(ctx context.Context, tx pgx.Tx, userId int64, paymentMethod, orderNumber string) error
-	SetPaymentFailed(ctx context.Context, tx pgx.Tx, orderId int64) error
+	StoreTransaction(ctx context.Context, t *PaymentTransaction) error
+	// FetchTransactions returns the payment transactions of an order, oldest first
+	FetchTransactions(ctx context.Context, orderID int64) ([]PaymentTransaction, error)
+	// RecordRefund adds a refund the gateway accepted to the ledger within tx
+	RecordRefund(ctx context.Context, tx pgx.Tx, t *PaymentTransaction) error
+	// CountRefunds returns how many refunds of an order the gateway accepted and how much they gave back
+	CountRefunds(ctx context.Context, tx pgx.Tx, orderID int64) (count int, amount float64, err error)
+}
+
+// GatewayItem is a line of the bill shown by the gateway, prices are in whole rupiah
+type GatewayItem struct {
+	ID    string
+	Name  string
+	Price int64
+	Qty   int32
+}
+
+// GatewayCharge asks the gateway to collect Amount for an order, Items less Discount add up to Amount
+type GatewayCharge struct {
+	OrderNumber string
+	Amount      int64
+	Discount    int64
+	Email       string
+	Items       []GatewayItem
+}
+
+// GatewayCheckout is where the user goes to pay
+type GatewayCheckout struct {
+	Token       string
+	RedirectURL string
+	Raw         json.RawMessage
+}
+
+// GatewayTransaction is the state of the transaction of an order at the gateway
+type GatewayTransaction struct {
+	TransactionID     string
+	OrderNumber       string
+	TransactionStatus string
+	FraudStatus       string
+	PaymentType       string
+	GrossAmount       int64
+	Currency          string
+	Raw               json.RawMessage
+}
+
+// GatewayRefund gives money of a settled transaction back, RefundKey makes a retried refund count once
+type GatewayRefund struct {
+	RefundKey string
+	Amount    int64
+	Reason    string
+}
+
+// PaymentGateway is the provider that takes the payments of orders. Transactions are keyed by order number.
+type PaymentGateway interface {
+	Name() string
+	CreateTransaction(ctx context.Context, charge *GatewayCharge) (*GatewayCheckout, error)
+	// CheckTransaction returns ErrGatewayTransactionNotFound while the gateway knows nothing about the order
+	CheckTransaction(ctx context.Context, orderNumber string) (*GatewayTransaction, error)
+	// CancelTransaction voids the transaction of an order, an order that has none is not an error
+	CancelTransaction(ctx context.Context, orderNumber string) error
+	RefundTransaction(ctx context.Context, orderNumber string, refund *GatewayRefund) (*GatewayTransaction, error)
+	// VerifyNotification reports whether a notification was really sent by the gateway
+	VerifyNotification(n *PaymentNotification) bool
 }
 
 type PaymentUsecase interface {
 	CreatePayment(ctx context.Context, input *PaymentRequest) (PaymentResponse, error)
 	CheckPaymentStatus(ctx context.Context, input *PaymentStatusRequest) (PaymentStatusResponse, error)
+	// HandleNotification applies a signed status notification from the gateway to its order, a notification that
+	// was already applied is accepted again without changing anything
+	HandleNotification(ctx context.Context, input *PaymentNotification) error
+	FetchTransactions(ctx context.Context, orderID int64) ([]PaymentTransactionResponse, error)
+
+	// Reconcile compares the recent orders with the gateway, fixes the ones still waiting for a payment the
+	// gateway already settled or closed and stores a report of every discrepancy
+	Reconcile(ctx context.Context) error
+	FetchReconciliations(ctx context.Context) ([]PaymentReconciliationResponse, error)
+	GetReconciliation(ctx context.Context, id int64) (PaymentReconciliationResponse, error)
+	DownloadReconciliation(ctx context.Context, id int64) (io.ReadCloser, error)
 }
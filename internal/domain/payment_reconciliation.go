@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	ReconciliationStatusProcessing = "processing"
+	ReconciliationStatusCompleted  = "completed"
+	ReconciliationStatusFailed     = "failed"
+
+	// DiscrepancyFixed is an order that was moved to where the gateway says it is, DiscrepancyNeedsReview one
+	// that cannot be moved by a transition and is left for an admin
+	DiscrepancyFixed       = "fixed"
+	DiscrepancyNeedsReview = "needs_review"
+)
+
+// PaymentDiscrepancy is an order that did not match its transaction at the gateway, one line of the report
+type PaymentDiscrepancy struct {
+	OrderID       int64
+	OrderNumber   string
+	OrderStatus   string
+	PaymentStatus string
+	OrderTotal    float64
+	GatewayStatus string
+	FraudStatus   string
+	GatewayAmount int64
+	Problem       string
+	Resolution    string
+}
+
+type PaymentReconciliation struct {
+	Id            int64
+	Gateway       string
+	Status        string
+	Since         time.Time
+	Checked       int
+	Fixed         int
+	Discrepancies int
+	FileKey       *string
+	ErrorMessage  *string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type PaymentReconciliationResponse struct {
+	ID            int64     `json:"id"`
+	Gateway       string    `json:"gateway"`
+	Status        string    `json:"status"`
+	Since         time.Time `json:"since"`
+	Checked       int       `json:"checked"`
+	Fixed         int       `json:"fixed"`
+	Discrepancies int       `json:"discrepancies"`
+	HasReport     bool      `json:"has_report"`
+	ErrorMessage  *string   `json:"error_message,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func PaymentReconciliationToResponse(r *PaymentReconciliation) PaymentReconciliationResponse {
+	return PaymentReconciliationResponse{
+		ID:            r.Id,
+		Gateway:       r.Gateway,
+		Status:        r.Status,
+		Since:         r.Since,
+		Checked:       r.Checked,
+		Fixed:         r.Fixed,
+		Discrepancies: r.Discrepancies,
+		HasReport:     r.FileKey != nil,
+		ErrorMessage:  r.ErrorMessage,
+		CreatedAt:     r.CreatedAt,
+		UpdatedAt:     r.UpdatedAt,
+	}
+}
+
+type PaymentReconciliationRepository interface {
+	Store(ctx context.Context, r *PaymentReconciliation) (id int64, err error)
+	GetByID(ctx context.Context, id int64) (*PaymentReconciliation, error)
+	// Fetch returns the latest runs, newest first
+	Fetch(ctx context.Context, limit int) ([]PaymentReconciliation, error)
+	SetStatus(ctx context.Context, id int64, status string, errorMessage *string) error
+	Complete(ctx context.Context, id int64, fileKey string, checked, fixed, discrepancies int) error
+}
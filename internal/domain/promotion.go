@@ -0,0 +1,171 @@
+package domain
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	PromotionPercentage = "percentage"
+	PromotionFixed      = "fixed"
+)
+
+// Promotion is a discount code. Without categories and books it takes off the whole order, otherwise only the
+// books in its scope count towards the discount
+type Promotion struct {
+	Id             int64
+	Code           string
+	Description    string
+	DiscountType   string
+	DiscountValue  float64
+	MinSubtotal    float64
+	StartsAt       time.Time
+	EndsAt         *time.Time
+	UsageLimit     *int
+	PerUserLimit   *int
+	FirstOrderOnly bool
+	Active         bool
+	CategoryIDs    []int64
+	BookIDs        []int64
+	// Redeemed counts the orders that took the promotion and were not cancelled or expired
+	Redeemed  int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Scoped tells whether the promotion is limited to some categories or books
+func (p *Promotion) Scoped() bool {
+	return len(p.CategoryIDs) > 0 || len(p.BookIDs) > 0
+}
+
+// ValidAt tells whether the promotion can be redeemed at t
+func (p *Promotion) ValidAt(t time.Time) bool {
+	return p.Active && !t.Before(p.StartsAt) && (p.EndsAt == nil || t.Before(*p.EndsAt))
+}
+
+// DiscountFor is what the promotion takes off eligible, the fees of the books in its scope. It never takes off
+// more than eligible and is rounded to whole rupiah like the fees
+func (p *Promotion) DiscountFor(eligible float64) float64 {
+	discount := p.DiscountValue
+
+	if p.DiscountType == PromotionPercentage {
+		discount = math.Round(eligible * p.DiscountValue / 100)
+	}
+
+	return math.Min(math.Round(discount), eligible)
+}
+
+type PromotionRedemption struct {
+	Id          int64
+	PromotionID int64
+	OrderID     int64
+	UserID      int64
+	Discount    float64
+	CreatedAt   time.Time
+}
+
+type PromotionResponse struct {
+	ID             int64      `json:"id"`
+	Code           string     `json:"code"`
+	Description    string     `json:"description"`
+	DiscountType   string     `json:"discount_type"`
+	DiscountValue  float64    `json:"discount_value"`
+	MinSubtotal    float64    `json:"min_subtotal"`
+	StartsAt       time.Time  `json:"starts_at"`
+	EndsAt         *time.Time `json:"ends_at"`
+	UsageLimit     *int       `json:"usage_limit"`
+	PerUserLimit   *int       `json:"per_user_limit"`
+	FirstOrderOnly bool       `json:"first_order_only"`
+	Active         bool       `json:"active"`
+	CategoryIDs    []int64    `json:"category_ids"`
+	BookIDs        []int64    `json:"book_ids"`
+	Redeemed       int        `json:"redeemed"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func PromotionToResponse(p *Promotion) PromotionResponse {
+	return PromotionResponse{
+		ID:             p.Id,
+		Code:           p.Code,
+		Description:    p.Description,
+		DiscountType:   p.DiscountType,
+		DiscountValue:  p.DiscountValue,
+		MinSubtotal:    p.MinSubtotal,
+		StartsAt:       p.StartsAt,
+		EndsAt:         p.EndsAt,
+		UsageLimit:     p.UsageLimit,
+		PerUserLimit:   p.PerUserLimit,
+		FirstOrderOnly: p.FirstOrderOnly,
+		Active:         p.Active,
+		CategoryIDs:    p.CategoryIDs,
+		BookIDs:        p.BookIDs,
+		Redeemed:       p.Redeemed,
+		CreatedAt:      p.CreatedAt,
+		UpdatedAt:      p.UpdatedAt,
+	}
+}
+
+// StorePromotionRequest creates a promotion, it starts right away when StartsAt is nil and never ends when
+// EndsAt is nil
+type StorePromotionRequest struct {
+	Code           string     `json:"code" validate:"required,alphanum,min=3,max=50"`
+	Description    string     `json:"description" validate:"max=255"`
+	DiscountType   string     `json:"discount_type" validate:"required,oneof=percentage fixed"`
+	DiscountValue  float64    `json:"discount_value" validate:"gt=0"`
+	MinSubtotal    float64    `json:"min_subtotal" validate:"min=0"`
+	StartsAt       *time.Time `json:"starts_at"`
+	EndsAt         *time.Time `json:"ends_at"`
+	UsageLimit     *int       `json:"usage_limit" validate:"omitempty,min=1"`
+	PerUserLimit   *int       `json:"per_user_limit" validate:"omitempty,min=1"`
+	FirstOrderOnly bool       `json:"first_order_only"`
+	CategoryIDs    []int64    `json:"category_ids" validate:"dive,gt=0"`
+	BookIDs        []int64    `json:"book_ids" validate:"dive,gt=0"`
+}
+
+// UpdatePromotionRequest replaces everything but the code, which orders may already have been placed with
+type UpdatePromotionRequest struct {
+	ID             int64      `json:"-"`
+	Description    string     `json:"description" validate:"max=255"`
+	DiscountType   string     `json:"discount_type" validate:"required,oneof=percentage fixed"`
+	DiscountValue  float64    `json:"discount_value" validate:"gt=0"`
+	MinSubtotal    float64    `json:"min_subtotal" validate:"min=0"`
+	StartsAt       time.Time  `json:"starts_at" validate:"required"`
+	EndsAt         *time.Time `json:"ends_at"`
+	UsageLimit     *int       `json:"usage_limit" validate:"omitempty,min=1"`
+	PerUserLimit   *int       `json:"per_user_limit" validate:"omitempty,min=1"`
+	FirstOrderOnly bool       `json:"first_order_only"`
+	Active         bool       `json:"active"`
+	CategoryIDs    []int64    `json:"category_ids" validate:"dive,gt=0"`
+	BookIDs        []int64    `json:"book_ids" validate:"dive,gt=0"`
+}
+
+type PromotionRepository interface {
+	GetTx(ctx context.Context) (pgx.Tx, error)
+	Fetch(ctx context.Context) ([]Promotion, error)
+	GetByID(ctx context.Context, id int64) (*Promotion, error)
+	Store(ctx context.Context, tx pgx.Tx, promotion *Promotion) (id int64, err error)
+	Update(ctx context.Context, tx pgx.Tx, promotion *Promotion) error
+	// Delete removes a promotion, ErrPromotionRedeemed once an order has taken it
+	Delete(ctx context.Context, id int64) error
+
+	// GetByCodeForUpdate locks the promotion until the end of tx, the redemptions of a code are checked and
+	// taken one order at a time
+	GetByCodeForUpdate(ctx context.Context, tx pgx.Tx, code string) (*Promotion, error)
+	// CountRedemptions counts the redemptions of the promotion that still stand, in total and by the user
+	CountRedemptions(ctx context.Context, tx pgx.Tx, promotionID, userID int64) (total, byUser int, err error)
+	// EligibleBooks returns which of bookIDs are in the scope of the promotion
+	EligibleBooks(ctx context.Context, tx pgx.Tx, promotionID int64, bookIDs []int64) ([]int64, error)
+	Redeem(ctx context.Context, tx pgx.Tx, redemption *PromotionRedemption) error
+}
+
+type PromotionUsecase interface {
+	Fetch(ctx context.Context) ([]PromotionResponse, error)
+	Get(ctx context.Context, id int64) (PromotionResponse, error)
+	Store(ctx context.Context, input *StorePromotionRequest) (PromotionResponse, error)
+	Update(ctx context.Context, input *UpdatePromotionRequest) (PromotionResponse, error)
+	Delete(ctx context.Context, id int64) error
+}
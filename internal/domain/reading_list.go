@@ -0,0 +1,145 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// DefaultReadingListName is the name of the list every user gets, books on it are watched for restocks
+const DefaultReadingListName = "Wishlist"
+
+type ReadingList struct {
+	Id         int64
+	UserID     int64
+	Name       string
+	IsPublic   bool
+	IsDefault  bool
+	TotalItems int64
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type ReadingListItem struct {
+	Id            int64
+	ReadingListID int64
+	BookID        int64
+	Position      int
+	BookTitle     string
+	ImageUrl      string
+	IsAvailable   bool
+	CreatedAt     time.Time
+}
+
+type ReadingListResponse struct {
+	Id         int64                     `json:"id"`
+	UserID     int64                     `json:"user_id"`
+	Name       string                    `json:"name"`
+	IsPublic   bool                      `json:"is_public"`
+	IsDefault  bool                      `json:"is_default"`
+	TotalItems int64                     `json:"total_items"`
+	Items      []ReadingListItemResponse `json:"items,omitempty"`
+	CreatedAt  time.Time                 `json:"created_at"`
+	UpdatedAt  time.Time                 `json:"updated_at"`
+}
+
+type ReadingListItemResponse struct {
+	BookID      int64     `json:"book_id"`
+	Position    int       `json:"position"`
+	BookTitle   string    `json:"book_title"`
+	ImageUrl    string    `json:"image_url"`
+	IsAvailable bool      `json:"is_available"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func ReadingListToResponse(l *ReadingList) ReadingListResponse {
+	return ReadingListResponse{
+		Id:         l.Id,
+		UserID:     l.UserID,
+		Name:       l.Name,
+		IsPublic:   l.IsPublic,
+		IsDefault:  l.IsDefault,
+		TotalItems: l.TotalItems,
+		CreatedAt:  l.CreatedAt,
+		UpdatedAt:  l.UpdatedAt,
+	}
+}
+
+func ReadingListItemToResponse(i *ReadingListItem) ReadingListItemResponse {
+	return ReadingListItemResponse{
+		BookID:      i.BookID,
+		Position:    i.Position,
+		BookTitle:   i.BookTitle,
+		ImageUrl:    i.ImageUrl,
+		IsAvailable: i.IsAvailable,
+		CreatedAt:   i.CreatedAt,
+	}
+}
+
+type StoreReadingListRequest struct {
+	UserID   int64  `json:"-"`
+	Name     string `json:"name" validate:"required,max=100"`
+	IsPublic bool   `json:"is_public"`
+}
+
+type UpdateReadingListRequest struct {
+	ID       int64  `json:"-"`
+	UserID   int64  `json:"-"`
+	Name     string `json:"name" validate:"required,max=100"`
+	IsPublic bool   `json:"is_public"`
+}
+
+type AddReadingListItemRequest struct {
+	BookID int64 `json:"book_id" validate:"required"`
+}
+
+type ReorderReadingListRequest struct {
+	BookIDs []int64 `json:"book_ids" validate:"required,min=1"`
+}
+
+// RestockWatcher is a user who has a book that came back in stock on their wishlist
+type RestockWatcher struct {
+	UserID    int64
+	Email     string
+	Name      string
+	BookTitle string
+}
+
+type ReadingListRepository interface {
+	GetTx(ctx context.Context) (pgx.Tx, error)
+
+	Store(ctx context.Context, tx pgx.Tx, list *ReadingList) (id int64, err error)
+	// EnsureDefault creates the wishlist of a user unless it exists already
+	EnsureDefault(ctx context.Context, userID int64) error
+	GetByID(ctx context.Context, id int64) (*ReadingList, error)
+	GetForUpdate(ctx context.Context, tx pgx.Tx, id int64) (*ReadingList, error)
+	FetchByUserID(ctx context.Context, userID int64) ([]ReadingList, error)
+	Update(ctx context.Context, tx pgx.Tx, list *ReadingList) error
+	Delete(ctx context.Context, tx pgx.Tx, id int64) error
+
+	FetchItems(ctx context.Context, listID int64) ([]ReadingListItem, error)
+	// AddItem appends the book at the end of the list
+	AddItem(ctx context.Context, tx pgx.Tx, listID, bookID int64) error
+	RemoveItem(ctx context.Context, tx pgx.Tx, listID, bookID int64) error
+	// Reorder gives the books their position in the order of bookIDs, which must hold every book on the list
+	Reorder(ctx context.Context, tx pgx.Tx, listID int64, bookIDs []int64) error
+
+	FetchRestockWatchers(ctx context.Context, bookID int64) ([]RestockWatcher, error)
+}
+
+type ReadingListUsecase interface {
+	Fetch(ctx context.Context, userID int64) ([]ReadingListResponse, error)
+	Get(ctx context.Context, id, userID int64) (ReadingListResponse, error)
+	GetPublic(ctx context.Context, id int64) (ReadingListResponse, error)
+	Store(ctx context.Context, input *StoreReadingListRequest) (ReadingListResponse, error)
+	Update(ctx context.Context, input *UpdateReadingListRequest) (ReadingListResponse, error)
+	Delete(ctx context.Context, id, userID int64) error
+
+	AddItem(ctx context.Context, id, userID, bookID int64) error
+	RemoveItem(ctx context.Context, id, userID, bookID int64) error
+	Reorder(ctx context.Context, id, userID int64, bookIDs []int64) error
+	MoveToCart(ctx context.Context, id, userID, bookID int64) (cartID int64, err error)
+
+	NotifyRestocked(ctx context.Context, bookID int64) error
+}
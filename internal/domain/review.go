@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type Review struct {
+	Id        int64
+	BookID    int64
+	UserID    int64
+	Rating    int
+	Body      *string
+	HiddenAt  *time.Time
+	HiddenBy  *int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ReviewWithUser is a review joined with the name of the user who wrote it
+type ReviewWithUser struct {
+	Review
+	UserName string
+}
+
+type ReviewResponse struct {
+	Id        int64      `json:"id"`
+	BookID    int64      `json:"book_id"`
+	UserID    int64      `json:"user_id"`
+	UserName  string     `json:"user_name,omitempty"`
+	Rating    int        `json:"rating"`
+	Body      *string    `json:"body"`
+	Hidden    bool       `json:"hidden"`
+	HiddenAt  *time.Time `json:"hidden_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func ReviewToResponse(r *Review) ReviewResponse {
+	return ReviewResponse{
+		Id:        r.Id,
+		BookID:    r.BookID,
+		UserID:    r.UserID,
+		Rating:    r.Rating,
+		Body:      r.Body,
+		Hidden:    r.HiddenAt != nil,
+		HiddenAt:  r.HiddenAt,
+		CreatedAt: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt,
+	}
+}
+
+type StoreReviewRequest struct {
+	BookID int64   `json:"-"`
+	UserID int64   `json:"-"`
+	Rating int     `json:"rating" validate:"required,min=1,max=5"`
+	Body   *string `json:"body" validate:"omitempty,max=5000"`
+}
+
+type UpdateReviewRequest struct {
+	ID     int64   `json:"-"`
+	UserID int64   `json:"-"`
+	Rating int     `json:"rating" validate:"required,min=1,max=5"`
+	Body   *string `json:"body" validate:"omitempty,max=5000"`
+}
+
+type ReviewRepository interface {
+	GetTx(ctx context.Context) (pgx.Tx, error)
+
+	Store(ctx context.Context, tx pgx.Tx, review *Review) (id int64, err error)
+	GetForUpdate(ctx context.Context, tx pgx.Tx, id int64) (*Review, error)
+	Update(ctx context.Context, tx pgx.Tx, review *Review) error
+	Delete(ctx context.Context, tx pgx.Tx, id int64) error
+	SetHidden(ctx context.Context, tx pgx.Tx, id int64, hiddenBy *int64) error
+	FetchVisibleByBookID(ctx context.Context, bookID int64, params RequestQueryParams) (reviews []ReviewWithUser, total int64, err error)
+
+	// HasBorrowed reports whether the user has a paid order with the book in it
+	HasBorrowed(ctx context.Context, tx pgx.Tx, userID, bookID int64) (bool, error)
+	// AdjustBookRating adds the deltas to the rating aggregates of a book and recomputes its average
+	AdjustBookRating(ctx context.Context, tx pgx.Tx, bookID int64, countDelta, sumDelta int) error
+}
+
+type ReviewUsecase interface {
+	Fetch(ctx context.Context, bookID int64, params RequestQueryParams) ([]ReviewResponse, int64, error)
+	Store(ctx context.Context, input *StoreReviewRequest) (ReviewResponse, error)
+	Update(ctx context.Context, input *UpdateReviewRequest) (ReviewResponse, error)
+	Delete(ctx context.Context, id, userID int64) error
+	SetHidden(ctx context.Context, id, moderatorID int64, hidden bool) error
+}
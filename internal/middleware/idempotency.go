@@ -0,0 +1,257 @@
+package middleware
+
+import (
+	"backend-layout/internal/httpcontext"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	idempotencyKeyMaxLength   = 255
+	idempotencyStateStarted   = "processing"
+	idempotencyStateCompleted = "completed"
+
+	// idempotencyInFlightTTL is how long a key stays taken by a request that never finishes, a crashed server
+	// must not lock the key for the whole ttl
+	idempotencyInFlightTTL = time.Minute
+)
+
+var errIdempotencyKeyNotFound = errors.New("idempotency key not found")
+
+// idempotencyStore keeps the idempotency records, Get answers errIdempotencyKeyNotFound for a missing key
+type idempotencyStore interface {
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Del(ctx context.Context, key string) error
+}
+
+type redisIdempotencyStore struct {
+	rdb *redis.Client
+}
+
+func (s *redisIdempotencyStore) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	return s.rdb.SetNX(ctx, key, value, ttl).Result()
+}
+
+func (s *redisIdempotencyStore) Get(ctx context.Context, key string) ([]byte, error) {
+	b, err := s.rdb.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, errIdempotencyKeyNotFound
+	}
+
+	return b, err
+}
+
+func (s *redisIdempotencyStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.rdb.Set(ctx, key, value, ttl).Err()
+}
+
+func (s *redisIdempotencyStore) Del(ctx context.Context, key string) error {
+	return s.rdb.Del(ctx, key).Err()
+}
+
+// idempotencyRecord is what is kept in redis for a key, the response once the first request finished
+type idempotencyRecord struct {
+	State       string `json:"state"`
+	Fingerprint string `json:"fingerprint"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+type IdempotencyMiddleware struct {
+	store idempotencyStore
+	ttl   time.Duration
+}
+
+// NewIdempotencyMiddleware remembers the responses of requests sent with an Idempotency-Key header for ttl
+func NewIdempotencyMiddleware(rdb *redis.Client, ttl time.Duration) *IdempotencyMiddleware {
+	return &IdempotencyMiddleware{store: &redisIdempotencyStore{rdb: rdb}, ttl: ttl}
+}
+
+// Idempotent replays the stored response when a request is retried with the same Idempotency-Key and body, and
+// answers 409 when the key comes back with a different request or while the first one is still running. Requests
+// without the header pass through, server errors are not stored so the client can retry them.
+func (m *IdempotencyMiddleware) Idempotent() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get(IdempotencyKeyHeader)
+
+			if key == "" {
+				return next(c)
+			}
+
+			if len(key) > idempotencyKeyMaxLength {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("%s must be at most %d characters", IdempotencyKeyHeader, idempotencyKeyMaxLength))
+			}
+
+			au, ok := httpcontext.GetUserJWT(c)
+			if !ok {
+				return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+			}
+
+			body, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "failed to read request body")
+			}
+
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := c.Request().Context()
+			redisKey := fmt.Sprintf("idempotency:%d:%s", au.ID, key)
+			fingerprint := requestFingerprint(c.Request().Method, c.Request().URL.Path, body)
+
+			started, err := json.Marshal(idempotencyRecord{State: idempotencyStateStarted, Fingerprint: fingerprint})
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+			}
+
+			first, err := m.store.SetNX(ctx, redisKey, started, idempotencyInFlightTTL)
+			if err != nil {
+				log.Error().Err(err).Str("layer", "middleware").Msg("failed to store idempotency key")
+
+				return echo.NewHTTPError(http.StatusInternalServerError, "failed to process request")
+			}
+
+			if !first {
+				return m.replay(c, redisKey, fingerprint)
+			}
+
+			// the outcome is stored even when the client hung up, that is exactly when it comes back with a retry
+			storeCtx := context.WithoutCancel(ctx)
+
+			defer func() {
+				if p := recover(); p != nil {
+					m.release(storeCtx, redisKey)
+
+					panic(p)
+				}
+			}()
+
+			recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+
+			// render the error here so the response can be stored like any other
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+
+			status := c.Response().Status
+
+			// a server error may go away on retry, let the key be used again
+			if status >= http.StatusInternalServerError {
+				m.release(storeCtx, redisKey)
+
+				return nil
+			}
+
+			completed, err := json.Marshal(idempotencyRecord{
+				State:       idempotencyStateCompleted,
+				Fingerprint: fingerprint,
+				Status:      status,
+				ContentType: c.Response().Header().Get(echo.HeaderContentType),
+				Body:        recorder.body.Bytes(),
+			})
+
+			if err == nil {
+				err = m.store.Set(storeCtx, redisKey, completed, m.ttl)
+			}
+
+			if err != nil {
+				log.Error().Err(err).Str("layer", "middleware").Msg("failed to store idempotent response")
+			}
+
+			return nil
+		}
+	}
+}
+
+func (m *IdempotencyMiddleware) release(ctx context.Context, redisKey string) {
+	if err := m.store.Del(ctx, redisKey); err != nil {
+		log.Error().Err(err).Str("layer", "middleware").Msg("failed to release idempotency key")
+	}
+}
+
+func (m *IdempotencyMiddleware) replay(c echo.Context, redisKey, fingerprint string) error {
+	stored, err := m.store.Get(c.Request().Context(), redisKey)
+	if err != nil {
+		// expired or released between the two calls, the client may simply retry
+		if errors.Is(err, errIdempotencyKeyNotFound) {
+			return echo.NewHTTPError(http.StatusConflict, "request with this Idempotency-Key is still being processed, retry later")
+		}
+
+		log.Error().Err(err).Str("layer", "middleware").Msg("failed to get idempotency key")
+
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to process request")
+	}
+
+	var record idempotencyRecord
+
+	if err := json.Unmarshal(stored, &record); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to process request")
+	}
+
+	if record.Fingerprint != fingerprint {
+		return echo.NewHTTPError(http.StatusConflict, "Idempotency-Key was already used for a different request")
+	}
+
+	if record.State != idempotencyStateCompleted {
+		return echo.NewHTTPError(http.StatusConflict, "request with this Idempotency-Key is still being processed, retry later")
+	}
+
+	c.Response().Header().Set(IdempotentReplayedHeader, "true")
+
+	return c.Blob(record.Status, record.ContentType, record.Body)
+}
+
+func requestFingerprint(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder keeps a copy of what is written to the client
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+
+	return h.Hijack()
+}
@@ -0,0 +1,267 @@
+package middleware
+
+import (
+	"backend-layout/internal/adapter/jwt"
+	"backend-layout/internal/httpcontext"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// memoryIdempotencyStore fails like redis does once the context of a call is done
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string][]byte
+	ttls    map[string]time.Duration
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: map[string][]byte{}, ttls: map[string]time.Duration{}}
+}
+
+func (s *memoryIdempotencyStore) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.records[key]; ok {
+		return false, nil
+	}
+
+	s.records[key], s.ttls[key] = value, ttl
+
+	return true, nil
+}
+
+func (s *memoryIdempotencyStore) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.records[key]
+	if !ok {
+		return nil, errIdempotencyKeyNotFound
+	}
+
+	return b, nil
+}
+
+func (s *memoryIdempotencyStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[key], s.ttls[key] = value, ttl
+
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Del(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	delete(s.ttls, key)
+
+	return nil
+}
+
+func (s *memoryIdempotencyStore) has(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.records[key]
+
+	return ok
+}
+
+const testIdempotencyKey = "idempotency:1:key-1"
+
+func newTestIdempotency() (*IdempotencyMiddleware, *memoryIdempotencyStore) {
+	store := newMemoryIdempotencyStore()
+
+	return &IdempotencyMiddleware{store: store, ttl: 24 * time.Hour}, store
+}
+
+// serve runs one request with the Idempotency-Key key-1 through the middleware
+func serve(t *testing.T, m *IdempotencyMiddleware, ctx context.Context, body string, handler echo.HandlerFunc) *httptest.ResponseRecorder {
+	t.Helper()
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body)).WithContext(ctx)
+	req.Header.Set(IdempotencyKeyHeader, "key-1")
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	c := e.NewContext(req, rec)
+	c.Set(httpcontext.UserKey, &jwt.User{ID: 1})
+
+	if err := m.Idempotent()(handler)(c); err != nil {
+		e.HTTPErrorHandler(err, c)
+	}
+
+	return rec
+}
+
+func created(calls *int) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		*calls++
+
+		return c.JSON(http.StatusCreated, map[string]int{"call": *calls})
+	}
+}
+
+func TestIdempotentReplaysCompletedResponse(t *testing.T) {
+	m, store := newTestIdempotency()
+	calls := 0
+
+	first := serve(t, m, context.Background(), `{"a":1}`, created(&calls))
+	second := serve(t, m, context.Background(), `{"a":1}`, created(&calls))
+
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls)
+	}
+
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Errorf("replay = %d %q, want %d %q", second.Code, second.Body.String(), first.Code, first.Body.String())
+	}
+
+	if second.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("replay is missing the %s header", IdempotentReplayedHeader)
+	}
+
+	if ttl := store.ttls[testIdempotencyKey]; ttl != m.ttl {
+		t.Errorf("completed ttl = %v, want %v", ttl, m.ttl)
+	}
+}
+
+func TestIdempotentRejectsDifferentRequest(t *testing.T) {
+	m, _ := newTestIdempotency()
+	calls := 0
+
+	serve(t, m, context.Background(), `{"a":1}`, created(&calls))
+	rec := serve(t, m, context.Background(), `{"a":2}`, created(&calls))
+
+	if rec.Code != http.StatusConflict {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+
+	if calls != 1 {
+		t.Errorf("handler ran %d times, want 1", calls)
+	}
+}
+
+func TestIdempotentKeepsKeyShortWhileInFlight(t *testing.T) {
+	m, store := newTestIdempotency()
+	calls := 0
+
+	serve(t, m, context.Background(), `{"a":1}`, func(c echo.Context) error {
+		if ttl := store.ttls[testIdempotencyKey]; ttl != idempotencyInFlightTTL {
+			t.Errorf("in-flight ttl = %v, want %v", ttl, idempotencyInFlightTTL)
+		}
+
+		if rec := serve(t, m, context.Background(), `{"a":1}`, created(&calls)); rec.Code != http.StatusConflict {
+			t.Errorf("concurrent retry status = %d, want %d", rec.Code, http.StatusConflict)
+		}
+
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	if calls != 0 {
+		t.Errorf("concurrent retry ran the handler %d times", calls)
+	}
+}
+
+func TestIdempotentReleasesKeyOnServerError(t *testing.T) {
+	m, store := newTestIdempotency()
+	calls := 0
+
+	rec := serve(t, m, context.Background(), `{"a":1}`, func(c echo.Context) error {
+		return errors.New("boom")
+	})
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+
+	if store.has(testIdempotencyKey) {
+		t.Fatal("key was not released after a server error")
+	}
+
+	if rec := serve(t, m, context.Background(), `{"a":1}`, created(&calls)); rec.Code != http.StatusCreated || calls != 1 {
+		t.Errorf("retry = %d after %d calls, want %d after 1", rec.Code, calls, http.StatusCreated)
+	}
+}
+
+func TestIdempotentReleasesKeyWhenClientHangsUp(t *testing.T) {
+	m, store := newTestIdempotency()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	serve(t, m, ctx, `{"a":1}`, func(c echo.Context) error {
+		cancel()
+
+		return c.Request().Context().Err()
+	})
+
+	if store.has(testIdempotencyKey) {
+		t.Fatal("key stayed taken after the client hung up")
+	}
+}
+
+func TestIdempotentStoresResponseWhenClientHangsUp(t *testing.T) {
+	m, _ := newTestIdempotency()
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+
+	serve(t, m, ctx, `{"a":1}`, func(c echo.Context) error {
+		cancel()
+
+		return created(&calls)(c)
+	})
+
+	rec := serve(t, m, context.Background(), `{"a":1}`, created(&calls))
+
+	if calls != 1 || rec.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("retry after hang up ran the handler again (%d calls)", calls)
+	}
+}
+
+func TestIdempotentReleasesKeyOnPanic(t *testing.T) {
+	m, store := newTestIdempotency()
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("panic was swallowed")
+			}
+		}()
+
+		serve(t, m, context.Background(), `{"a":1}`, func(c echo.Context) error {
+			panic("boom")
+		})
+	}()
+
+	if store.has(testIdempotencyKey) {
+		t.Error("key stayed taken after a panic")
+	}
+}
@@ -0,0 +1,134 @@
+package http
+
+import (
+	"backend-layout/helper"
+	"backend-layout/internal/domain"
+	"backend-layout/internal/middleware"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+type BookCopyHandler struct {
+	copyUsecase domain.BookCopyUsecase
+}
+
+func NewBookCopyHandler(r *echo.Group, cu domain.BookCopyUsecase, rbac *middleware.RBACMiddleware) {
+	handler := &BookCopyHandler{
+		copyUsecase: cu,
+	}
+
+	r.GET("/books/:id/copies", handler.List, rbac.RequiredPermission("book:copies"))
+	r.POST("/books/:id/copies", handler.Store, rbac.RequiredPermission("book:copies"))
+	r.PATCH("/books/:id/copies/:copyId", handler.Update, rbac.RequiredPermission("book:copies"))
+	r.DELETE("/books/:id/copies/:copyId", handler.Delete, rbac.RequiredPermission("book:copies"))
+}
+
+func (h *BookCopyHandler) List(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid book ID format")
+	}
+
+	ctx := c.Request().Context()
+
+	params := helper.GetRequestParams(c)
+
+	copies, total, err := h.copyUsecase.Fetch(ctx, id, params)
+
+	if err != nil {
+		log.Err(err).Msg("failed to fetch book copies")
+		return err
+	}
+
+	return c.JSON(http.StatusOK, helper.Paginate(c, copies, total, params))
+}
+
+func (h *BookCopyHandler) Store(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid book ID format")
+	}
+
+	req := new(domain.StoreBookCopyRequest)
+
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	req.BookID = id
+
+	ctx := c.Request().Context()
+
+	bookCopy, err := h.copyUsecase.Store(ctx, req)
+
+	if err != nil {
+		log.Err(err).Msg("failed to add book copy")
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, echo.Map{"message": "book copy added successfully", "data": bookCopy})
+}
+
+func (h *BookCopyHandler) Update(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid book ID format")
+	}
+
+	copyID, err := strconv.ParseInt(c.Param("copyId"), 10, 64)
+	if err != nil || copyID <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid copy ID format")
+	}
+
+	req := new(domain.UpdateBookCopyRequest)
+
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	req.ID = copyID
+	req.BookID = id
+
+	ctx := c.Request().Context()
+
+	bookCopy, err := h.copyUsecase.Update(ctx, req)
+
+	if err != nil {
+		log.Err(err).Msg("failed to update book copy")
+		return err
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "book copy updated successfully", "data": bookCopy})
+}
+
+func (h *BookCopyHandler) Delete(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid book ID format")
+	}
+
+	copyID, err := strconv.ParseInt(c.Param("copyId"), 10, 64)
+	if err != nil || copyID <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid copy ID format")
+	}
+
+	ctx := c.Request().Context()
+
+	if err := h.copyUsecase.Delete(ctx, id, copyID); err != nil {
+		log.Err(err).Msg("failed to delete book copy")
+		return err
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "book copy deleted successfully"})
+}
@@ -0,0 +1,139 @@
+package http
+
+import (
+	"backend-layout/internal/adapter/catalog"
+	"backend-layout/internal/domain"
+	"backend-layout/internal/httpcontext"
+	"backend-layout/internal/middleware"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+type BookExportHandler struct {
+	exportUsecase domain.BookExportUsecase
+}
+
+func NewBookExportHandler(r *echo.Group, eu domain.BookExportUsecase, rbac *middleware.RBACMiddleware) {
+	handler := &BookExportHandler{
+		exportUsecase: eu,
+	}
+
+	r.GET("/books/export", handler.Stream, rbac.RequiredPermission("book:export"))
+	r.POST("/books/exports", handler.Create, rbac.RequiredPermission("book:export"))
+	r.GET("/books/exports/:id", handler.Get, rbac.RequiredPermission("book:export"))
+	r.GET("/books/exports/:id/download", handler.Download, rbac.RequiredPermission("book:export"))
+}
+
+func (h *BookExportHandler) Stream(c echo.Context) error {
+	format := c.QueryParam("format")
+	if format == "" {
+		format = domain.ExportFormatCSV
+	}
+
+	minPrice, _ := strconv.ParseInt(c.QueryParam("min_price"), 10, 64)
+	maxPrice, _ := strconv.ParseInt(c.QueryParam("max_price"), 10, 64)
+
+	filter := domain.BookExportFilter{
+		Keyword:  c.QueryParam("q"),
+		MinPrice: minPrice,
+		MaxPrice: maxPrice,
+		SortBy:   c.QueryParam("sort_by"),
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, catalog.ExportContentType(format))
+	res.Header().Set(echo.HeaderContentDisposition, "attachment; filename=books"+catalog.ExportExtension(format))
+
+	err := h.exportUsecase.Stream(c.Request().Context(), format, filter, res)
+
+	if err != nil {
+		log.Err(err).Msg("failed to stream book export")
+
+		// once the first record is written the status is already sent, the client sees a truncated file
+		if res.Committed {
+			return nil
+		}
+
+		res.Header().Del(echo.HeaderContentDisposition)
+
+		return err
+	}
+
+	return nil
+}
+
+func (h *BookExportHandler) Create(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
+	req := new(domain.CreateBookExportRequest)
+
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	resp, err := h.exportUsecase.Create(ctx, user.ID, req.Format, req.BookExportFilter)
+
+	if err != nil {
+		log.Err(err).Msg("failed to create book export")
+		return err
+	}
+
+	return c.JSON(http.StatusAccepted, echo.Map{"message": "export queued", "data": resp})
+}
+
+func (h *BookExportHandler) Get(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid export ID format")
+	}
+
+	ctx := c.Request().Context()
+
+	resp, err := h.exportUsecase.Get(ctx, id)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *BookExportHandler) Download(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid export ID format")
+	}
+
+	ctx := c.Request().Context()
+
+	export, err := h.exportUsecase.Get(ctx, id)
+
+	if err != nil {
+		return err
+	}
+
+	file, err := h.exportUsecase.Download(ctx, id)
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=books-export-%d%s", id, catalog.ExportExtension(export.Format)))
+
+	return c.Stream(http.StatusOK, catalog.ExportContentType(export.Format), file)
+}
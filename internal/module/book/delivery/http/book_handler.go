@@ -3,6 +3,7 @@ package http
 import (
 	"backend-layout/helper"
 	"backend-layout/internal/domain"
+	"backend-layout/internal/httpcontext"
 	"backend-layout/internal/middleware"
 
 	"net/http"
@@ -28,9 +29,25 @@ func NewBookHandler(p *echo.Group, r *echo.Group, bu domain.BookUsecase, rbac *m
 	p.GET("/books/:id", handler.Get)
 	r.DELETE("/books/:id", handler.Delete, rbac.RequiredPermission("book:delete"))
 	r.PATCH("/books/:id", handler.Update, rbac.RequiredPermission("book:update"))
+	r.POST("/books/:id/image", handler.UploadImage, rbac.RequiredPermission("book:update"))
+	r.POST("/books/:id/restore", handler.Restore, rbac.RequiredPermission("book:restore"))
+	r.GET("/admin/books", handler.AdminList, rbac.RequiredPermission("book:restore"))
+	r.GET("/books/:id/revisions", handler.Revisions, rbac.RequiredPermission("book:update"))
+	r.POST("/books/:id/revisions/:revisionId/revert", handler.Revert, rbac.RequiredPermission("book:revert"))
 }
 
 func (h *BookHandler) List(c echo.Context) (err error) {
+	return h.list(c, false)
+}
+
+// AdminList is List for staff, deleted books are included with ?include_deleted=true
+func (h *BookHandler) AdminList(c echo.Context) (err error) {
+	includeDeleted, _ := strconv.ParseBool(c.QueryParam("include_deleted"))
+
+	return h.list(c, includeDeleted)
+}
+
+func (h *BookHandler) list(c echo.Context, includeDeleted bool) (err error) {
 	ctx := c.Request().Context()
 
 	params := helper.GetRequestParams(c)
@@ -38,8 +55,9 @@ func (h *BookHandler) List(c echo.Context) (err error) {
 	maxPrice, _ := strconv.ParseInt(c.QueryParam("max_price"), 10, 64)
 
 	params.Filters = map[string]interface{}{
-		"min_price": minPrice,
-		"max_price": maxPrice,
+		"min_price":       minPrice,
+		"max_price":       maxPrice,
+		"include_deleted": includeDeleted,
 	}
 
 	listBooks, total, err := h.bookUsecase.Fetch(ctx, params)
@@ -62,6 +80,11 @@ func (h *BookHandler) List(c echo.Context) (err error) {
 }
 
 func (h *BookHandler) Store(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
 	req := new(domain.StoreBookRequest)
 
 	correlationID, ok := c.Request().Context().Value(middleware.CorrelationIDKey).(string)
@@ -77,6 +100,8 @@ func (h *BookHandler) Store(c echo.Context) error {
 		return err
 	}
 
+	req.ActorID = user.ID
+
 	ctx := c.Request().Context()
 
 	id, err := h.bookUsecase.Store(ctx, req)
@@ -120,6 +145,10 @@ func (h *BookHandler) Get(c echo.Context) error {
 }
 
 func (h *BookHandler) Delete(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
 
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil || id <= 0 {
@@ -128,7 +157,7 @@ func (h *BookHandler) Delete(c echo.Context) error {
 
 	ctx := c.Request().Context()
 
-	err = h.bookUsecase.Delete(ctx, id)
+	err = h.bookUsecase.Delete(ctx, id, user.ID)
 
 	if err != nil {
 		log.Err(err).Msg("failed to delete book")
@@ -139,7 +168,35 @@ func (h *BookHandler) Delete(c echo.Context) error {
 
 }
 
+func (h *BookHandler) Restore(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid book ID format")
+	}
+
+	ctx := c.Request().Context()
+
+	err = h.bookUsecase.Restore(ctx, id, user.ID)
+
+	if err != nil {
+		log.Err(err).Msg("failed to restore book")
+		return err
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "book restored successfully"})
+}
+
 func (h *BookHandler) Update(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil || id <= 0 {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid book ID format")
@@ -156,6 +213,8 @@ func (h *BookHandler) Update(c echo.Context) error {
 		return err
 	}
 
+	req.ActorID = user.ID
+
 	ctx := c.Request().Context()
 
 	err = h.bookUsecase.Update(ctx, req)
@@ -167,3 +226,76 @@ func (h *BookHandler) Update(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, echo.Map{"message": "book updated successfully"})
 }
+
+func (h *BookHandler) UploadImage(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid book ID format")
+	}
+
+	file, err := c.FormFile("image")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "image file is required")
+	}
+
+	ctx := c.Request().Context()
+
+	imageUrl, err := h.bookUsecase.UploadImage(ctx, id, file)
+
+	if err != nil {
+		log.Err(err).Msg("failed to upload book image")
+		return err
+	}
+
+	return c.JSON(http.StatusAccepted, echo.Map{"message": "image uploaded, renditions are being processed", "data": echo.Map{
+		"image_url": imageUrl,
+	}})
+}
+
+func (h *BookHandler) Revisions(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid book ID format")
+	}
+
+	ctx := c.Request().Context()
+
+	params := helper.GetRequestParams(c)
+
+	revisions, total, err := h.bookUsecase.FetchRevisions(ctx, id, params)
+
+	if err != nil {
+		log.Err(err).Msg("failed to fetch book revisions")
+		return err
+	}
+
+	return c.JSON(http.StatusOK, helper.Paginate(c, revisions, total, params))
+}
+
+func (h *BookHandler) Revert(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid book ID format")
+	}
+
+	revisionID, err := strconv.ParseInt(c.Param("revisionId"), 10, 64)
+	if err != nil || revisionID <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid revision ID format")
+	}
+
+	ctx := c.Request().Context()
+
+	err = h.bookUsecase.RevertToRevision(ctx, id, revisionID, user.ID)
+
+	if err != nil {
+		log.Err(err).Msg("failed to revert book")
+		return err
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "book reverted successfully"})
+}
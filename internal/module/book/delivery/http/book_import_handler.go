@@ -0,0 +1,88 @@
+package http
+
+import (
+	"backend-layout/internal/domain"
+	"backend-layout/internal/httpcontext"
+	"backend-layout/internal/middleware"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+type BookImportHandler struct {
+	importUsecase domain.BookImportUsecase
+}
+
+func NewBookImportHandler(r *echo.Group, iu domain.BookImportUsecase, rbac *middleware.RBACMiddleware) {
+	handler := &BookImportHandler{
+		importUsecase: iu,
+	}
+
+	r.POST("/books/imports", handler.Create, rbac.RequiredPermission("book:import"))
+	r.GET("/books/imports/:id", handler.Get, rbac.RequiredPermission("book:import"))
+	r.GET("/books/imports/:id/report", handler.Report, rbac.RequiredPermission("book:import"))
+}
+
+func (h *BookImportHandler) Create(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "import file is required")
+	}
+
+	ctx := c.Request().Context()
+
+	resp, err := h.importUsecase.Create(ctx, user.ID, c.FormValue("format"), file)
+
+	if err != nil {
+		log.Err(err).Msg("failed to create book import")
+		return err
+	}
+
+	return c.JSON(http.StatusAccepted, echo.Map{"message": "import queued", "data": resp})
+}
+
+func (h *BookImportHandler) Get(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid import ID format")
+	}
+
+	ctx := c.Request().Context()
+
+	resp, err := h.importUsecase.Get(ctx, id)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *BookImportHandler) Report(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid import ID format")
+	}
+
+	ctx := c.Request().Context()
+
+	report, err := h.importUsecase.Report(ctx, id)
+
+	if err != nil {
+		return err
+	}
+
+	defer report.Close()
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=import-%d-report.csv", id))
+
+	return c.Stream(http.StatusOK, "text/csv", report)
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"backend-layout/internal/domain"
+	"backend-layout/internal/middleware"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+type BookMetadataHandler struct {
+	metadataUsecase domain.BookMetadataUsecase
+}
+
+func NewBookMetadataHandler(r *echo.Group, mu domain.BookMetadataUsecase, rbac *middleware.RBACMiddleware) {
+	handler := &BookMetadataHandler{
+		metadataUsecase: mu,
+	}
+
+	r.GET("/books/lookup", handler.Lookup, rbac.RequiredPermission("book:create"))
+	r.POST("/books/metadata/enrich", handler.Enrich, rbac.RequiredPermission("book:update"))
+}
+
+func (h *BookMetadataHandler) Lookup(c echo.Context) error {
+	req := new(domain.LookupBookRequest)
+
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	resp, err := h.metadataUsecase.Lookup(ctx, req.Isbn)
+
+	if err != nil {
+		log.Err(err).Msg("failed to lookup isbn")
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *BookMetadataHandler) Enrich(c echo.Context) error {
+	req := new(domain.EnrichBookMetadataRequest)
+
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	if err := h.metadataUsecase.RequestEnrichment(ctx, req.BookIDs); err != nil {
+		log.Err(err).Msg("failed to request metadata enrichment")
+		return err
+	}
+
+	return c.JSON(http.StatusAccepted, echo.Map{"message": "metadata enrichment queued"})
+}
@@ -6,6 +6,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -34,11 +35,11 @@ func (p *postgresBookRepository) GetTx(ctx context.Context) (pgx.Tx, error) {
 	return tx, nil
 }
 
-// Delete implements domain.BookRepository.
-func (p *postgresBookRepository) Delete(ctx context.Context, id int64) error {
-	query := "DELETE FROM books WHERE id = $1;"
+// Delete implements domain.BookRepository. Books are only marked as deleted, lending history keeps pointing at them.
+func (p *postgresBookRepository) Delete(ctx context.Context, tx pgx.Tx, id int64) error {
+	query := "UPDATE books SET deleted_at = now(), updated_at = now() WHERE id = $1 AND deleted_at IS NULL;"
 
-	row, err := p.conn.Exec(ctx, query, id)
+	row, err := tx.Exec(ctx, query, id)
 
 	if err != nil {
 		return err
@@ -53,6 +54,99 @@ func (p *postgresBookRepository) Delete(ctx context.Context, id int64) error {
 	return nil
 }
 
+// Restore implements domain.BookRepository.
+func (p *postgresBookRepository) Restore(ctx context.Context, tx pgx.Tx, id int64) error {
+	query := "UPDATE books SET deleted_at = NULL, updated_at = now() WHERE id = $1 AND deleted_at IS NOT NULL;"
+
+	row, err := tx.Exec(ctx, query, id)
+
+	if err != nil {
+		return err
+	}
+
+	if row.RowsAffected() == 0 {
+		return ErrBookNotFound
+	}
+
+	return nil
+}
+
+// PurgeDeleted implements domain.BookRepository. Only books that were never lent out are removed, their revisions
+// are kept for the audit trail with book_id set to NULL.
+func (p *postgresBookRepository) PurgeDeleted(ctx context.Context, tx pgx.Tx, deletedBefore time.Time) ([]int64, error) {
+	query := `SELECT b.id
+			  FROM books b
+			  WHERE b.deleted_at IS NOT NULL AND b.deleted_at < $1
+			  AND NOT EXISTS (SELECT 1 FROM order_details od WHERE od.book_id = b.id)
+			  ORDER BY b.id
+			  FOR UPDATE;`
+
+	rows, err := tx.Query(ctx, query, deletedBefore)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, 0)
+
+	for rows.Next() {
+		var id int64
+
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return ids, nil
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM book_category WHERE book_id = ANY($1);`, ids); err != nil {
+		return nil, fmt.Errorf("failed to delete book categories: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM books WHERE id = ANY($1);`, ids); err != nil {
+		return nil, fmt.Errorf("failed to delete books: %w", err)
+	}
+
+	return ids, nil
+}
+
+// GetForUpdate implements domain.BookRepository. The row is locked until tx ends, deleted books are returned too.
+func (p *postgresBookRepository) GetForUpdate(ctx context.Context, tx pgx.Tx, id int64) (*domain.Book, error) {
+	query := `SELECT id, title, slug, author_id, publisher_id, publish_year, total_page, COALESCE(description, ''),
+				sku, isbn, price,
+				ARRAY(SELECT category_id FROM book_category WHERE book_id = books.id ORDER BY category_id),
+				created_at, updated_at, deleted_at
+			  FROM books
+			  WHERE id = $1
+			  FOR UPDATE;`
+
+	var b domain.Book
+
+	err := tx.QueryRow(ctx, query, id).Scan(&b.Id, &b.Title, &b.Slug, &b.Author.Id, &b.Publisher.Id, &b.PublishYear, &b.TotalPage,
+		&b.Description, &b.Sku, &b.Isbn, &b.Price, &b.CategoryID, &b.CreatedAt, &b.UpdatedAt, &b.DeletedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrBookNotFound
+		}
+
+		return nil, err
+	}
+
+	return &b, nil
+}
+
 // Update implements domain.BookRepository.
 func (p *postgresBookRepository) Update(ctx context.Context, tx pgx.Tx, book *domain.Book) error {
 	query := `UPDATE books SET
@@ -66,7 +160,7 @@ func (p *postgresBookRepository) Update(ctx context.Context, tx pgx.Tx, book *do
 				isbn = $8,
 				price = $9,
 				updated_at = now()
-	          WHERE id = $10;`
+	          WHERE id = $10 AND deleted_at IS NULL;`
 
 	row, err := tx.Exec(ctx, query,
 		book.Title, book.Slug, book.Author.Id, book.Publisher.Id, book.PublishYear, book.TotalPage, book.Description, book.Isbn, book.Price, book.Id)
@@ -108,27 +202,34 @@ func (p *postgresBookRepository) GetByID(ctx context.Context, id int64) (*domain
 				publishers.name as publisher_name,
 				books.publish_year,
 				books.total_page,
-				books.description,
+				COALESCE(books.description, '') as description,
 				books.sku,
 				books.isbn,
 				books.price,
 				STRING_AGG(c.name, ',') as category_name,
+				COALESCE(books.image_url, '') as image_url,
+				COALESCE(books.thumbnail_url, '') as thumbnail_url,
 				books.created_at,
-				books.updated_at
+				books.updated_at,
+				books.deleted_at,
+				books.average_rating,
+				books.rating_count
 			FROM books
 			JOIN authors ON authors.id = books.author_id
             JOIN publishers ON publishers.id = books.publisher_id
 			JOIN book_category bc ON books.id = bc.book_id
 			JOIN categories c ON bc.category_id = c.id
-			WHERE books.id=$1
+			WHERE books.id=$1 AND books.deleted_at IS NULL
 			GROUP BY books.id, books.title, books.slug, books.author_id, 
 			authors.name, books.publisher_id, publishers.name, 
 			books.publish_year, books.total_page, books.description, 
-			books.sku, books.isbn, books.price, books.created_at, books.updated_at;`
+			books.sku, books.isbn, books.price, books.image_url, books.thumbnail_url,
+			books.created_at, books.updated_at, books.deleted_at,
+			books.average_rating, books.rating_count;`
 
 	var b domain.Book
 
-	err := p.conn.QueryRow(ctx, query, id).Scan(&b.Id, &b.Title, &b.Slug, &b.Author.Id, &b.Author.Name, &b.Publisher.Id, &b.Publisher.Name, &b.PublishYear, &b.TotalPage, &b.Description, &b.Sku, &b.Isbn, &b.Price, &b.CategoryName, &b.CreatedAt, &b.UpdatedAt)
+	err := p.conn.QueryRow(ctx, query, id).Scan(&b.Id, &b.Title, &b.Slug, &b.Author.Id, &b.Author.Name, &b.Publisher.Id, &b.Publisher.Name, &b.PublishYear, &b.TotalPage, &b.Description, &b.Sku, &b.Isbn, &b.Price, &b.CategoryName, &b.ImageUrl, &b.ThumbnailUrl, &b.CreatedAt, &b.UpdatedAt, &b.DeletedAt, &b.AverageRating, &b.RatingCount)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -142,7 +243,7 @@ func (p *postgresBookRepository) GetByID(ctx context.Context, id int64) (*domain
 }
 
 // Store implements domain.BookRepository.
-func (p *postgresBookRepository) Store(ctx context.Context, book *domain.Book) (id int64, err error) {
+func (p *postgresBookRepository) Store(ctx context.Context, tx pgx.Tx, book *domain.Book) (id int64, err error) {
 	query := `
 	  			INSERT INTO books (
 					title, slug, author_id, publisher_id, publish_year, total_page, description, sku, isbn, price
@@ -150,7 +251,7 @@ func (p *postgresBookRepository) Store(ctx context.Context, book *domain.Book) (
 				  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
 				) RETURNING id;`
 
-	err = p.conn.QueryRow(ctx, query, book.Title, book.Slug, book.Author.Id, book.Publisher.Id, book.PublishYear, book.TotalPage, book.Description, book.Sku, book.Isbn, book.Price).Scan(&id)
+	err = tx.QueryRow(ctx, query, book.Title, book.Slug, book.Author.Id, book.Publisher.Id, book.PublishYear, book.TotalPage, book.Description, book.Sku, book.Isbn, book.Price).Scan(&id)
 
 	if err != nil {
 
@@ -172,7 +273,7 @@ func (p *postgresBookRepository) Store(ctx context.Context, book *domain.Book) (
 		return 0, fmt.Errorf("failed to insert book: %w", err)
 	}
 
-	_, err = p.conn.CopyFrom(ctx, pgx.Identifier{"book_category"}, []string{"category_id", "book_id"}, pgx.CopyFromSlice(len(book.CategoryID), func(i int) ([]any, error) {
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"book_category"}, []string{"category_id", "book_id"}, pgx.CopyFromSlice(len(book.CategoryID), func(i int) ([]any, error) {
 		return []any{book.CategoryID[i], id}, nil
 	}))
 
@@ -225,8 +326,13 @@ func (p *postgresBookRepository) Fetch(ctx context.Context, params domain.Reques
 			&t.Isbn,
 			&t.Price,
 			&t.CategoryName,
+			&t.ImageUrl,
+			&t.ThumbnailUrl,
 			&t.CreatedAt,
 			&t.UpdatedAt,
+			&t.DeletedAt,
+			&t.AverageRating,
+			&t.RatingCount,
 		)
 
 		if err != nil {
@@ -239,6 +345,135 @@ func (p *postgresBookRepository) Fetch(ctx context.Context, params domain.Reques
 	return result, total, nil
 }
 
+// UpdateImage implements domain.BookRepository.
+func (p *postgresBookRepository) UpdateImage(ctx context.Context, tx pgx.Tx, id int64, imageUrl string) error {
+	query := `UPDATE books SET image_url = $1, updated_at = now() WHERE id = $2 AND deleted_at IS NULL;`
+
+	row, err := tx.Exec(ctx, query, imageUrl, id)
+
+	if err != nil {
+		return err
+	}
+
+	if row.RowsAffected() == 0 {
+		return ErrBookNotFound
+	}
+
+	return nil
+}
+
+// SaveImageRenditions implements domain.BookRepository.
+func (p *postgresBookRepository) SaveImageRenditions(ctx context.Context, tx pgx.Tx, book *domain.Book, renditions []domain.BookImageRendition) error {
+	query := `UPDATE books SET image_url = $1, thumbnail_url = $2, updated_at = now() WHERE id = $3;`
+
+	row, err := tx.Exec(ctx, query, book.ImageUrl, book.ThumbnailUrl, book.Id)
+
+	if err != nil {
+		return err
+	}
+
+	if row.RowsAffected() == 0 {
+		return ErrBookNotFound
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM book_image_renditions WHERE book_id = $1`, book.Id); err != nil {
+		return fmt.Errorf("failed to delete existing renditions: %w", err)
+	}
+
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"book_image_renditions"}, []string{"book_id", "variant", "format", "width", "height", "url"}, pgx.CopyFromSlice(len(renditions), func(i int) ([]any, error) {
+		r := renditions[i]
+		return []any{book.Id, r.Variant, r.Format, r.Width, r.Height, r.Url}, nil
+	}))
+
+	if err != nil {
+		return fmt.Errorf("failed to insert renditions: %w", err)
+	}
+
+	return nil
+}
+
+// GetImageRenditions implements domain.BookRepository.
+func (p *postgresBookRepository) GetImageRenditions(ctx context.Context, bookID int64) ([]domain.BookImageRendition, error) {
+	query := `SELECT id, book_id, variant, format, width, height, url, created_at
+			  FROM book_image_renditions
+			  WHERE book_id = $1
+			  ORDER BY width, format;`
+
+	rows, err := p.conn.Query(ctx, query, bookID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := make([]domain.BookImageRendition, 0)
+
+	for rows.Next() {
+		r := domain.BookImageRendition{}
+
+		err = rows.Scan(&r.Id, &r.BookID, &r.Variant, &r.Format, &r.Width, &r.Height, &r.Url, &r.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, r)
+	}
+
+	return result, rows.Err()
+}
+
+// FetchMissingMetadata implements domain.BookRepository.
+func (p *postgresBookRepository) FetchMissingMetadata(ctx context.Context, afterID int64, limit int) ([]domain.Book, error) {
+	query := `SELECT id, title, isbn, COALESCE(description, ''), total_page
+			  FROM books
+			  WHERE (COALESCE(description, '') = '' OR total_page <= 0) AND deleted_at IS NULL AND id > $1
+			  ORDER BY id
+			  LIMIT $2;`
+
+	rows, err := p.conn.Query(ctx, query, afterID, limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := make([]domain.Book, 0)
+
+	for rows.Next() {
+		b := domain.Book{}
+
+		if err := rows.Scan(&b.Id, &b.Title, &b.Isbn, &b.Description, &b.TotalPage); err != nil {
+			return nil, err
+		}
+
+		result = append(result, b)
+	}
+
+	return result, rows.Err()
+}
+
+// FillMetadata implements domain.BookRepository. Only empty values are filled, so data typed in by staff always wins.
+func (p *postgresBookRepository) FillMetadata(ctx context.Context, tx pgx.Tx, id int64, description string, totalPage int) (bool, error) {
+	query := `UPDATE books SET
+				description = CASE WHEN COALESCE(description, '') = '' AND $1 <> '' THEN $1 ELSE description END,
+				total_page = CASE WHEN total_page <= 0 AND $2 > 0 THEN $2 ELSE total_page END,
+				updated_at = now()
+			  WHERE id = $3 AND deleted_at IS NULL AND (
+				(COALESCE(description, '') = '' AND $1 <> '') OR (total_page <= 0 AND $2 > 0)
+			  );`
+
+	row, err := tx.Exec(ctx, query, description, totalPage, id)
+
+	if err != nil {
+		return false, err
+	}
+
+	return row.RowsAffected() > 0, nil
+}
+
 func (p *postgresBookRepository) count(ctx context.Context, params domain.RequestQueryParams) (total int64, err error) {
 	query, args := buildCountBookQuery(params)
 
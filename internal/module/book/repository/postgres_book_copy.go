@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"backend-layout/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrBookCopyNotFound         = errors.New("book copy not found")
+	ErrBookCopyBarcodeDuplicate = errors.New("duplicate entry: barcode already exists")
+	ErrBookCopyInUse            = errors.New("book copy is referenced by an order")
+)
+
+type postgresBookCopyRepository struct {
+	conn *pgxpool.Pool
+}
+
+const bookCopyColumns = `id, book_id, barcode, condition, status, location, created_at, updated_at`
+
+func scanBookCopy(row pgx.Row) (*domain.BookCopy, error) {
+	var c domain.BookCopy
+
+	err := row.Scan(&c.Id, &c.BookID, &c.Barcode, &c.Condition, &c.Status, &c.Location, &c.CreatedAt, &c.UpdatedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrBookCopyNotFound
+		}
+
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// Store implements domain.BookCopyRepository.
+func (p *postgresBookCopyRepository) Store(ctx context.Context, tx pgx.Tx, bookCopy *domain.BookCopy) (id int64, err error) {
+	query := `INSERT INTO book_copies (book_id, barcode, condition, status, location)
+			  VALUES ($1, $2, $3, $4, $5)
+			  RETURNING id;`
+
+	err = tx.QueryRow(ctx, query, bookCopy.BookID, bookCopy.Barcode, bookCopy.Condition, bookCopy.Status, bookCopy.Location).Scan(&id)
+
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
+			return 0, ErrBookCopyBarcodeDuplicate
+		}
+
+		return 0, fmt.Errorf("failed to insert book copy: %w", err)
+	}
+
+	return
+}
+
+// GetForUpdate implements domain.BookCopyRepository.
+func (p *postgresBookCopyRepository) GetForUpdate(ctx context.Context, tx pgx.Tx, bookID, id int64) (*domain.BookCopy, error) {
+	query := `SELECT ` + bookCopyColumns + `
+			  FROM book_copies
+			  WHERE id = $1 AND book_id = $2
+			  FOR UPDATE;`
+
+	return scanBookCopy(tx.QueryRow(ctx, query, id, bookID))
+}
+
+// GetByBarcodeForUpdate implements domain.BookCopyRepository.
+func (p *postgresBookCopyRepository) GetByBarcodeForUpdate(ctx context.Context, tx pgx.Tx, barcode string) (*domain.BookCopy, error) {
+	query := `SELECT ` + bookCopyColumns + `
+			  FROM book_copies
+			  WHERE barcode = $1
+			  FOR UPDATE;`
+
+	return scanBookCopy(tx.QueryRow(ctx, query, barcode))
+}
+
+// FetchByBookID implements domain.BookCopyRepository.
+func (p *postgresBookCopyRepository) FetchByBookID(ctx context.Context, bookID int64, params domain.RequestQueryParams) ([]domain.BookCopy, int64, error) {
+	var total int64
+
+	err := p.conn.QueryRow(ctx, `SELECT COUNT(1) FROM book_copies WHERE book_id = $1;`, bookID).Scan(&total)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + bookCopyColumns + `
+			  FROM book_copies
+			  WHERE book_id = $1
+			  ORDER BY id
+			  LIMIT $2 OFFSET $3;`
+
+	rows, err := p.conn.Query(ctx, query, bookID, params.PerPage, (params.Page-1)*params.PerPage)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer rows.Close()
+
+	result := make([]domain.BookCopy, 0)
+
+	for rows.Next() {
+		c, err := scanBookCopy(rows)
+
+		if err != nil {
+			return nil, 0, err
+		}
+
+		result = append(result, *c)
+	}
+
+	return result, total, rows.Err()
+}
+
+// Update implements domain.BookCopyRepository.
+func (p *postgresBookCopyRepository) Update(ctx context.Context, tx pgx.Tx, bookCopy *domain.BookCopy) error {
+	query := `UPDATE book_copies
+			  SET barcode = $1, condition = $2, status = $3, location = $4, updated_at = now()
+			  WHERE id = $5;`
+
+	row, err := tx.Exec(ctx, query, bookCopy.Barcode, bookCopy.Condition, bookCopy.Status, bookCopy.Location, bookCopy.Id)
+
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
+			return ErrBookCopyBarcodeDuplicate
+		}
+
+		return err
+	}
+
+	if row.RowsAffected() == 0 {
+		return ErrBookCopyNotFound
+	}
+
+	return nil
+}
+
+// SetStatus implements domain.BookCopyRepository.
+func (p *postgresBookCopyRepository) SetStatus(ctx context.Context, tx pgx.Tx, id int64, status string) error {
+	row, err := tx.Exec(ctx, `UPDATE book_copies SET status = $1, updated_at = now() WHERE id = $2;`, status, id)
+
+	if err != nil {
+		return err
+	}
+
+	if row.RowsAffected() == 0 {
+		return ErrBookCopyNotFound
+	}
+
+	return nil
+}
+
+// Delete implements domain.BookCopyRepository. Copies that were ever handed out are kept for the order history.
+func (p *postgresBookCopyRepository) Delete(ctx context.Context, tx pgx.Tx, id int64) error {
+	row, err := tx.Exec(ctx, `DELETE FROM book_copies WHERE id = $1;`, id)
+
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23503" {
+			return ErrBookCopyInUse
+		}
+
+		return err
+	}
+
+	if row.RowsAffected() == 0 {
+		return ErrBookCopyNotFound
+	}
+
+	return nil
+}
+
+// LockBooks implements domain.BookCopyRepository.
+func (p *postgresBookCopyRepository) LockBooks(ctx context.Context, tx pgx.Tx, bookIDs []int64) error {
+	rows, err := tx.Query(ctx, `SELECT id FROM books WHERE id = ANY($1) ORDER BY id FOR UPDATE;`, bookIDs)
+
+	if err != nil {
+		return fmt.Errorf("failed to lock books: %w", err)
+	}
+
+	rows.Close()
+
+	return rows.Err()
+}
+
+// RefreshStock implements domain.BookCopyRepository. in_stock is the copies on the shelf minus the order items
+// that are still waiting for a copy and the holds queued for one, total_stock every copy that is not lost.
+func (p *postgresBookCopyRepository) RefreshStock(ctx context.Context, tx pgx.Tx, bookID int64) (domain.BookStock, error) {
+	var s domain.BookStock
+
+	err := tx.QueryRow(ctx, `SELECT in_stock FROM books WHERE id = $1 FOR UPDATE;`, bookID).Scan(&s.Before)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return s, ErrBookNotFound
+		}
+
+		return s, err
+	}
+
+	query := `SELECT
+				(SELECT COUNT(1) FROM book_copies WHERE book_id = $1 AND status = 'available'),
+				(SELECT COUNT(1) FROM book_copies WHERE book_id = $1 AND status <> 'lost'),
+				(SELECT COUNT(1)
+				 FROM order_details od
+				 JOIN orders o ON o.id = od.order_id
+				 WHERE od.book_id = $1 AND od.book_copy_id IS NULL AND o.status IN ('pending_payment', 'paid', 'ready_for_pickup')),
+				(SELECT COUNT(1) FROM book_holds WHERE book_id = $1 AND status = 'waiting');`
+
+	if err := tx.QueryRow(ctx, query, bookID).Scan(&s.Available, &s.TotalStock, &s.Reserved, &s.Waiting); err != nil {
+		return s, fmt.Errorf("failed to count book copies: %w", err)
+	}
+
+	s.After = max(s.Available-s.Reserved-s.Waiting, 0)
+
+	_, err = tx.Exec(ctx, `UPDATE books SET in_stock = $1, total_stock = $2, updated_at = now() WHERE id = $3;`, s.After, s.TotalStock, bookID)
+
+	if err != nil {
+		return s, fmt.Errorf("failed to update book stock: %w", err)
+	}
+
+	return s, nil
+}
+
+func NewPostgresBookCopyRepository(conn *pgxpool.Pool) domain.BookCopyRepository {
+	return &postgresBookCopyRepository{
+		conn: conn,
+	}
+}
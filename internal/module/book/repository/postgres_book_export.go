@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"backend-layout/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrBookExportNotFound = errors.New("book export not found")
+)
+
+type postgresBookExportRepository struct {
+	conn *pgxpool.Pool
+}
+
+// Store implements domain.BookExportRepository.
+func (p *postgresBookExportRepository) Store(ctx context.Context, be *domain.BookExport) (id int64, err error) {
+	query := `INSERT INTO book_exports (user_id, format, status, filter)
+			  VALUES ($1, $2, $3, $4)
+			  RETURNING id;`
+
+	err = p.conn.QueryRow(ctx, query, be.UserID, be.Format, be.Status, be.Filter).Scan(&id)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert book export: %w", err)
+	}
+
+	return
+}
+
+// GetByID implements domain.BookExportRepository.
+func (p *postgresBookExportRepository) GetByID(ctx context.Context, id int64) (*domain.BookExport, error) {
+	query := `SELECT id, user_id, format, status, filter, file_key, total_rows, error_message, created_at, updated_at
+			  FROM book_exports
+			  WHERE id = $1;`
+
+	var be domain.BookExport
+
+	err := p.conn.QueryRow(ctx, query, id).Scan(&be.Id, &be.UserID, &be.Format, &be.Status, &be.Filter,
+		&be.FileKey, &be.TotalRows, &be.ErrorMessage, &be.CreatedAt, &be.UpdatedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrBookExportNotFound
+		}
+
+		return nil, err
+	}
+
+	return &be, nil
+}
+
+// SetStatus implements domain.BookExportRepository.
+func (p *postgresBookExportRepository) SetStatus(ctx context.Context, id int64, status string, errorMessage *string) error {
+	query := `UPDATE book_exports SET status = $1, error_message = $2, updated_at = now() WHERE id = $3;`
+
+	return p.exec(ctx, query, status, errorMessage, id)
+}
+
+// Complete implements domain.BookExportRepository.
+func (p *postgresBookExportRepository) Complete(ctx context.Context, id int64, fileKey string, totalRows int64) error {
+	query := `UPDATE book_exports SET status = $1, file_key = $2, total_rows = $3, updated_at = now() WHERE id = $4;`
+
+	return p.exec(ctx, query, domain.ExportStatusCompleted, fileKey, totalRows, id)
+}
+
+func (p *postgresBookExportRepository) exec(ctx context.Context, query string, args ...any) error {
+	row, err := p.conn.Exec(ctx, query, args...)
+
+	if err != nil {
+		return err
+	}
+
+	if row.RowsAffected() == 0 {
+		return ErrBookExportNotFound
+	}
+
+	return nil
+}
+
+func NewPostgresBookExportRepository(conn *pgxpool.Pool) domain.BookExportRepository {
+	return &postgresBookExportRepository{
+		conn: conn,
+	}
+}
@@ -17,14 +17,19 @@ const (
         publishers.name as publisher_name,
         books.publish_year,
         books.total_page,
-        books.description,
+        COALESCE(books.description, '') as description,
         books.sku,
 		books.in_stock,
         books.isbn,
         books.price,
 		STRING_AGG(c.name, ',') as category_name,
+		COALESCE(books.image_url, '') as image_url,
+		COALESCE(books.thumbnail_url, '') as thumbnail_url,
         books.created_at,
-        books.updated_at
+        books.updated_at,
+		books.deleted_at,
+		books.average_rating,
+		books.rating_count
     `
 
 	baseQuery = `
@@ -34,11 +39,13 @@ const (
         JOIN publishers ON publishers.id = books.publisher_id
 		JOIN book_category bc ON books.id = bc.book_id
 		JOIN categories c ON bc.category_id = c.id
-        WHERE 1=1
+        WHERE 1=1 %s
 		GROUP BY books.id, books.title, books.slug, books.author_id, 
 		authors.name, books.publisher_id, publishers.name, 
 		books.publish_year, books.total_page, books.description, 
-		books.sku, books.isbn, books.price, books.created_at, books.updated_at
+		books.sku, books.isbn, books.price, books.image_url, books.thumbnail_url,
+		books.created_at, books.updated_at, books.deleted_at,
+		books.average_rating, books.rating_count
     `
 
 	countQuery = `
@@ -51,42 +58,15 @@ const (
 )
 
 func buildBookQuery(params domain.RequestQueryParams) (string, []interface{}) {
-	var (
-		query      = fmt.Sprintf(baseQuery, bookColumns)
-		conditions = make([]string, 0)
-		args       = make([]interface{}, 0)
-		argCounter = 1
-	)
-
-	// Handle price filters
-	if v, ok := params.Filters["min_price"]; ok && v.(int64) > 0 {
-		conditions = append(conditions, fmt.Sprintf("books.price >= $%d", argCounter))
-		args = append(args, v.(int64))
-		argCounter++
-	}
-
-	if v, ok := params.Filters["max_price"]; ok && v.(int64) > 0 {
-		conditions = append(conditions, fmt.Sprintf("books.price <= $%d", argCounter))
-		args = append(args, v.(int64))
-		argCounter++
-	}
+	conditions, args := buildBookConditions(params)
 
-	// Handle search
-	if params.Keyword != "" {
-		conditions = append(conditions, fmt.Sprintf("books.title ILIKE $%d", argCounter))
-		args = append(args, "%"+escapeSQLLike(params.Keyword)+"%")
-		argCounter++
-	}
-
-	// Append conditions jika ada
-	if len(conditions) > 0 {
-		query += " AND " + strings.Join(conditions, " AND ")
-	}
+	query := fmt.Sprintf(baseQuery, bookColumns, conditions)
 
 	// Handle sorting
 	query += buildOrderBy(params.SortBy)
 
 	// Handle pagination
+	argCounter := len(args) + 1
 	offset := (params.Page - 1) * params.PerPage
 	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argCounter, argCounter+1)
 	args = append(args, params.PerPage, offset)
@@ -94,25 +74,41 @@ func buildBookQuery(params domain.RequestQueryParams) (string, []interface{}) {
 	return query, args
 }
 
+// buildOrderBy always ends with books.id so paging through the whole catalog never skips or repeats a book
 func buildOrderBy(sortBy string) string {
 	switch sortBy {
 	case "highest_price":
-		return " ORDER BY books.price DESC"
+		return " ORDER BY books.price DESC, books.id DESC"
 	case "lowest_price":
-		return " ORDER BY books.price ASC"
+		return " ORDER BY books.price ASC, books.id ASC"
+	case "highest_rating":
+		return " ORDER BY books.average_rating DESC, books.rating_count DESC, books.id DESC"
+	case "most_rated":
+		return " ORDER BY books.rating_count DESC, books.average_rating DESC, books.id DESC"
 	default:
-		return " ORDER BY books.created_at DESC"
+		return " ORDER BY books.created_at DESC, books.id DESC"
 	}
 }
 
 func buildCountBookQuery(params domain.RequestQueryParams) (string, []interface{}) {
+	conditions, args := buildBookConditions(params)
+
+	return countQuery + conditions, args
+}
+
+// buildBookConditions returns the filter conditions shared by the list and count queries
+func buildBookConditions(params domain.RequestQueryParams) (string, []interface{}) {
 	var (
-		query      = countQuery
 		conditions = make([]string, 0)
 		args       = make([]interface{}, 0)
 		argCounter = 1
 	)
 
+	// Deleted books are only listed when explicitly asked for
+	if v, ok := params.Filters["include_deleted"]; !ok || !v.(bool) {
+		conditions = append(conditions, "books.deleted_at IS NULL")
+	}
+
 	// Handle price filters
 	if v, ok := params.Filters["min_price"]; ok && v.(int64) > 0 {
 		conditions = append(conditions, fmt.Sprintf("books.price >= $%d", argCounter))
@@ -134,11 +130,11 @@ func buildCountBookQuery(params domain.RequestQueryParams) (string, []interface{
 	}
 
 	// Append conditions jika ada
-	if len(conditions) > 0 {
-		query += " AND " + strings.Join(conditions, " AND ")
+	if len(conditions) == 0 {
+		return "", args
 	}
 
-	return query, args
+	return " AND " + strings.Join(conditions, " AND "), args
 }
 
 func escapeSQLLike(input string) string {
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"backend-layout/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrBookImportNotFound = errors.New("book import not found")
+)
+
+type postgresBookImportRepository struct {
+	conn *pgxpool.Pool
+}
+
+// Store implements domain.BookImportRepository.
+func (p *postgresBookImportRepository) Store(ctx context.Context, bi *domain.BookImport) (id int64, err error) {
+	query := `INSERT INTO book_imports (user_id, filename, format, status, source_key)
+			  VALUES ($1, $2, $3, $4, $5)
+			  RETURNING id;`
+
+	err = p.conn.QueryRow(ctx, query, bi.UserID, bi.Filename, bi.Format, bi.Status, bi.SourceKey).Scan(&id)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert book import: %w", err)
+	}
+
+	return
+}
+
+// GetByID implements domain.BookImportRepository.
+func (p *postgresBookImportRepository) GetByID(ctx context.Context, id int64) (*domain.BookImport, error) {
+	query := `SELECT id, user_id, filename, format, status, source_key, total_rows, processed_rows,
+				success_rows, failed_rows, report_key, error_message, created_at, updated_at
+			  FROM book_imports
+			  WHERE id = $1;`
+
+	var bi domain.BookImport
+
+	err := p.conn.QueryRow(ctx, query, id).Scan(&bi.Id, &bi.UserID, &bi.Filename, &bi.Format, &bi.Status, &bi.SourceKey,
+		&bi.TotalRows, &bi.ProcessedRows, &bi.SuccessRows, &bi.FailedRows, &bi.ReportKey, &bi.ErrorMessage, &bi.CreatedAt, &bi.UpdatedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrBookImportNotFound
+		}
+
+		return nil, err
+	}
+
+	return &bi, nil
+}
+
+// Claim implements domain.BookImportRepository.
+func (p *postgresBookImportRepository) Claim(ctx context.Context, id int64) (bool, error) {
+	query := `UPDATE book_imports SET status = $1, updated_at = now() WHERE id = $2 AND status = $3;`
+
+	row, err := p.conn.Exec(ctx, query, domain.ImportStatusProcessing, id, domain.ImportStatusPending)
+
+	if err != nil {
+		return false, err
+	}
+
+	return row.RowsAffected() > 0, nil
+}
+
+// SetStatus implements domain.BookImportRepository.
+func (p *postgresBookImportRepository) SetStatus(ctx context.Context, id int64, status string, errorMessage *string) error {
+	query := `UPDATE book_imports SET status = $1, error_message = $2, updated_at = now() WHERE id = $3;`
+
+	return p.exec(ctx, query, status, errorMessage, id)
+}
+
+// SetTotalRows implements domain.BookImportRepository.
+func (p *postgresBookImportRepository) SetTotalRows(ctx context.Context, id int64, total int64) error {
+	query := `UPDATE book_imports SET total_rows = $1, updated_at = now() WHERE id = $2;`
+
+	return p.exec(ctx, query, total, id)
+}
+
+// UpdateProgress implements domain.BookImportRepository.
+func (p *postgresBookImportRepository) UpdateProgress(ctx context.Context, id int64, processed, success, failed int64) error {
+	query := `UPDATE book_imports
+			  SET processed_rows = $1, success_rows = $2, failed_rows = $3, updated_at = now()
+			  WHERE id = $4;`
+
+	return p.exec(ctx, query, processed, success, failed, id)
+}
+
+// Complete implements domain.BookImportRepository.
+func (p *postgresBookImportRepository) Complete(ctx context.Context, id int64, reportKey *string) error {
+	query := `UPDATE book_imports SET status = $1, report_key = $2, updated_at = now() WHERE id = $3;`
+
+	return p.exec(ctx, query, domain.ImportStatusCompleted, reportKey, id)
+}
+
+// FindOrCreateAuthor implements domain.BookImportRepository.
+func (p *postgresBookImportRepository) FindOrCreateAuthor(ctx context.Context, tx pgx.Tx, name string) (int64, error) {
+	return findOrCreateByName(ctx, tx, "authors", name)
+}
+
+// FindOrCreatePublisher implements domain.BookImportRepository.
+func (p *postgresBookImportRepository) FindOrCreatePublisher(ctx context.Context, tx pgx.Tx, name string) (int64, error) {
+	return findOrCreateByName(ctx, tx, "publishers", name)
+}
+
+// GetCategoryIDsByNames implements domain.BookImportRepository.
+func (p *postgresBookImportRepository) GetCategoryIDsByNames(ctx context.Context, tx pgx.Tx, names []string) (map[string]int64, error) {
+	lowered := make([]string, len(names))
+	for i, n := range names {
+		lowered[i] = strings.ToLower(n)
+	}
+
+	rows, err := tx.Query(ctx, `SELECT id, LOWER(name) FROM categories WHERE LOWER(name) = ANY($1);`, lowered)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := make(map[string]int64, len(names))
+
+	for rows.Next() {
+		var (
+			id   int64
+			name string
+		)
+
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+
+		result[name] = id
+	}
+
+	return result, rows.Err()
+}
+
+// findOrCreateByName upserts into authors or publishers, which have no unique constraint on name.
+// An advisory lock keyed on the name keeps two concurrent imports from inserting the same row twice.
+func findOrCreateByName(ctx context.Context, tx pgx.Tx, table, name string) (id int64, err error) {
+	if _, err = tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1));`, table+":"+strings.ToLower(name)); err != nil {
+		return 0, err
+	}
+
+	query := fmt.Sprintf(`SELECT id FROM %s WHERE LOWER(name) = LOWER($1) ORDER BY id LIMIT 1;`, table)
+
+	err = tx.QueryRow(ctx, query, name).Scan(&id)
+
+	if err == nil {
+		return id, nil
+	}
+
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return 0, err
+	}
+
+	query = fmt.Sprintf(`INSERT INTO %s (name) VALUES ($1) RETURNING id;`, table)
+
+	if err = tx.QueryRow(ctx, query, name).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to insert into %s: %w", table, err)
+	}
+
+	return id, nil
+}
+
+func (p *postgresBookImportRepository) exec(ctx context.Context, query string, args ...any) error {
+	row, err := p.conn.Exec(ctx, query, args...)
+
+	if err != nil {
+		return err
+	}
+
+	if row.RowsAffected() == 0 {
+		return ErrBookImportNotFound
+	}
+
+	return nil
+}
+
+func NewPostgresBookImportRepository(conn *pgxpool.Pool) domain.BookImportRepository {
+	return &postgresBookImportRepository{
+		conn: conn,
+	}
+}
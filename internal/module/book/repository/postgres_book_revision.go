@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"backend-layout/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrBookRevisionNotFound = errors.New("book revision not found")
+)
+
+type postgresBookRevisionRepository struct {
+	conn *pgxpool.Pool
+}
+
+// Store implements domain.BookRevisionRepository.
+func (p *postgresBookRevisionRepository) Store(ctx context.Context, tx pgx.Tx, revision *domain.BookRevision) (id int64, err error) {
+	query := `INSERT INTO book_revisions (book_id, action, actor_id, changes)
+			  VALUES ($1, $2, $3, $4)
+			  RETURNING id;`
+
+	err = tx.QueryRow(ctx, query, revision.BookID, revision.Action, revision.ActorID, revision.Changes).Scan(&id)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert book revision: %w", err)
+	}
+
+	return
+}
+
+// GetByID implements domain.BookRevisionRepository.
+func (p *postgresBookRevisionRepository) GetByID(ctx context.Context, tx pgx.Tx, bookID, id int64) (*domain.BookRevision, error) {
+	query := `SELECT id, book_id, action, actor_id, changes, created_at
+			  FROM book_revisions
+			  WHERE id = $1 AND book_id = $2;`
+
+	var r domain.BookRevision
+
+	err := tx.QueryRow(ctx, query, id, bookID).Scan(&r.Id, &r.BookID, &r.Action, &r.ActorID, &r.Changes, &r.CreatedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrBookRevisionNotFound
+		}
+
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// FetchByBookID implements domain.BookRevisionRepository.
+func (p *postgresBookRevisionRepository) FetchByBookID(ctx context.Context, bookID int64, params domain.RequestQueryParams) ([]domain.BookRevisionWithActor, int64, error) {
+	var total int64
+
+	err := p.conn.QueryRow(ctx, `SELECT COUNT(1) FROM book_revisions WHERE book_id = $1;`, bookID).Scan(&total)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT r.id, r.book_id, r.action, r.actor_id, u.name, r.changes, r.created_at
+			  FROM book_revisions r
+			  LEFT JOIN users u ON u.id = r.actor_id
+			  WHERE r.book_id = $1
+			  ORDER BY r.id DESC
+			  LIMIT $2 OFFSET $3;`
+
+	rows, err := p.conn.Query(ctx, query, bookID, params.PerPage, (params.Page-1)*params.PerPage)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer rows.Close()
+
+	result := make([]domain.BookRevisionWithActor, 0)
+
+	for rows.Next() {
+		r := domain.BookRevisionWithActor{}
+
+		err = rows.Scan(&r.Id, &r.BookID, &r.Action, &r.ActorID, &r.ActorName, &r.Changes, &r.CreatedAt)
+
+		if err != nil {
+			return nil, 0, err
+		}
+
+		result = append(result, r)
+	}
+
+	return result, total, rows.Err()
+}
+
+// FetchAfter implements domain.BookRevisionRepository.
+func (p *postgresBookRevisionRepository) FetchAfter(ctx context.Context, tx pgx.Tx, bookID, id int64) ([]domain.BookRevision, error) {
+	query := `SELECT id, book_id, action, actor_id, changes, created_at
+			  FROM book_revisions
+			  WHERE book_id = $1 AND id > $2
+			  ORDER BY id DESC;`
+
+	rows, err := tx.Query(ctx, query, bookID, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := make([]domain.BookRevision, 0)
+
+	for rows.Next() {
+		r := domain.BookRevision{}
+
+		if err := rows.Scan(&r.Id, &r.BookID, &r.Action, &r.ActorID, &r.Changes, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		result = append(result, r)
+	}
+
+	return result, rows.Err()
+}
+
+func NewPostgresBookRevisionRepository(conn *pgxpool.Pool) domain.BookRevisionRepository {
+	return &postgresBookRevisionRepository{
+		conn: conn,
+	}
+}
@@ -0,0 +1,291 @@
+package usecase
+
+import (
+	baseErr "backend-layout/internal/adapter/errors"
+	"backend-layout/internal/domain"
+	"backend-layout/internal/module/book/repository"
+	"backend-layout/internal/tasks"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+)
+
+const defaultBookCopyCondition = "good"
+
+type BookCopyUsecase struct {
+	copyRepo        domain.BookCopyRepository
+	bookRepo        domain.BookRepository
+	taskDistributor tasks.TaskDistributor
+}
+
+// Fetch implements domain.BookCopyUsecase.
+func (b *BookCopyUsecase) Fetch(ctx context.Context, bookID int64, params domain.RequestQueryParams) ([]domain.BookCopyResponse, int64, error) {
+	if _, err := b.bookRepo.GetByID(ctx, bookID); err != nil {
+		if errors.Is(err, repository.ErrBookNotFound) {
+			return nil, 0, baseErr.NewNotFoundError("book not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("book_id", bookID).Msg("failed to get book")
+
+		return nil, 0, baseErr.NewInternalServerError("failed to fetch book copies")
+	}
+
+	copies, total, err := b.copyRepo.FetchByBookID(ctx, bookID, params)
+
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("book_id", bookID).Msg("failed to fetch book copies")
+
+		return nil, 0, baseErr.NewInternalServerError("failed to fetch book copies")
+	}
+
+	result := make([]domain.BookCopyResponse, len(copies))
+
+	for i := range copies {
+		result[i] = domain.BookCopyToResponse(&copies[i])
+	}
+
+	return result, total, nil
+}
+
+// Store implements domain.BookCopyUsecase.
+func (b *BookCopyUsecase) Store(ctx context.Context, input *domain.StoreBookCopyRequest) (resp domain.BookCopyResponse, err error) {
+	tx, err := b.bookRepo.GetTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to begin transaction")
+		return resp, baseErr.NewInternalServerError("failed to add book copy")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	book, err := b.bookRepo.GetForUpdate(ctx, tx, input.BookID)
+	if err != nil {
+		if errors.Is(err, repository.ErrBookNotFound) {
+			return resp, baseErr.NewNotFoundError("book not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("book_id", input.BookID).Msg("failed to get book")
+
+		return resp, baseErr.NewInternalServerError("failed to add book copy")
+	}
+
+	if book.DeletedAt != nil {
+		return resp, baseErr.NewNotFoundError("book not found")
+	}
+
+	bookCopy := domain.BookCopy{
+		BookID:    input.BookID,
+		Barcode:   input.Barcode,
+		Condition: input.Condition,
+		Status:    domain.BookCopyAvailable,
+		Location:  input.Location,
+	}
+
+	if bookCopy.Condition == "" {
+		bookCopy.Condition = defaultBookCopyCondition
+	}
+
+	id, err := b.copyRepo.Store(ctx, tx, &bookCopy)
+	if err != nil {
+		if errors.Is(err, repository.ErrBookCopyBarcodeDuplicate) {
+			return resp, baseErr.NewConflictError("barcode already exists")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("book_id", input.BookID).Msg("failed to store book copy")
+
+		return resp, baseErr.NewInternalServerError("failed to add book copy")
+	}
+
+	stock, err := b.copyRepo.RefreshStock(ctx, tx, input.BookID)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("book_id", input.BookID).Msg("failed to refresh book stock")
+
+		return resp, baseErr.NewInternalServerError("failed to add book copy")
+	}
+
+	stored, err := b.copyRepo.GetForUpdate(ctx, tx, input.BookID, id)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("copy_id", id).Msg("failed to get book copy")
+
+		return resp, baseErr.NewInternalServerError("failed to add book copy")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to commit transaction")
+
+		return resp, baseErr.NewInternalServerError("failed to add book copy")
+	}
+
+	tasks.StockChanged(ctx, b.taskDistributor, input.BookID, stock)
+
+	return domain.BookCopyToResponse(stored), nil
+}
+
+// Update implements domain.BookCopyUsecase.
+func (b *BookCopyUsecase) Update(ctx context.Context, input *domain.UpdateBookCopyRequest) (resp domain.BookCopyResponse, err error) {
+	tx, err := b.bookRepo.GetTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to begin transaction")
+		return resp, baseErr.NewInternalServerError("failed to update book copy")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	bookCopy, err := b.copyRepo.GetForUpdate(ctx, tx, input.BookID, input.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrBookCopyNotFound) {
+			return resp, baseErr.NewNotFoundError("book copy not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("copy_id", input.ID).Msg("failed to get book copy")
+
+		return resp, baseErr.NewInternalServerError("failed to update book copy")
+	}
+
+	// a lent out copy comes back through the order it was handed out for, staff can only write it off
+	if bookCopy.Status == domain.BookCopyOnLoan && input.Status != domain.BookCopyLost {
+		return resp, baseErr.NewConflictError("book copy is on loan")
+	}
+
+	if bookCopy.Status == domain.BookCopyOnHold {
+		return resp, baseErr.NewConflictError("book copy is set aside for a hold")
+	}
+
+	bookCopy.Barcode = input.Barcode
+	bookCopy.Condition = input.Condition
+	bookCopy.Status = input.Status
+	bookCopy.Location = input.Location
+
+	if err = b.copyRepo.Update(ctx, tx, bookCopy); err != nil {
+		if errors.Is(err, repository.ErrBookCopyBarcodeDuplicate) {
+			return resp, baseErr.NewConflictError("barcode already exists")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("copy_id", input.ID).Msg("failed to update book copy")
+
+		return resp, baseErr.NewInternalServerError("failed to update book copy")
+	}
+
+	stock, err := b.copyRepo.RefreshStock(ctx, tx, input.BookID)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("book_id", input.BookID).Msg("failed to refresh book stock")
+
+		return resp, baseErr.NewInternalServerError("failed to update book copy")
+	}
+
+	updated, err := b.copyRepo.GetForUpdate(ctx, tx, input.BookID, input.ID)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("copy_id", input.ID).Msg("failed to get book copy")
+
+		return resp, baseErr.NewInternalServerError("failed to update book copy")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to commit transaction")
+
+		return resp, baseErr.NewInternalServerError("failed to update book copy")
+	}
+
+	tasks.StockChanged(ctx, b.taskDistributor, input.BookID, stock)
+
+	return domain.BookCopyToResponse(updated), nil
+}
+
+// Delete implements domain.BookCopyUsecase.
+func (b *BookCopyUsecase) Delete(ctx context.Context, bookID, id int64) (err error) {
+	tx, err := b.bookRepo.GetTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to begin transaction")
+		return baseErr.NewInternalServerError("failed to delete book copy")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	bookCopy, err := b.copyRepo.GetForUpdate(ctx, tx, bookID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrBookCopyNotFound) {
+			return baseErr.NewNotFoundError("book copy not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("copy_id", id).Msg("failed to get book copy")
+
+		return baseErr.NewInternalServerError("failed to delete book copy")
+	}
+
+	if bookCopy.Status == domain.BookCopyOnLoan {
+		return baseErr.NewConflictError("book copy is on loan")
+	}
+
+	if bookCopy.Status == domain.BookCopyOnHold {
+		return baseErr.NewConflictError("book copy is set aside for a hold")
+	}
+
+	if err = b.copyRepo.Delete(ctx, tx, id); err != nil {
+		if errors.Is(err, repository.ErrBookCopyInUse) {
+			return baseErr.NewConflictError("book copy has lending history, mark it as lost instead")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("copy_id", id).Msg("failed to delete book copy")
+
+		return baseErr.NewInternalServerError("failed to delete book copy")
+	}
+
+	if _, err = b.copyRepo.RefreshStock(ctx, tx, bookID); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("book_id", bookID).Msg("failed to refresh book stock")
+
+		return baseErr.NewInternalServerError("failed to delete book copy")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to commit transaction")
+
+		return baseErr.NewInternalServerError("failed to delete book copy")
+	}
+
+	return nil
+}
+
+func NewBookCopyUsecase(copyRepo domain.BookCopyRepository, bookRepo domain.BookRepository, taskDistributor tasks.TaskDistributor) domain.BookCopyUsecase {
+	return &BookCopyUsecase{
+		copyRepo:        copyRepo,
+		bookRepo:        bookRepo,
+		taskDistributor: taskDistributor,
+	}
+}
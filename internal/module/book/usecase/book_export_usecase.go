@@ -0,0 +1,202 @@
+package usecase
+
+import (
+	"backend-layout/internal/adapter/catalog"
+	baseErr "backend-layout/internal/adapter/errors"
+	"backend-layout/internal/adapter/storage"
+	"backend-layout/internal/domain"
+	"backend-layout/internal/module/book/repository"
+	"backend-layout/internal/tasks"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// directExportLimit is the largest export served straight from the request, anything bigger
+// has to go through a background export
+const directExportLimit = 10000
+
+type BookExportUsecase struct {
+	exportRepo      domain.BookExportRepository
+	bookRepo        domain.BookRepository
+	storage         storage.Uploader
+	taskDistributor tasks.TaskDistributor
+}
+
+// Stream implements domain.BookExportUsecase.
+func (b *BookExportUsecase) Stream(ctx context.Context, format string, filter domain.BookExportFilter, w io.Writer) error {
+	if !catalog.IsExportFormat(format) {
+		return baseErr.NewBadRequestError("format must be csv, ndjson or marc")
+	}
+
+	_, total, err := b.bookRepo.Fetch(ctx, filter.ToParams(1, 1))
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to count books to export")
+		return baseErr.NewInternalServerError("failed to export books")
+	}
+
+	if total > directExportLimit {
+		return baseErr.NewBadRequestError(fmt.Sprintf("export has %d books, exports above %d books must be requested as a background export", total, directExportLimit))
+	}
+
+	if _, err := catalog.ExportBooks(ctx, b.bookRepo, format, filter, w); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Str("format", format).Msg("failed to stream export")
+		return baseErr.NewInternalServerError("failed to export books")
+	}
+
+	return nil
+}
+
+// Create implements domain.BookExportUsecase.
+func (b *BookExportUsecase) Create(ctx context.Context, userID int64, format string, filter domain.BookExportFilter) (domain.BookExportResponse, error) {
+	if !catalog.IsExportFormat(format) {
+		return domain.BookExportResponse{}, baseErr.NewBadRequestError("format must be csv, ndjson or marc")
+	}
+
+	be := domain.BookExport{
+		UserID:    userID,
+		Format:    format,
+		Status:    domain.ExportStatusPending,
+		Filter:    filter,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	var err error
+
+	be.Id, err = b.exportRepo.Store(ctx, &be)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to save export")
+		return domain.BookExportResponse{}, baseErr.NewInternalServerError("failed to create export")
+	}
+
+	err = b.taskDistributor.DistributeTaskExportBooks(ctx, &tasks.PayloadExportBooks{ExportID: be.Id})
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("export_id", be.Id).Msg("failed to enqueue export")
+		return domain.BookExportResponse{}, baseErr.NewInternalServerError("failed to start export")
+	}
+
+	return domain.BookExportToResponse(&be), nil
+}
+
+// Get implements domain.BookExportUsecase.
+func (b *BookExportUsecase) Get(ctx context.Context, id int64) (domain.BookExportResponse, error) {
+	be, err := b.getByID(ctx, id)
+	if err != nil {
+		return domain.BookExportResponse{}, err
+	}
+
+	return domain.BookExportToResponse(be), nil
+}
+
+// Download implements domain.BookExportUsecase.
+func (b *BookExportUsecase) Download(ctx context.Context, id int64) (io.ReadCloser, error) {
+	be, err := b.getByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if be.FileKey == nil {
+		return nil, baseErr.NewNotFoundError("export is not ready yet")
+	}
+
+	file, err := b.storage.Open(ctx, *be.FileKey)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("export_id", id).Msg("failed to open export file")
+		return nil, baseErr.NewInternalServerError("failed to download export")
+	}
+
+	return file, nil
+}
+
+// Process implements domain.BookExportUsecase. The export is written to a temporary file first
+// so the storage upload always receives a complete file.
+func (b *BookExportUsecase) Process(ctx context.Context, id int64) error {
+	be, err := b.exportRepo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get export %d: %w", id, err)
+	}
+
+	if be.Status == domain.ExportStatusCompleted {
+		log.Warn().Int64("export_id", id).Msg("export already completed")
+		return nil
+	}
+
+	if err := b.exportRepo.SetStatus(ctx, id, domain.ExportStatusProcessing, nil); err != nil {
+		return fmt.Errorf("failed to update export status: %w", err)
+	}
+
+	if err := b.process(ctx, be); err != nil {
+		msg := err.Error()
+
+		if statusErr := b.exportRepo.SetStatus(ctx, id, domain.ExportStatusFailed, &msg); statusErr != nil {
+			log.Error().Err(statusErr).Int64("export_id", id).Msg("failed to mark export as failed")
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (b *BookExportUsecase) process(ctx context.Context, be *domain.BookExport) error {
+	file, err := os.CreateTemp("", "book-export-*"+catalog.ExportExtension(be.Format))
+	if err != nil {
+		return fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	total, err := catalog.ExportBooks(ctx, b.bookRepo, be.Format, be.Filter, file)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read export file: %w", err)
+	}
+
+	key := fmt.Sprintf("exports/%d/books%s", be.Id, catalog.ExportExtension(be.Format))
+
+	if _, err := b.storage.Put(ctx, key, file, catalog.ExportContentType(be.Format)); err != nil {
+		return fmt.Errorf("failed to store export file: %w", err)
+	}
+
+	if err := b.exportRepo.Complete(ctx, be.Id, key, total); err != nil {
+		return fmt.Errorf("failed to complete export: %w", err)
+	}
+
+	log.Info().Int64("export_id", be.Id).Int64("total", total).Str("format", be.Format).Msg("book export completed")
+
+	return nil
+}
+
+func (b *BookExportUsecase) getByID(ctx context.Context, id int64) (*domain.BookExport, error) {
+	be, err := b.exportRepo.GetByID(ctx, id)
+
+	if err != nil {
+		if errors.Is(err, repository.ErrBookExportNotFound) {
+			return nil, baseErr.NewNotFoundError("export not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("export_id", id).Msg("failed to get export")
+
+		return nil, baseErr.NewInternalServerError("failed to get export")
+	}
+
+	return be, nil
+}
+
+func NewBookExportUsecase(er domain.BookExportRepository, br domain.BookRepository, storage storage.Uploader, td tasks.TaskDistributor) domain.BookExportUsecase {
+	return &BookExportUsecase{
+		exportRepo:      er,
+		bookRepo:        br,
+		storage:         storage,
+		taskDistributor: td,
+	}
+}
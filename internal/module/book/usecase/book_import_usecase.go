@@ -0,0 +1,457 @@
+package usecase
+
+import (
+	"backend-layout/helper"
+	"backend-layout/internal/adapter/catalog"
+	baseErr "backend-layout/internal/adapter/errors"
+	"backend-layout/internal/adapter/storage"
+	"backend-layout/internal/domain"
+	"backend-layout/internal/module/book/repository"
+	"backend-layout/internal/tasks"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog/log"
+)
+
+// importProgressEvery is how many rows are processed between progress updates
+const importProgressEvery = 25
+
+type BookImportUsecase struct {
+	importRepo      domain.BookImportRepository
+	bookRepo        domain.BookRepository
+	revisionRepo    domain.BookRevisionRepository
+	copyRepo        domain.BookCopyRepository
+	storage         storage.Uploader
+	taskDistributor tasks.TaskDistributor
+	validator       *helper.Validator
+}
+
+// Create implements domain.BookImportUsecase.
+func (b *BookImportUsecase) Create(ctx context.Context, userID int64, format string, file *multipart.FileHeader) (domain.BookImportResponse, error) {
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+
+	if format == "" {
+		switch ext {
+		case ".csv":
+			format = domain.ImportFormatCSV
+		case ".xml", ".onix":
+			format = domain.ImportFormatONIX
+		}
+	}
+
+	if format != domain.ImportFormatCSV && format != domain.ImportFormatONIX {
+		return domain.BookImportResponse{}, baseErr.NewBadRequestError("file must be csv or onix xml")
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to open import file")
+		return domain.BookImportResponse{}, baseErr.NewInternalServerError("failed to create import")
+	}
+	defer src.Close()
+
+	key := fmt.Sprintf("imports/source-%d%s", time.Now().UnixNano(), ext)
+
+	if _, err := b.storage.Put(ctx, key, src, file.Header.Get("Content-Type")); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to store import file")
+		return domain.BookImportResponse{}, baseErr.NewInternalServerError("failed to create import")
+	}
+
+	bi := domain.BookImport{
+		UserID:    userID,
+		Filename:  filepath.Base(file.Filename),
+		Format:    format,
+		Status:    domain.ImportStatusPending,
+		SourceKey: key,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	bi.Id, err = b.importRepo.Store(ctx, &bi)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to save import")
+		return domain.BookImportResponse{}, baseErr.NewInternalServerError("failed to create import")
+	}
+
+	err = b.taskDistributor.DistributeTaskImportBooks(ctx, &tasks.PayloadImportBooks{ImportID: bi.Id})
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("import_id", bi.Id).Msg("failed to enqueue import")
+		return domain.BookImportResponse{}, baseErr.NewInternalServerError("failed to start import")
+	}
+
+	return domain.BookImportToResponse(&bi), nil
+}
+
+// Get implements domain.BookImportUsecase.
+func (b *BookImportUsecase) Get(ctx context.Context, id int64) (domain.BookImportResponse, error) {
+	bi, err := b.importRepo.GetByID(ctx, id)
+
+	if err != nil {
+		if errors.Is(err, repository.ErrBookImportNotFound) {
+			return domain.BookImportResponse{}, baseErr.NewNotFoundError("import not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("import_id", id).Msg("failed to get import")
+
+		return domain.BookImportResponse{}, baseErr.NewInternalServerError("failed to get import")
+	}
+
+	return domain.BookImportToResponse(bi), nil
+}
+
+// Report implements domain.BookImportUsecase.
+func (b *BookImportUsecase) Report(ctx context.Context, id int64) (io.ReadCloser, error) {
+	bi, err := b.importRepo.GetByID(ctx, id)
+
+	if err != nil {
+		if errors.Is(err, repository.ErrBookImportNotFound) {
+			return nil, baseErr.NewNotFoundError("import not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("import_id", id).Msg("failed to get import")
+
+		return nil, baseErr.NewInternalServerError("failed to get import report")
+	}
+
+	if bi.ReportKey == nil {
+		return nil, baseErr.NewNotFoundError("import has no error report")
+	}
+
+	report, err := b.storage.Open(ctx, *bi.ReportKey)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("import_id", id).Msg("failed to open import report")
+		return nil, baseErr.NewInternalServerError("failed to get import report")
+	}
+
+	return report, nil
+}
+
+// Process implements domain.BookImportUsecase. Every row is stored in its own transaction,
+// so a bad row only shows up in the report and never rolls back the rows around it.
+//
+// Rows that were stored would be rejected as duplicates if the file ran again, so an import runs at most once. It is
+// claimed before the first row and marked failed on any error or panic after that, a retry of the task then finds
+// it settled. An import still processing when its task comes back was cut off with the worker and is failed too.
+func (b *BookImportUsecase) Process(ctx context.Context, id int64) (err error) {
+	bi, err := b.importRepo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get import %d: %w", id, err)
+	}
+
+	switch bi.Status {
+	case domain.ImportStatusPending:
+	case domain.ImportStatusProcessing:
+		b.fail(ctx, id, "import was interrupted, upload the file again to import the rest")
+
+		return nil
+	default:
+		log.Warn().Int64("import_id", id).Str("status", bi.Status).Msg("import already processed")
+
+		return nil
+	}
+
+	claimed, err := b.importRepo.Claim(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to claim import %d: %w", id, err)
+	}
+
+	if !claimed {
+		log.Warn().Int64("import_id", id).Msg("import claimed by another worker")
+
+		return nil
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			b.fail(ctx, id, fmt.Sprintf("import stopped unexpectedly: %v", p))
+
+			panic(p)
+		}
+
+		if err != nil {
+			b.fail(ctx, id, err.Error())
+		}
+	}()
+
+	return b.process(ctx, bi)
+}
+
+// fail marks an import as failed, also when the task that ran it was cancelled
+func (b *BookImportUsecase) fail(ctx context.Context, id int64, msg string) {
+	if err := b.importRepo.SetStatus(context.WithoutCancel(ctx), id, domain.ImportStatusFailed, &msg); err != nil {
+		log.Error().Err(err).Int64("import_id", id).Msg("failed to mark import as failed")
+	}
+}
+
+func (b *BookImportUsecase) process(ctx context.Context, bi *domain.BookImport) error {
+	total, err := b.countRows(ctx, bi)
+	if err != nil {
+		return err
+	}
+
+	if err := b.importRepo.SetTotalRows(ctx, bi.Id, total); err != nil {
+		return fmt.Errorf("failed to update total rows: %w", err)
+	}
+
+	src, err := b.storage.Open(ctx, bi.SourceKey)
+	if err != nil {
+		return fmt.Errorf("failed to open import file: %w", err)
+	}
+	defer src.Close()
+
+	reader, err := catalog.NewRowReader(bi.Format, src)
+	if err != nil {
+		return err
+	}
+
+	reportFile, err := os.CreateTemp("", "import-report-*.csv")
+	if err != nil {
+		return fmt.Errorf("failed to create report file: %w", err)
+	}
+	defer os.Remove(reportFile.Name())
+	defer reportFile.Close()
+
+	report, err := catalog.NewImportReportWriter(reportFile)
+	if err != nil {
+		return fmt.Errorf("failed to write report header: %w", err)
+	}
+
+	var processed, success, failed int64
+
+	for {
+		row, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		processed++
+
+		if rowErrs := b.importRow(ctx, bi.UserID, row); len(rowErrs) > 0 {
+			failed++
+
+			err = report.Write(domain.ImportRowError{Line: row.Line, Isbn: row.Isbn, Title: row.Title, Errors: rowErrs})
+			if err != nil {
+				return fmt.Errorf("failed to write report: %w", err)
+			}
+		} else {
+			success++
+		}
+
+		if processed%importProgressEvery == 0 {
+			if err := b.importRepo.UpdateProgress(ctx, bi.Id, processed, success, failed); err != nil {
+				log.Warn().Err(err).Int64("import_id", bi.Id).Msg("failed to update import progress")
+			}
+		}
+	}
+
+	if err := b.importRepo.UpdateProgress(ctx, bi.Id, processed, success, failed); err != nil {
+		return fmt.Errorf("failed to update import progress: %w", err)
+	}
+
+	var reportKey *string
+
+	if failed > 0 {
+		if err := report.Flush(); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+
+		if _, err := reportFile.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to read report: %w", err)
+		}
+
+		key := fmt.Sprintf("imports/%d/report.csv", bi.Id)
+
+		if _, err := b.storage.Put(ctx, key, reportFile, "text/csv"); err != nil {
+			return fmt.Errorf("failed to store report: %w", err)
+		}
+
+		reportKey = &key
+	}
+
+	if err := b.importRepo.Complete(ctx, bi.Id, reportKey); err != nil {
+		return fmt.Errorf("failed to complete import: %w", err)
+	}
+
+	log.Info().Int64("import_id", bi.Id).Int64("success", success).Int64("failed", failed).Msg("book import completed")
+
+	return nil
+}
+
+func (b *BookImportUsecase) countRows(ctx context.Context, bi *domain.BookImport) (int64, error) {
+	src, err := b.storage.Open(ctx, bi.SourceKey)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open import file: %w", err)
+	}
+	defer src.Close()
+
+	reader, err := catalog.NewRowReader(bi.Format, src)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+
+	for {
+		if _, err := reader.Next(); err != nil {
+			if err == io.EOF {
+				return total, nil
+			}
+
+			return 0, err
+		}
+
+		total++
+	}
+}
+
+// importRow validates and stores a single row, returning the reasons it was rejected
+func (b *BookImportUsecase) importRow(ctx context.Context, userID int64, row *domain.ImportBookRow) (rowErrs []string) {
+	rowErrs = append(rowErrs, row.ParseErrors...)
+
+	if err := b.validator.Validate(row); err != nil {
+		var vErrs validator.ValidationErrors
+		if !errors.As(err, &vErrs) {
+			return append(rowErrs, err.Error())
+		}
+
+		for _, fe := range b.validator.TranslateError(vErrs) {
+			rowErrs = append(rowErrs, fe.Message)
+		}
+	}
+
+	if len(rowErrs) > 0 {
+		return rowErrs
+	}
+
+	tx, err := b.bookRepo.GetTx(ctx)
+	if err != nil {
+		return []string{"failed to store book"}
+	}
+
+	defer tx.Rollback(ctx)
+
+	authorID, err := b.importRepo.FindOrCreateAuthor(ctx, tx, row.AuthorName)
+	if err != nil {
+		log.Error().Err(err).Int("line", row.Line).Msg("failed to upsert author")
+		return []string{"failed to save author"}
+	}
+
+	publisherID, err := b.importRepo.FindOrCreatePublisher(ctx, tx, row.Publisher)
+	if err != nil {
+		log.Error().Err(err).Int("line", row.Line).Msg("failed to upsert publisher")
+		return []string{"failed to save publisher"}
+	}
+
+	categories, err := b.importRepo.GetCategoryIDsByNames(ctx, tx, row.Categories)
+	if err != nil {
+		log.Error().Err(err).Int("line", row.Line).Msg("failed to get categories")
+		return []string{"failed to resolve categories"}
+	}
+
+	categoryIDs := make([]int64, 0, len(row.Categories))
+
+	for _, name := range row.Categories {
+		id, ok := categories[strings.ToLower(name)]
+		if !ok {
+			rowErrs = append(rowErrs, fmt.Sprintf("unknown category: %s", name))
+			continue
+		}
+
+		categoryIDs = append(categoryIDs, id)
+	}
+
+	if len(rowErrs) > 0 {
+		return rowErrs
+	}
+
+	sku, _ := helper.GenerateRandomNumberString(10)
+
+	book := domain.Book{
+		Title:       row.Title,
+		Slug:        toSlug(row.Title),
+		Author:      domain.Author{Id: authorID},
+		Publisher:   domain.Publisher{Id: publisherID},
+		PublishYear: row.PublishYear,
+		TotalPage:   row.TotalPage,
+		Description: row.Description,
+		Sku:         sku,
+		Isbn:        row.Isbn,
+		Price:       row.Price,
+		CategoryID:  categoryIDs,
+	}
+
+	bookID, err := b.bookRepo.Store(ctx, tx, &book)
+	if err != nil {
+		if errors.Is(err, repository.ErrISBNDuplicateEntry) {
+			return []string{"isbn already exists"}
+		}
+
+		return []string{"failed to store book"}
+	}
+
+	// the stock of a book is counted from its copies, so the file's stock comes in as that many copies on the shelf
+	for i := 1; i <= row.Stock; i++ {
+		bookCopy := domain.BookCopy{
+			BookID:    bookID,
+			Barcode:   fmt.Sprintf("%s-%d", sku, i),
+			Condition: defaultBookCopyCondition,
+			Status:    domain.BookCopyAvailable,
+		}
+
+		if _, err := b.copyRepo.Store(ctx, tx, &bookCopy); err != nil {
+			log.Error().Err(err).Int("line", row.Line).Msg("failed to store book copy")
+			return []string{"failed to store book copies"}
+		}
+	}
+
+	if row.Stock > 0 {
+		if _, err := b.copyRepo.RefreshStock(ctx, tx, bookID); err != nil {
+			log.Error().Err(err).Int("line", row.Line).Msg("failed to refresh book stock")
+			return []string{"failed to store book copies"}
+		}
+	}
+
+	revision := domain.BookRevision{
+		BookID:  bookID,
+		Action:  domain.BookRevisionCreate,
+		ActorID: &userID,
+		Changes: domain.DiffBookState(nil, domain.BookToState(&book)),
+	}
+
+	if _, err := b.revisionRepo.Store(ctx, tx, &revision); err != nil {
+		log.Error().Err(err).Int("line", row.Line).Msg("failed to store book revision")
+		return []string{"failed to store book"}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Int("line", row.Line).Msg("failed to commit imported book")
+		return []string{"failed to store book"}
+	}
+
+	return nil
+}
+
+func NewBookImportUsecase(ir domain.BookImportRepository, br domain.BookRepository, rr domain.BookRevisionRepository, cr domain.BookCopyRepository, storage storage.Uploader, td tasks.TaskDistributor) domain.BookImportUsecase {
+	return &BookImportUsecase{
+		importRepo:      ir,
+		bookRepo:        br,
+		revisionRepo:    rr,
+		copyRepo:        cr,
+		storage:         storage,
+		taskDistributor: td,
+		validator:       helper.NewValidator(),
+	}
+}
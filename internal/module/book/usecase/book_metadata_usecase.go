@@ -0,0 +1,216 @@
+package usecase
+
+import (
+	baseErr "backend-layout/internal/adapter/errors"
+	"backend-layout/internal/domain"
+	"backend-layout/internal/module/book/repository"
+	"backend-layout/internal/tasks"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// enrichBatchSize is how many books are read per query while scanning for missing metadata
+	enrichBatchSize = 100
+
+	// enrichLookupInterval spaces out provider lookups so a full run stays within public API limits
+	enrichLookupInterval = 500 * time.Millisecond
+)
+
+type BookMetadataUsecase struct {
+	bookRepo        domain.BookRepository
+	revisionRepo    domain.BookRevisionRepository
+	provider        domain.BookMetadataProvider
+	taskDistributor tasks.TaskDistributor
+}
+
+// Lookup implements domain.BookMetadataUsecase.
+func (b *BookMetadataUsecase) Lookup(ctx context.Context, isbn string) (domain.BookMetadataResponse, error) {
+	m, err := b.provider.LookupISBN(ctx, isbn)
+
+	if err != nil {
+		if errors.Is(err, domain.ErrBookMetadataNotFound) {
+			return domain.BookMetadataResponse{}, baseErr.NewNotFoundError("no metadata found for isbn")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Str("isbn", isbn).Msg("failed to lookup isbn")
+
+		return domain.BookMetadataResponse{}, baseErr.NewBadGatewayError("metadata source is unavailable")
+	}
+
+	return domain.BookMetadataToResponse(m), nil
+}
+
+// RequestEnrichment implements domain.BookMetadataUsecase.
+func (b *BookMetadataUsecase) RequestEnrichment(ctx context.Context, bookIDs []int64) error {
+	opts := make([]asynq.Option, 0)
+
+	// a full scan already covers every book, there is no point in queueing a second one
+	if len(bookIDs) == 0 {
+		opts = append(opts, asynq.Unique(time.Hour))
+	}
+
+	err := b.taskDistributor.DistributeTaskEnrichBookMetadata(ctx, &tasks.PayloadEnrichBookMetadata{BookIDs: bookIDs}, opts...)
+
+	if err != nil {
+		if errors.Is(err, asynq.ErrDuplicateTask) {
+			return baseErr.NewConflictError("metadata enrichment is already queued")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to enqueue metadata enrichment")
+
+		return baseErr.NewInternalServerError("failed to start metadata enrichment")
+	}
+
+	return nil
+}
+
+// Enrich implements domain.BookMetadataUsecase. Without book IDs every book missing a description
+// or page count is checked.
+func (b *BookMetadataUsecase) Enrich(ctx context.Context, bookIDs []int64) (domain.EnrichMetadataResult, error) {
+	var result domain.EnrichMetadataResult
+
+	if len(bookIDs) > 0 {
+		for _, id := range bookIDs {
+			book, err := b.bookRepo.GetByID(ctx, id)
+			if err != nil {
+				if errors.Is(err, repository.ErrBookNotFound) {
+					result.NotFound++
+					continue
+				}
+
+				return result, err
+			}
+
+			if err := b.enrichBook(ctx, book, &result); err != nil {
+				return result, err
+			}
+		}
+
+		return result, nil
+	}
+
+	var afterID int64
+
+	for {
+		books, err := b.bookRepo.FetchMissingMetadata(ctx, afterID, enrichBatchSize)
+		if err != nil {
+			return result, err
+		}
+
+		for i := range books {
+			if err := b.enrichBook(ctx, &books[i], &result); err != nil {
+				return result, err
+			}
+
+			afterID = books[i].Id
+		}
+
+		if len(books) < enrichBatchSize {
+			return result, nil
+		}
+	}
+}
+
+// enrichBook fills what is missing on a single book, lookup failures are counted rather than returned
+// so one bad ISBN does not stop the run
+func (b *BookMetadataUsecase) enrichBook(ctx context.Context, book *domain.Book, result *domain.EnrichMetadataResult) error {
+	if book.Description != "" && book.TotalPage > 0 {
+		return nil
+	}
+
+	result.Checked++
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(enrichLookupInterval):
+	}
+
+	m, err := b.provider.LookupISBN(ctx, book.Isbn)
+	if err != nil {
+		if errors.Is(err, domain.ErrBookMetadataNotFound) {
+			result.NotFound++
+			return nil
+		}
+
+		log.Warn().Err(err).Int64("book_id", book.Id).Str("isbn", book.Isbn).Msg("failed to lookup book metadata")
+		result.Failed++
+
+		return nil
+	}
+
+	// only the missing fields are handed over, what the book already has is never overwritten
+	changes := make(map[string]domain.BookFieldChange)
+
+	var (
+		description string
+		totalPage   int
+	)
+
+	if book.Description == "" && m.Description != "" {
+		description = m.Description
+		changes["description"] = fieldChange(book.Description, m.Description)
+	}
+
+	if book.TotalPage <= 0 && m.TotalPage > 0 {
+		totalPage = m.TotalPage
+		changes["total_page"] = fieldChange(book.TotalPage, m.TotalPage)
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	tx, err := b.bookRepo.GetTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	updated, err := b.bookRepo.FillMetadata(ctx, tx, book.Id, description, totalPage)
+	if err != nil {
+		return err
+	}
+
+	if !updated {
+		return nil
+	}
+
+	// enrichment has no actor, the revision is recorded without one
+	revision := domain.BookRevision{BookID: book.Id, Action: domain.BookRevisionUpdate, Changes: changes}
+
+	if _, err := b.revisionRepo.Store(ctx, tx, &revision); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	result.Updated++
+
+	return nil
+}
+
+func fieldChange(before, after any) domain.BookFieldChange {
+	b, _ := json.Marshal(before)
+	a, _ := json.Marshal(after)
+
+	return domain.BookFieldChange{Before: b, After: a}
+}
+
+func NewBookMetadataUsecase(br domain.BookRepository, rr domain.BookRevisionRepository, provider domain.BookMetadataProvider, td tasks.TaskDistributor) domain.BookMetadataUsecase {
+	return &BookMetadataUsecase{
+		bookRepo:        br,
+		revisionRepo:    rr,
+		provider:        provider,
+		taskDistributor: td,
+	}
+}
@@ -0,0 +1,194 @@
+package usecase
+
+import (
+	baseErr "backend-layout/internal/adapter/errors"
+	"backend-layout/internal/adapter/metadata"
+	"backend-layout/internal/domain"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// the embedded interfaces are left nil, the tests only reach the methods written out below
+
+type fakeTx struct {
+	pgx.Tx
+	committed bool
+}
+
+func (t *fakeTx) Commit(ctx context.Context) error {
+	t.committed = true
+	return nil
+}
+
+func (t *fakeTx) Rollback(ctx context.Context) error {
+	return nil
+}
+
+type fillCall struct {
+	id          int64
+	description string
+	totalPage   int
+}
+
+type fakeMetadataBookRepo struct {
+	domain.BookRepository
+	books map[int64]*domain.Book
+	fills []fillCall
+	tx    *fakeTx
+}
+
+func (r *fakeMetadataBookRepo) GetByID(ctx context.Context, id int64) (*domain.Book, error) {
+	return r.books[id], nil
+}
+
+func (r *fakeMetadataBookRepo) GetTx(ctx context.Context) (pgx.Tx, error) {
+	r.tx = &fakeTx{}
+	return r.tx, nil
+}
+
+func (r *fakeMetadataBookRepo) FillMetadata(ctx context.Context, tx pgx.Tx, id int64, description string, totalPage int) (bool, error) {
+	r.fills = append(r.fills, fillCall{id: id, description: description, totalPage: totalPage})
+	return true, nil
+}
+
+type fakeRevisionRepo struct {
+	domain.BookRevisionRepository
+	revisions []domain.BookRevision
+}
+
+func (r *fakeRevisionRepo) Store(ctx context.Context, tx pgx.Tx, revision *domain.BookRevision) (int64, error) {
+	r.revisions = append(r.revisions, *revision)
+	return int64(len(r.revisions)), nil
+}
+
+type failingProvider struct {
+	calls int
+}
+
+func (p *failingProvider) LookupISBN(ctx context.Context, isbn string) (*domain.BookMetadata, error) {
+	p.calls++
+	return nil, errors.New("connection refused")
+}
+
+func fixtureProvider(t *testing.T) domain.BookMetadataProvider {
+	t.Helper()
+
+	provider, err := metadata.NewFixtureProvider("")
+	if err != nil {
+		t.Fatalf("load bundled fixtures: %v", err)
+	}
+
+	return provider
+}
+
+func statusOf(err error) int {
+	var be baseErr.BaseError
+	if errors.As(err, &be) {
+		return be.Code
+	}
+
+	return 0
+}
+
+func TestLookupFromFixtures(t *testing.T) {
+	u := NewBookMetadataUsecase(nil, nil, fixtureProvider(t), nil)
+
+	resp, err := u.Lookup(context.Background(), "9780132350884")
+	if err != nil {
+		t.Fatalf("lookup: %v", err)
+	}
+
+	if resp.Title != "Clean Code" || resp.TotalPage != 431 {
+		t.Errorf("response = %+v, want Clean Code with 431 pages", resp)
+	}
+}
+
+func TestLookupUnknownISBN(t *testing.T) {
+	u := NewBookMetadataUsecase(nil, nil, fixtureProvider(t), nil)
+
+	if _, err := u.Lookup(context.Background(), "0000000000000"); statusOf(err) != http.StatusNotFound {
+		t.Errorf("err = %v, want a %d", err, http.StatusNotFound)
+	}
+}
+
+func TestLookupProviderError(t *testing.T) {
+	u := NewBookMetadataUsecase(nil, nil, &failingProvider{}, nil)
+
+	if _, err := u.Lookup(context.Background(), "9780132350884"); statusOf(err) != http.StatusBadGateway {
+		t.Errorf("err = %v, want a %d", err, http.StatusBadGateway)
+	}
+}
+
+func TestEnrichKeepsFieldsAlreadySet(t *testing.T) {
+	books := &fakeMetadataBookRepo{books: map[int64]*domain.Book{
+		1: {Id: 1, Isbn: "9780132350884", Description: "Written by the librarian.", TotalPage: 0},
+	}}
+	revisions := &fakeRevisionRepo{}
+
+	u := NewBookMetadataUsecase(books, revisions, fixtureProvider(t), nil)
+
+	result, err := u.Enrich(context.Background(), []int64{1})
+	if err != nil {
+		t.Fatalf("enrich: %v", err)
+	}
+
+	if result.Checked != 1 || result.Updated != 1 {
+		t.Errorf("result = %+v, want 1 checked and 1 updated", result)
+	}
+
+	if len(books.fills) != 1 || books.fills[0] != (fillCall{id: 1, totalPage: 431}) {
+		t.Fatalf("fills = %+v, want only the page count of book 1", books.fills)
+	}
+
+	if len(revisions.revisions) != 1 {
+		t.Fatalf("stored %d revisions, want 1", len(revisions.revisions))
+	}
+
+	changes := revisions.revisions[0].Changes
+	if _, ok := changes["description"]; ok || len(changes) != 1 {
+		t.Errorf("revision changes = %v, want total_page only", changes)
+	}
+
+	if !books.tx.committed {
+		t.Error("enrichment was not committed")
+	}
+}
+
+func TestEnrichSkipsCompleteBooks(t *testing.T) {
+	books := &fakeMetadataBookRepo{books: map[int64]*domain.Book{
+		1: {Id: 1, Isbn: "9780132350884", Description: "Written by the librarian.", TotalPage: 300},
+	}}
+	provider := &failingProvider{}
+
+	u := NewBookMetadataUsecase(books, &fakeRevisionRepo{}, provider, nil)
+
+	result, err := u.Enrich(context.Background(), []int64{1})
+	if err != nil {
+		t.Fatalf("enrich: %v", err)
+	}
+
+	if provider.calls != 0 || len(books.fills) != 0 || result.Checked != 0 {
+		t.Errorf("complete book was looked up %d times and filled %d times", provider.calls, len(books.fills))
+	}
+}
+
+func TestEnrichCountsProviderErrors(t *testing.T) {
+	books := &fakeMetadataBookRepo{books: map[int64]*domain.Book{
+		1: {Id: 1, Isbn: "9780132350884"},
+	}}
+
+	u := NewBookMetadataUsecase(books, &fakeRevisionRepo{}, &failingProvider{}, nil)
+
+	result, err := u.Enrich(context.Background(), []int64{1})
+	if err != nil {
+		t.Fatalf("enrich: %v", err)
+	}
+
+	if result.Failed != 1 || len(books.fills) != 0 {
+		t.Errorf("result = %+v after %d fills, want 1 failed and nothing filled", result, len(books.fills))
+	}
+}
@@ -3,38 +3,240 @@ package usecase
 import (
 	"backend-layout/helper"
 	baseErr "backend-layout/internal/adapter/errors"
+	"backend-layout/internal/adapter/storage"
 	"backend-layout/internal/domain"
 	"backend-layout/internal/middleware"
 	"backend-layout/internal/module/book/repository"
+	"backend-layout/internal/tasks"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"mime/multipart"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/rs/zerolog/log"
 )
 
 type BookUsecase struct {
-	bookRepo domain.BookRepository
+	bookRepo        domain.BookRepository
+	revisionRepo    domain.BookRevisionRepository
+	storage         storage.Uploader
+	taskDistributor tasks.TaskDistributor
+}
+
+// UploadImage implements domain.BookUsecase. The processing is enqueued before the new image is committed, a
+// failure to enqueue leaves the book as it was and removes the stored source. The row stays locked until the
+// commit, so the renditions the task saves can not be overwritten by the source URL.
+func (b *BookUsecase) UploadImage(ctx context.Context, id int64, file *multipart.FileHeader) (imageUrl string, err error) {
+	if err := helper.ValidateImageFile(file); err != nil {
+		return "", baseErr.NewBadRequestError(err.Error())
+	}
+
+	if _, err := b.bookRepo.GetByID(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrBookNotFound) {
+			return "", baseErr.NewNotFoundError("book not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("book_id", id).Msg("failed to get book")
+
+		return "", baseErr.NewInternalServerError("failed to upload image")
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("book_id", id).Msg("failed to open image")
+		return "", baseErr.NewInternalServerError("failed to upload image")
+	}
+	defer src.Close()
+
+	key := fmt.Sprintf("books/%d/source-%d%s", id, time.Now().UnixNano(), strings.ToLower(filepath.Ext(file.Filename)))
+
+	imageUrl, err = b.storage.Put(ctx, key, src, file.Header.Get("Content-Type"))
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("book_id", id).Msg("failed to store image")
+		return "", baseErr.NewInternalServerError("failed to upload image")
+	}
+
+	tx, err := b.bookRepo.GetTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to begin transaction")
+		b.deleteSource(ctx, id, key)
+
+		return "", baseErr.NewInternalServerError("failed to upload image")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			b.deleteSource(ctx, id, key)
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+
+			b.deleteSource(ctx, id, key)
+		}
+	}()
+
+	if err = b.bookRepo.UpdateImage(ctx, tx, id, imageUrl); err != nil {
+		if errors.Is(err, repository.ErrBookNotFound) {
+			return "", baseErr.NewNotFoundError("book not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("book_id", id).Msg("failed to update book image")
+
+		return "", baseErr.NewInternalServerError("failed to upload image")
+	}
+
+	err = b.taskDistributor.DistributeTaskProcessBookImage(ctx, &tasks.PayloadProcessBookImage{
+		BookID:    id,
+		SourceKey: key,
+	})
+
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("book_id", id).Msg("failed to enqueue image processing")
+		return "", baseErr.NewInternalServerError("failed to process image")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("book_id", id).Msg("failed to commit transaction")
+		return "", baseErr.NewInternalServerError("failed to upload image")
+	}
+
+	return imageUrl, nil
+}
+
+// deleteSource removes an uploaded source image no book points to, the client is expected to upload it again
+func (b *BookUsecase) deleteSource(ctx context.Context, id int64, key string) {
+	if err := b.storage.Delete(context.WithoutCancel(ctx), key); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("book_id", id).Str("key", key).Msg("failed to delete image source")
+	}
 }
 
 // Delete implements domain.BookUsecase.
-func (b *BookUsecase) Delete(ctx context.Context, id int64) error {
-	err := b.bookRepo.Delete(ctx, id)
+func (b *BookUsecase) Delete(ctx context.Context, id int64, actorID int64) (err error) {
+	return b.setDeleted(ctx, id, actorID, true)
+}
+
+// Restore implements domain.BookUsecase.
+func (b *BookUsecase) Restore(ctx context.Context, id int64, actorID int64) (err error) {
+	return b.setDeleted(ctx, id, actorID, false)
+}
+
+// setDeleted soft deletes or restores a book and records the revision in the same transaction
+func (b *BookUsecase) setDeleted(ctx context.Context, id int64, actorID int64, deleted bool) (err error) {
+	action, verb := domain.BookRevisionRestore, "restore"
+	if deleted {
+		action, verb = domain.BookRevisionDelete, "delete"
+	}
+
+	tx, err := b.bookRepo.GetTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to begin transaction")
+		return baseErr.NewInternalServerError("failed to " + verb + " book")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	if deleted {
+		err = b.bookRepo.Delete(ctx, tx, id)
+	} else {
+		err = b.bookRepo.Restore(ctx, tx, id)
+	}
 
 	if err != nil {
 		if errors.Is(err, repository.ErrBookNotFound) {
-			return baseErr.NewNotFoundError("book not found")
+			if deleted {
+				return baseErr.NewNotFoundError("book not found")
+			}
+
+			return baseErr.NewNotFoundError("deleted book not found")
 		}
 
-		log.Error().Err(err).Str("layer", "usecase").Msg("failed to delete book")
+		log.Error().Err(err).Str("layer", "usecase").Int64("book_id", id).Msg("failed to " + verb + " book")
+
+		return baseErr.NewInternalServerError("failed to " + verb + " book")
+	}
+
+	changes := map[string]domain.BookFieldChange{
+		domain.BookFieldDeleted: {
+			Before: json.RawMessage(strconv.FormatBool(!deleted)),
+			After:  json.RawMessage(strconv.FormatBool(deleted)),
+		},
+	}
+
+	if err = b.storeRevision(ctx, tx, id, action, actorID, changes); err != nil {
+		return baseErr.NewInternalServerError("failed to " + verb + " book")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to commit transaction")
 
-		return baseErr.NewInternalServerError("failed to delete book")
+		return baseErr.NewInternalServerError("failed to " + verb + " book")
 	}
 
 	return nil
 }
 
+// PurgeDeleted implements domain.BookUsecase.
+func (b *BookUsecase) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (purged int, err error) {
+	tx, err := b.bookRepo.GetTx(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	ids, err := b.bookRepo.PurgeDeleted(ctx, tx, deletedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted books: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit purge: %w", err)
+	}
+
+	if len(ids) > 0 {
+		log.Info().Ints64("book_ids", ids).Msg("purged deleted books")
+	}
+
+	return len(ids), nil
+}
+
 // Get implements domain.BookUsecase.
 func (b *BookUsecase) Get(ctx context.Context, id int64) (domain.BookResponse, error) {
 	book, err := b.bookRepo.GetByID(ctx, id)
@@ -50,7 +252,27 @@ func (b *BookUsecase) Get(ctx context.Context, id int64) (domain.BookResponse, e
 		return domain.BookResponse{}, baseErr.NewInternalServerError("failed to get book")
 	}
 
-	return domain.BookToResponse(book), nil
+	renditions, err := b.bookRepo.GetImageRenditions(ctx, id)
+
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("book_id", id).Msg("failed to get image renditions")
+
+		return domain.BookResponse{}, baseErr.NewInternalServerError("failed to get book")
+	}
+
+	resp := domain.BookToResponse(book)
+
+	for _, r := range renditions {
+		resp.Renditions = append(resp.Renditions, domain.BookImageRenditionResponse{
+			Variant: r.Variant,
+			Format:  r.Format,
+			Width:   r.Width,
+			Height:  r.Height,
+			Url:     r.Url,
+		})
+	}
+
+	return resp, nil
 }
 
 // Update implements domain.BookUsecase.
@@ -89,6 +311,21 @@ func (b *BookUsecase) Update(ctx context.Context, input *domain.UpdateBookReques
 		}
 	}()
 
+	before, err := b.bookRepo.GetForUpdate(ctx, tx, book.Id)
+	if err != nil {
+		if errors.Is(err, repository.ErrBookNotFound) {
+			return baseErr.NewNotFoundError("book not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("book_id", book.Id).Msg("failed to get book")
+
+		return baseErr.NewInternalServerError("failed to update book")
+	}
+
+	if before.DeletedAt != nil {
+		return baseErr.NewNotFoundError("book not found")
+	}
+
 	if err = b.bookRepo.Update(ctx, tx, &book); err != nil {
 		if errors.Is(err, repository.ErrBookNotFound) {
 			return baseErr.NewNotFoundError("book not found")
@@ -99,6 +336,14 @@ func (b *BookUsecase) Update(ctx context.Context, input *domain.UpdateBookReques
 		return baseErr.NewInternalServerError("failed to update book")
 	}
 
+	beforeState := domain.BookToState(before)
+
+	if changes := domain.DiffBookState(&beforeState, domain.BookToState(&book)); len(changes) > 0 {
+		if err = b.storeRevision(ctx, tx, book.Id, domain.BookRevisionUpdate, input.ActorID, changes); err != nil {
+			return baseErr.NewInternalServerError("failed to update book")
+		}
+	}
+
 	if err = tx.Commit(ctx); err != nil {
 		log.Error().Err(err).Str("layer", "usecase").Msg("failed to update book")
 
@@ -109,7 +354,7 @@ func (b *BookUsecase) Update(ctx context.Context, input *domain.UpdateBookReques
 }
 
 // Store implements domain.BookUsecase.
-func (b *BookUsecase) Store(ctx context.Context, input *domain.StoreBookRequest) (int64, error) {
+func (b *BookUsecase) Store(ctx context.Context, input *domain.StoreBookRequest) (id int64, err error) {
 
 	sku, _ := helper.GenerateRandomNumberString(10)
 
@@ -131,7 +376,27 @@ func (b *BookUsecase) Store(ctx context.Context, input *domain.StoreBookRequest)
 		CategoryID:  input.CategoryID,
 	}
 
-	id, err := b.bookRepo.Store(ctx, &book)
+	tx, err := b.bookRepo.GetTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to begin transaction")
+		return 0, baseErr.NewInternalServerError("failed to create book")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	id, err = b.bookRepo.Store(ctx, tx, &book)
 
 	if err != nil {
 
@@ -149,9 +414,168 @@ func (b *BookUsecase) Store(ctx context.Context, input *domain.StoreBookRequest)
 		return 0, baseErr.NewInternalServerError("failed to create book")
 	}
 
+	if err = b.storeRevision(ctx, tx, id, domain.BookRevisionCreate, input.ActorID, domain.DiffBookState(nil, domain.BookToState(&book))); err != nil {
+		return 0, baseErr.NewInternalServerError("failed to create book")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to commit transaction")
+
+		return 0, baseErr.NewInternalServerError("failed to create book")
+	}
+
 	return id, nil
 }
 
+// FetchRevisions implements domain.BookUsecase.
+func (b *BookUsecase) FetchRevisions(ctx context.Context, id int64, params domain.RequestQueryParams) ([]domain.BookRevisionResponse, int64, error) {
+	revisions, total, err := b.revisionRepo.FetchByBookID(ctx, id, params)
+
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("book_id", id).Msg("failed to fetch book revisions")
+
+		return nil, 0, baseErr.NewInternalServerError("failed to fetch book revisions")
+	}
+
+	result := make([]domain.BookRevisionResponse, len(revisions))
+
+	for i := range revisions {
+		result[i] = domain.BookRevisionToResponse(&revisions[i])
+	}
+
+	return result, total, nil
+}
+
+// RevertToRevision implements domain.BookUsecase. Revisions only hold the fields that changed, so the
+// state right after revisionID is rebuilt by undoing every later revision on top of the current book.
+func (b *BookUsecase) RevertToRevision(ctx context.Context, id, revisionID, actorID int64) (err error) {
+	tx, err := b.bookRepo.GetTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to begin transaction")
+		return baseErr.NewInternalServerError("failed to revert book")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	current, err := b.bookRepo.GetForUpdate(ctx, tx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrBookNotFound) {
+			return baseErr.NewNotFoundError("book not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("book_id", id).Msg("failed to get book")
+
+		return baseErr.NewInternalServerError("failed to revert book")
+	}
+
+	if current.DeletedAt != nil {
+		return baseErr.NewConflictError("book is deleted, restore it before reverting")
+	}
+
+	if _, err = b.revisionRepo.GetByID(ctx, tx, id, revisionID); err != nil {
+		if errors.Is(err, repository.ErrBookRevisionNotFound) {
+			return baseErr.NewNotFoundError("revision not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("revision_id", revisionID).Msg("failed to get book revision")
+
+		return baseErr.NewInternalServerError("failed to revert book")
+	}
+
+	later, err := b.revisionRepo.FetchAfter(ctx, tx, id, revisionID)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("revision_id", revisionID).Msg("failed to fetch later revisions")
+
+		return baseErr.NewInternalServerError("failed to revert book")
+	}
+
+	currentState := domain.BookToState(current)
+	fields := currentState.Fields()
+
+	for _, rev := range later {
+		for field, change := range rev.Changes {
+			if _, tracked := fields[field]; tracked {
+				fields[field] = change.Before
+			}
+		}
+	}
+
+	target, err := domain.BookStateFromFields(fields)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("revision_id", revisionID).Msg("failed to rebuild book state")
+
+		return baseErr.NewInternalServerError("failed to revert book")
+	}
+
+	changes := domain.DiffBookState(&currentState, target)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	book := domain.Book{
+		Id:          id,
+		Title:       target.Title,
+		Slug:        toSlug(target.Title),
+		Author:      domain.Author{Id: target.AuthorID},
+		Publisher:   domain.Publisher{Id: target.PublisherID},
+		PublishYear: target.PublishYear,
+		TotalPage:   target.TotalPage,
+		Description: target.Description,
+		Isbn:        target.Isbn,
+		Price:       target.Price,
+		CategoryID:  target.CategoryID,
+	}
+
+	if err = b.bookRepo.Update(ctx, tx, &book); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("book_id", id).Int64("revision_id", revisionID).Msg("failed to revert book")
+
+		return baseErr.NewConflictError("book can not be reverted to this revision")
+	}
+
+	if err = b.storeRevision(ctx, tx, id, domain.BookRevisionRevert, actorID, changes); err != nil {
+		return baseErr.NewInternalServerError("failed to revert book")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to commit transaction")
+
+		return baseErr.NewInternalServerError("failed to revert book")
+	}
+
+	return nil
+}
+
+func (b *BookUsecase) storeRevision(ctx context.Context, tx pgx.Tx, bookID int64, action string, actorID int64, changes map[string]domain.BookFieldChange) error {
+	revision := domain.BookRevision{
+		BookID:  bookID,
+		Action:  action,
+		Changes: changes,
+	}
+
+	if actorID > 0 {
+		revision.ActorID = &actorID
+	}
+
+	if _, err := b.revisionRepo.Store(ctx, tx, &revision); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("book_id", bookID).Str("action", action).Msg("failed to store book revision")
+		return err
+	}
+
+	return nil
+}
+
 // Fetch implements domain.BookUseCase.
 func (b *BookUsecase) Fetch(ctx context.Context, params domain.RequestQueryParams) ([]domain.Book, int64, error) {
 	books, total, err := b.bookRepo.Fetch(ctx, params)
@@ -165,9 +589,12 @@ func (b *BookUsecase) Fetch(ctx context.Context, params domain.RequestQueryParam
 	return books, total, nil
 }
 
-func NewBookUsecase(br domain.BookRepository) domain.BookUsecase {
+func NewBookUsecase(br domain.BookRepository, rr domain.BookRevisionRepository, storage storage.Uploader, td tasks.TaskDistributor) domain.BookUsecase {
 	return &BookUsecase{
-		bookRepo: br,
+		bookRepo:        br,
+		revisionRepo:    rr,
+		storage:         storage,
+		taskDistributor: td,
 	}
 }
 
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -21,17 +22,34 @@ var (
 func (p *postgresCartRepository) HasStock(ctx context.Context, bookID int64) (bool, error) {
 	query := `SELECT in_stock
 			  FROM books
-			  WHERE id = $1;`
+			  WHERE id = $1 AND deleted_at IS NULL;`
 	var stock int64
 	err := p.conn.QueryRow(ctx, query, bookID).Scan(&stock)
 
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, ErrRecordNotFound
+		}
+
 		return false, err
 	}
 
 	return stock > 0, nil
 }
 
+// HasReadyHold implements domain.CartRepository.
+func (p *postgresCartRepository) HasReadyHold(ctx context.Context, bookID, userID int64) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM book_holds WHERE book_id = $1 AND user_id = $2 AND status = 'ready');`
+	var exists bool
+	err := p.conn.QueryRow(ctx, query, bookID, userID).Scan(&exists)
+
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
 // AddToCart implements domain.CartRepository.
 func (p *postgresCartRepository) AddToCart(ctx context.Context, cart *domain.Cart) (id int64, err error) {
 	query := `INSERT INTO carts (
@@ -59,6 +77,8 @@ func (p *postgresCartRepository) CartDetails(ctx context.Context, userID int64)
   				b.image_url,
 				CASE
 					WHEN B.in_stock > 0 THEN TRUE
+					WHEN EXISTS (SELECT 1 FROM book_holds h
+								 WHERE h.book_id = c.book_id AND h.user_id = c.user_id AND h.status = 'ready') THEN TRUE
 					ELSE FALSE
 				END AS is_available
 			  FROM 
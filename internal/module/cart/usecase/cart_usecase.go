@@ -87,6 +87,10 @@ func (c *CartUsecase) StoreCart(ctx context.Context, input *domain.StoreCartRequ
 	hasStock, err := c.cartRepo.HasStock(ctx, input.BookID)
 
 	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return 0, baseErr.NewNotFoundError("book not found")
+		}
+
 		log.Error().Err(err).Str("layer", "usecase").
 			Int64("user_id", input.UserID).
 			Int64("book_id", input.BookID).
@@ -96,6 +100,20 @@ func (c *CartUsecase) StoreCart(ctx context.Context, input *domain.StoreCartRequ
 
 	}
 
+	// a copy set aside for the user's hold is claimed when the order is created
+	if !hasStock {
+		hasStock, err = c.cartRepo.HasReadyHold(ctx, input.BookID, input.UserID)
+
+		if err != nil {
+			log.Error().Err(err).Str("layer", "usecase").
+				Int64("user_id", input.UserID).
+				Int64("book_id", input.BookID).
+				Msg("failed to check hold")
+
+			return 0, baseErr.NewInternalServerError("failed to check stock")
+		}
+	}
+
 	if !hasStock {
 		return 0, baseErr.NewConflictError("item is out of stock")
 	}
@@ -0,0 +1,91 @@
+package http
+
+import (
+	"backend-layout/internal/domain"
+	"backend-layout/internal/httpcontext"
+	"backend-layout/internal/middleware"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type FineHandler struct {
+	fineUsecase domain.FineUsecase
+}
+
+func NewFineHandler(r *echo.Group, fu domain.FineUsecase, rbac *middleware.RBACMiddleware) {
+	handler := &FineHandler{
+		fineUsecase: fu,
+	}
+
+	r.GET("/fines", handler.Outstanding)
+	r.GET("/admin/users/:id/fines", handler.UserOutstanding, rbac.RequiredPermission("fine:settle"))
+	r.POST("/admin/users/:id/fines/settle", handler.Settle, rbac.RequiredPermission("fine:settle"))
+}
+
+func (h *FineHandler) Outstanding(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
+	ctx := c.Request().Context()
+
+	resp, err := h.fineUsecase.FetchOutstanding(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// UserOutstanding lists the outstanding fines of a borrower for the desk
+func (h *FineHandler) UserOutstanding(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID format")
+	}
+
+	ctx := c.Request().Context()
+
+	resp, err := h.fineUsecase.FetchOutstanding(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// Settle records fines of a borrower as paid at the desk or waives them
+func (h *FineHandler) Settle(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID format")
+	}
+
+	req := new(domain.SettleFinesRequest)
+
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	req.UserID = id
+	req.StaffID = user.ID
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	resp, err := h.fineUsecase.Settle(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"backend-layout/internal/domain"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type postgresFineRepository struct {
+	conn *pgxpool.Pool
+}
+
+// AccrueLateFees implements domain.FineRepository. Every day after the due date up to today is charged once at the
+// daily fine of the borrower's lending policy, the running total of a loan is capped at the price its book had when
+// it was ordered. Items ordered without a price are capped at the current price of the book, a book without any
+// price is never fined.
+func (p *postgresFineRepository) AccrueLateFees(ctx context.Context, today time.Time) (int64, error) {
+	query := `INSERT INTO fines (user_id, order_detail_id, kind, amount, accrued_for)
+			  SELECT user_id, order_detail_id, 'late_fee', amount, accrued_for
+			  FROM (
+				SELECT o.user_id, od.id AS order_detail_id, d::date AS accrued_for,
+					LEAST(policy.daily_fine, COALESCE(NULLIF(od.unit_price, 0), b.price, 0) - COALESCE(charged.total, 0)
+						- policy.daily_fine * (ROW_NUMBER() OVER (PARTITION BY od.id ORDER BY d) - 1)) AS amount
+				FROM order_details od
+				JOIN orders o ON o.id = od.order_id
+				JOIN books b ON b.id = od.book_id
+				JOIN lending_policies policy ON policy.id = lending_policy_for_user(o.user_id)
+				LEFT JOIN LATERAL (
+					SELECT SUM(f.amount) AS total, MAX(f.accrued_for) AS last_day
+					FROM fines f
+					WHERE f.order_detail_id = od.id AND f.kind = 'late_fee'
+				) charged ON TRUE
+				CROSS JOIN LATERAL generate_series(
+					GREATEST(od.return_date::date, charged.last_day) + 1, $1::date, interval '1 day'
+				) d
+				WHERE od.returned_at IS NULL AND od.return_date::date < $1::date
+					AND od.book_copy_id IS NOT NULL AND o.status = 'on_loan'
+			  ) due
+			  WHERE amount > 0
+			  ON CONFLICT (order_detail_id, accrued_for) DO NOTHING;`
+
+	row, err := p.conn.Exec(ctx, query, today)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to accrue late fees: %w", err)
+	}
+
+	return row.RowsAffected(), nil
+}
+
+// OutstandingTotal implements domain.FineRepository.
+func (p *postgresFineRepository) OutstandingTotal(ctx context.Context, userID int64) (float64, error) {
+	var total float64
+
+	err := p.conn.QueryRow(ctx, `SELECT COALESCE(SUM(amount), 0) FROM fines WHERE user_id = $1 AND paid_at IS NULL;`, userID).Scan(&total)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// FetchOutstanding implements domain.FineRepository.
+func (p *postgresFineRepository) FetchOutstanding(ctx context.Context, userID int64) ([]domain.FineWithBook, error) {
+	query := `SELECT f.id, f.user_id, f.order_detail_id, f.kind, f.amount, f.accrued_for, f.paid_at, f.created_at,
+				od.order_id, od.book_id, b.title
+			  FROM fines f
+			  JOIN order_details od ON od.id = f.order_detail_id
+			  JOIN books b ON b.id = od.book_id
+			  WHERE f.user_id = $1 AND f.paid_at IS NULL
+			  ORDER BY f.accrued_for, f.id;`
+
+	rows, err := p.conn.Query(ctx, query, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := make([]domain.FineWithBook, 0)
+
+	for rows.Next() {
+		f := domain.FineWithBook{}
+
+		err := rows.Scan(&f.Id, &f.UserID, &f.OrderDetailID, &f.Kind, &f.Amount, &f.AccruedFor, &f.PaidAt, &f.CreatedAt,
+			&f.OrderId, &f.BookId, &f.BookTitle)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, f)
+	}
+
+	return result, rows.Err()
+}
+
+// Settle implements domain.FineRepository.
+func (p *postgresFineRepository) Settle(ctx context.Context, userID int64, fineIDs []int64, settlement string, staffID int64) (settled int, amount float64, err error) {
+	query := `WITH settled AS (
+				UPDATE fines SET paid_at = now(), settlement = $3, settled_by = $4
+				WHERE user_id = $1 AND paid_at IS NULL AND (cardinality($2::INT[]) = 0 OR id = ANY($2))
+				RETURNING amount
+			  )
+			  SELECT COUNT(1), COALESCE(SUM(amount), 0) FROM settled;`
+
+	if fineIDs == nil {
+		fineIDs = []int64{}
+	}
+
+	err = p.conn.QueryRow(ctx, query, userID, fineIDs, settlement, staffID).Scan(&settled, &amount)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to settle fines: %w", err)
+	}
+
+	return settled, amount, nil
+}
+
+func NewPostgresFineRepository(conn *pgxpool.Pool) domain.FineRepository {
+	return &postgresFineRepository{
+		conn: conn,
+	}
+}
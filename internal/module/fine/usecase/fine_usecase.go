@@ -0,0 +1,62 @@
+package usecase
+
+import (
+	baseErr "backend-layout/internal/adapter/errors"
+	"backend-layout/internal/domain"
+	"context"
+
+	"github.com/rs/zerolog/log"
+)
+
+type FineUsecase struct {
+	fineRepo domain.FineRepository
+}
+
+// FetchOutstanding implements domain.FineUsecase.
+func (f *FineUsecase) FetchOutstanding(ctx context.Context, userID int64) (domain.OutstandingFinesResponse, error) {
+	fines, err := f.fineRepo.FetchOutstanding(ctx, userID)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("user_id", userID).Msg("failed to get outstanding fines")
+
+		return domain.OutstandingFinesResponse{}, baseErr.NewInternalServerError("failed to get fines")
+	}
+
+	resp := domain.OutstandingFinesResponse{Fines: make([]domain.FineResponse, 0, len(fines))}
+
+	for _, v := range fines {
+		resp.Total += v.Amount
+		resp.Fines = append(resp.Fines, domain.FineToResponse(&v))
+	}
+
+	return resp, nil
+}
+
+// Settle implements domain.FineUsecase.
+func (f *FineUsecase) Settle(ctx context.Context, input *domain.SettleFinesRequest) (domain.SettleFinesResponse, error) {
+	settled, amount, err := f.fineRepo.Settle(ctx, input.UserID, input.FineIDs, input.Settlement, input.StaffID)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("user_id", input.UserID).Msg("failed to settle fines")
+
+		return domain.SettleFinesResponse{}, baseErr.NewInternalServerError("failed to settle fines")
+	}
+
+	if settled == 0 {
+		return domain.SettleFinesResponse{}, baseErr.NewNotFoundError("no outstanding fines to settle")
+	}
+
+	outstanding, err := f.fineRepo.OutstandingTotal(ctx, input.UserID)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("user_id", input.UserID).Msg("failed to get outstanding fines")
+
+		return domain.SettleFinesResponse{}, baseErr.NewInternalServerError("failed to get fines")
+	}
+
+	log.Info().Str("layer", "usecase").Int64("user_id", input.UserID).Int64("staff_id", input.StaffID).Str("settlement", input.Settlement).
+		Int("settled", settled).Float64("amount", amount).Msg("fines settled")
+
+	return domain.SettleFinesResponse{Settled: settled, Amount: amount, Outstanding: outstanding}, nil
+}
+
+func NewFineUsecase(fineRepo domain.FineRepository) domain.FineUsecase {
+	return &FineUsecase{fineRepo: fineRepo}
+}
@@ -0,0 +1,81 @@
+package http
+
+import (
+	"backend-layout/internal/domain"
+	"backend-layout/internal/httpcontext"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type BookHoldHandler struct {
+	holdUsecase domain.BookHoldUsecase
+}
+
+func NewBookHoldHandler(r *echo.Group, hu domain.BookHoldUsecase) {
+	handler := &BookHoldHandler{
+		holdUsecase: hu,
+	}
+
+	r.GET("/holds", handler.List)
+	r.POST("/books/:id/holds", handler.Store)
+	r.DELETE("/holds/:id", handler.Cancel)
+}
+
+func (h *BookHoldHandler) List(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
+	ctx := c.Request().Context()
+
+	resp, err := h.holdUsecase.Fetch(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *BookHoldHandler) Store(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid book ID format")
+	}
+
+	ctx := c.Request().Context()
+
+	resp, err := h.holdUsecase.Store(ctx, id, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, echo.Map{"message": "hold placed successfully", "data": resp})
+}
+
+func (h *BookHoldHandler) Cancel(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid hold ID format")
+	}
+
+	ctx := c.Request().Context()
+
+	if err := h.holdUsecase.Cancel(ctx, id, user.ID); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "hold cancelled successfully"})
+}
@@ -0,0 +1,335 @@
+package repository
+
+import (
+	"backend-layout/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrHoldNotFound  = errors.New("hold not found")
+	ErrHoldDuplicate = errors.New("duplicate entry: user already holds this book")
+)
+
+type postgresBookHoldRepository struct {
+	conn *pgxpool.Pool
+}
+
+const bookHoldColumns = `h.id, h.book_id, h.user_id, h.status, h.book_copy_id, h.ready_at, h.expires_at, h.created_at, h.updated_at`
+
+// bookHoldWithBookColumns adds the title and, for waiting holds, the place in the queue
+const bookHoldWithBookColumns = bookHoldColumns + `, b.title,
+				CASE WHEN h.status = 'waiting' THEN
+					(SELECT COUNT(1) FROM book_holds w
+					 WHERE w.book_id = h.book_id AND w.status = 'waiting' AND (w.created_at, w.id) <= (h.created_at, h.id))
+				END`
+
+func scanBookHoldWithBook(row pgx.Row) (*domain.BookHoldWithBook, error) {
+	var h domain.BookHoldWithBook
+
+	err := row.Scan(&h.Id, &h.BookID, &h.UserID, &h.Status, &h.BookCopyID, &h.ReadyAt, &h.ExpiresAt, &h.CreatedAt, &h.UpdatedAt,
+		&h.BookTitle, &h.Position)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrHoldNotFound
+		}
+
+		return nil, err
+	}
+
+	return &h, nil
+}
+
+// GetTx implements domain.BookHoldRepository.
+func (p *postgresBookHoldRepository) GetTx(ctx context.Context) (pgx.Tx, error) {
+	tx, err := p.conn.Begin(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// Store implements domain.BookHoldRepository.
+func (p *postgresBookHoldRepository) Store(ctx context.Context, tx pgx.Tx, hold *domain.BookHold) (id int64, err error) {
+	query := `INSERT INTO book_holds (book_id, user_id, status) VALUES ($1, $2, $3) RETURNING id;`
+
+	err = tx.QueryRow(ctx, query, hold.BookID, hold.UserID, domain.HoldWaiting).Scan(&id)
+
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
+			return 0, ErrHoldDuplicate
+		}
+
+		return 0, fmt.Errorf("failed to insert hold: %w", err)
+	}
+
+	return
+}
+
+// GetForUpdate implements domain.BookHoldRepository.
+func (p *postgresBookHoldRepository) GetForUpdate(ctx context.Context, tx pgx.Tx, id int64) (*domain.BookHold, error) {
+	query := `SELECT ` + bookHoldColumns + ` FROM book_holds h WHERE h.id = $1 FOR UPDATE;`
+
+	var h domain.BookHold
+
+	err := tx.QueryRow(ctx, query, id).Scan(&h.Id, &h.BookID, &h.UserID, &h.Status, &h.BookCopyID, &h.ReadyAt, &h.ExpiresAt,
+		&h.CreatedAt, &h.UpdatedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrHoldNotFound
+		}
+
+		return nil, err
+	}
+
+	return &h, nil
+}
+
+// GetWithBook implements domain.BookHoldRepository.
+func (p *postgresBookHoldRepository) GetWithBook(ctx context.Context, id int64) (*domain.BookHoldWithBook, error) {
+	query := `SELECT ` + bookHoldWithBookColumns + `
+			  FROM book_holds h
+			  JOIN books b ON b.id = h.book_id
+			  WHERE h.id = $1;`
+
+	return scanBookHoldWithBook(p.conn.QueryRow(ctx, query, id))
+}
+
+// FetchActiveByUserID implements domain.BookHoldRepository.
+func (p *postgresBookHoldRepository) FetchActiveByUserID(ctx context.Context, userID int64) ([]domain.BookHoldWithBook, error) {
+	query := `SELECT ` + bookHoldWithBookColumns + `
+			  FROM book_holds h
+			  JOIN books b ON b.id = h.book_id
+			  WHERE h.user_id = $1 AND h.status IN ('waiting', 'ready')
+			  ORDER BY h.created_at, h.id;`
+
+	rows, err := p.conn.Query(ctx, query, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := make([]domain.BookHoldWithBook, 0)
+
+	for rows.Next() {
+		h, err := scanBookHoldWithBook(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, *h)
+	}
+
+	return result, rows.Err()
+}
+
+// SetStatus implements domain.BookHoldRepository.
+func (p *postgresBookHoldRepository) SetStatus(ctx context.Context, tx pgx.Tx, id int64, status string) error {
+	row, err := tx.Exec(ctx, `UPDATE book_holds SET status = $1, updated_at = now() WHERE id = $2;`, status, id)
+
+	if err != nil {
+		return err
+	}
+
+	if row.RowsAffected() == 0 {
+		return ErrHoldNotFound
+	}
+
+	return nil
+}
+
+// HasOthersQueued implements domain.BookHoldRepository.
+func (p *postgresBookHoldRepository) HasOthersQueued(ctx context.Context, tx pgx.Tx, bookID, userID int64) (bool, error) {
+	query := `SELECT EXISTS (
+				SELECT 1 FROM book_holds
+				WHERE book_id = $1 AND user_id <> $2 AND status IN ('waiting', 'ready')
+			  );`
+
+	var exists bool
+
+	if err := tx.QueryRow(ctx, query, bookID, userID).Scan(&exists); err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// Claim implements domain.BookHoldRepository.
+func (p *postgresBookHoldRepository) Claim(ctx context.Context, tx pgx.Tx, userID, bookID int64) (bookCopyID int64, err error) {
+	query := `UPDATE book_holds
+			  SET status = 'claimed', updated_at = now()
+			  WHERE user_id = $1 AND book_id = $2 AND status = 'ready'
+			  RETURNING book_copy_id;`
+
+	err = tx.QueryRow(ctx, query, userID, bookID).Scan(&bookCopyID)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrHoldNotFound
+		}
+
+		return 0, err
+	}
+
+	return
+}
+
+// Offer implements domain.BookHoldRepository. The caller holds the lock on the book so the queue and the shelf
+// cannot change underneath.
+func (p *postgresBookHoldRepository) Offer(ctx context.Context, tx pgx.Tx, bookID, limit int64, expiresAt time.Time) ([]domain.BookHoldOffer, error) {
+	holdIDs, err := p.collectIDs(ctx, tx, `SELECT id FROM book_holds
+			  WHERE book_id = $1 AND status = 'waiting'
+			  ORDER BY created_at, id
+			  LIMIT $2
+			  FOR UPDATE;`, bookID, limit)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get waiting holds: %w", err)
+	}
+
+	copyIDs, err := p.collectIDs(ctx, tx, `SELECT id FROM book_copies
+			  WHERE book_id = $1 AND status = 'available'
+			  ORDER BY id
+			  LIMIT $2
+			  FOR UPDATE;`, bookID, int64(len(holdIDs)))
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get available copies: %w", err)
+	}
+
+	offered := make([]int64, 0, len(copyIDs))
+
+	for i, copyID := range copyIDs {
+		_, err := tx.Exec(ctx, `UPDATE book_copies SET status = 'on_hold', updated_at = now() WHERE id = $1;`, copyID)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to set aside book copy: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `UPDATE book_holds
+				  SET status = 'ready', book_copy_id = $1, ready_at = now(), expires_at = $2, updated_at = now()
+				  WHERE id = $3;`, copyID, expiresAt, holdIDs[i])
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to mark hold ready: %w", err)
+		}
+
+		offered = append(offered, holdIDs[i])
+	}
+
+	if len(offered) == 0 {
+		return []domain.BookHoldOffer{}, nil
+	}
+
+	query := `SELECT h.id, u.id, u.email, u.name, b.title, h.expires_at
+			  FROM book_holds h
+			  JOIN users u ON u.id = h.user_id
+			  JOIN books b ON b.id = h.book_id
+			  WHERE h.id = ANY($1)
+			  ORDER BY h.created_at, h.id;`
+
+	rows, err := tx.Query(ctx, query, offered)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := make([]domain.BookHoldOffer, 0, len(offered))
+
+	for rows.Next() {
+		o := domain.BookHoldOffer{}
+
+		if err := rows.Scan(&o.HoldID, &o.UserID, &o.Email, &o.Name, &o.BookTitle, &o.ExpiresAt); err != nil {
+			return nil, err
+		}
+
+		result = append(result, o)
+	}
+
+	return result, rows.Err()
+}
+
+// ExpireReady implements domain.BookHoldRepository. released is not read but postgres runs every data modifying
+// statement in a WITH to completion.
+func (p *postgresBookHoldRepository) ExpireReady(ctx context.Context, now time.Time) (bookIDs []int64, err error) {
+	query := `WITH expired AS (
+				UPDATE book_holds
+				SET status = 'expired', updated_at = now()
+				WHERE status = 'ready' AND expires_at < $1
+				RETURNING book_id, book_copy_id
+			  ), released AS (
+				UPDATE book_copies c
+				SET status = 'available', updated_at = now()
+				FROM expired e
+				WHERE c.id = e.book_copy_id AND c.status = 'on_hold'
+				RETURNING c.book_id
+			  )
+			  SELECT DISTINCT book_id FROM expired;`
+
+	rows, err := p.conn.Query(ctx, query, now)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	bookIDs = make([]int64, 0)
+
+	for rows.Next() {
+		var id int64
+
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		bookIDs = append(bookIDs, id)
+	}
+
+	return bookIDs, rows.Err()
+}
+
+func (p *postgresBookHoldRepository) collectIDs(ctx context.Context, tx pgx.Tx, query string, args ...any) ([]int64, error) {
+	rows, err := tx.Query(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ids := make([]int64, 0)
+
+	for rows.Next() {
+		var id int64
+
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func NewPostgresBookHoldRepository(conn *pgxpool.Pool) domain.BookHoldRepository {
+	return &postgresBookHoldRepository{
+		conn: conn,
+	}
+}
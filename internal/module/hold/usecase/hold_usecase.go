@@ -0,0 +1,298 @@
+package usecase
+
+import (
+	baseErr "backend-layout/internal/adapter/errors"
+	"backend-layout/internal/domain"
+	bookRepository "backend-layout/internal/module/book/repository"
+	"backend-layout/internal/module/hold/repository"
+	"backend-layout/internal/tasks"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+type BookHoldUsecase struct {
+	holdRepo        domain.BookHoldRepository
+	copyRepo        domain.BookCopyRepository
+	bookRepo        domain.BookRepository
+	taskDistributor tasks.TaskDistributor
+	// pickupWindow is how long a ready hold keeps its copy before it passes to the next user
+	pickupWindow time.Duration
+}
+
+// Fetch implements domain.BookHoldUsecase.
+func (h *BookHoldUsecase) Fetch(ctx context.Context, userID int64) ([]domain.BookHoldResponse, error) {
+	holds, err := h.holdRepo.FetchActiveByUserID(ctx, userID)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("user_id", userID).Msg("failed to get holds")
+
+		return nil, baseErr.NewInternalServerError("failed to get holds")
+	}
+
+	resp := make([]domain.BookHoldResponse, 0, len(holds))
+
+	for _, v := range holds {
+		resp = append(resp, domain.BookHoldToResponse(&v))
+	}
+
+	return resp, nil
+}
+
+// Store implements domain.BookHoldUsecase.
+func (h *BookHoldUsecase) Store(ctx context.Context, bookID, userID int64) (resp domain.BookHoldResponse, err error) {
+	tx, err := h.holdRepo.GetTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to begin transaction")
+		return resp, baseErr.NewInternalServerError("failed to place hold")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	book, err := h.bookRepo.GetForUpdate(ctx, tx, bookID)
+	if err != nil {
+		if errors.Is(err, bookRepository.ErrBookNotFound) {
+			return resp, baseErr.NewNotFoundError("book not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("book_id", bookID).Msg("failed to get book")
+
+		return resp, baseErr.NewInternalServerError("failed to place hold")
+	}
+
+	if book.DeletedAt != nil {
+		return resp, baseErr.NewNotFoundError("book not found")
+	}
+
+	stock, err := h.copyRepo.RefreshStock(ctx, tx, bookID)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("book_id", bookID).Msg("failed to refresh book stock")
+
+		return resp, baseErr.NewInternalServerError("failed to place hold")
+	}
+
+	if stock.After > 0 {
+		return resp, baseErr.NewConflictError("book is in stock, add it to your cart instead")
+	}
+
+	id, err := h.holdRepo.Store(ctx, tx, &domain.BookHold{BookID: bookID, UserID: userID})
+	if err != nil {
+		if errors.Is(err, repository.ErrHoldDuplicate) {
+			return resp, baseErr.NewConflictError("you already hold this book")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("book_id", bookID).Int64("user_id", userID).Msg("failed to store hold")
+
+		return resp, baseErr.NewInternalServerError("failed to place hold")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to commit transaction")
+
+		return resp, baseErr.NewInternalServerError("failed to place hold")
+	}
+
+	hold, err := h.holdRepo.GetWithBook(ctx, id)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("hold_id", id).Msg("failed to get hold")
+
+		return resp, baseErr.NewInternalServerError("failed to get hold")
+	}
+
+	return domain.BookHoldToResponse(hold), nil
+}
+
+// Cancel implements domain.BookHoldUsecase.
+func (h *BookHoldUsecase) Cancel(ctx context.Context, id, userID int64) (err error) {
+	tx, err := h.holdRepo.GetTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to begin transaction")
+		return baseErr.NewInternalServerError("failed to cancel hold")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	hold, err := h.holdRepo.GetForUpdate(ctx, tx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrHoldNotFound) {
+			return baseErr.NewNotFoundError("hold not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("hold_id", id).Msg("failed to get hold")
+
+		return baseErr.NewInternalServerError("failed to cancel hold")
+	}
+
+	if hold.UserID != userID {
+		return baseErr.NewNotFoundError("hold not found")
+	}
+
+	if hold.Status != domain.HoldWaiting && hold.Status != domain.HoldReady {
+		return baseErr.NewConflictError(fmt.Sprintf("hold is already %s", hold.Status))
+	}
+
+	if err = h.holdRepo.SetStatus(ctx, tx, id, domain.HoldCancelled); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("hold_id", id).Msg("failed to cancel hold")
+
+		return baseErr.NewInternalServerError("failed to cancel hold")
+	}
+
+	// the copy set aside for a ready hold goes back on the shelf, ProcessQueue passes it on
+	if hold.Status == domain.HoldReady && hold.BookCopyID != nil {
+		if err = h.copyRepo.SetStatus(ctx, tx, *hold.BookCopyID, domain.BookCopyAvailable); err != nil {
+			log.Error().Err(err).Str("layer", "usecase").Int64("copy_id", *hold.BookCopyID).Msg("failed to release book copy")
+
+			return baseErr.NewInternalServerError("failed to cancel hold")
+		}
+	}
+
+	stock, err := h.copyRepo.RefreshStock(ctx, tx, hold.BookID)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("book_id", hold.BookID).Msg("failed to refresh book stock")
+
+		return baseErr.NewInternalServerError("failed to cancel hold")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to commit transaction")
+
+		return baseErr.NewInternalServerError("failed to cancel hold")
+	}
+
+	tasks.StockChanged(ctx, h.taskDistributor, hold.BookID, stock)
+
+	return nil
+}
+
+// ProcessQueue implements domain.BookHoldUsecase.
+func (h *BookHoldUsecase) ProcessQueue(ctx context.Context, bookID int64) (err error) {
+	tx, err := h.holdRepo.GetTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	// the recount locks the book, nothing else can take or free a copy until we commit
+	stock, err := h.copyRepo.RefreshStock(ctx, tx, bookID)
+	if err != nil {
+		if errors.Is(err, bookRepository.ErrBookNotFound) {
+			log.Warn().Str("layer", "usecase").Int64("book_id", bookID).Msg("book of hold queue no longer exists")
+
+			return nil
+		}
+
+		return fmt.Errorf("failed to refresh book stock: %w", err)
+	}
+
+	before := stock.Before
+
+	offers := []domain.BookHoldOffer{}
+
+	if stock.HasHoldsToOffer() {
+		offers, err = h.holdRepo.Offer(ctx, tx, bookID, min(stock.Available-stock.Reserved, stock.Waiting), time.Now().Add(h.pickupWindow))
+		if err != nil {
+			return fmt.Errorf("failed to offer copies to holds: %w", err)
+		}
+
+		if stock, err = h.copyRepo.RefreshStock(ctx, tx, bookID); err != nil {
+			return fmt.Errorf("failed to refresh book stock: %w", err)
+		}
+
+		stock.Before = before
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for _, o := range offers {
+		err := h.taskDistributor.DistributeTaskSendNotification(ctx, &tasks.PayloadSendNotification{
+			Email:   o.Email,
+			Name:    o.Name,
+			Subject: "Your hold is ready",
+			Message: fmt.Sprintf("A copy of %q is waiting for you. Borrow it before %s or it passes to the next reader in line.",
+				o.BookTitle, o.ExpiresAt.Format("2 Jan 2006 15:04 MST")),
+		})
+
+		if err != nil {
+			log.Error().Err(err).Str("layer", "usecase").Int64("hold_id", o.HoldID).Msg("failed to enqueue hold ready notification")
+		}
+	}
+
+	if stock.Restocked() {
+		tasks.NotifyBookRestocked(ctx, h.taskDistributor, bookID)
+	}
+
+	log.Info().Str("layer", "usecase").Int64("book_id", bookID).Int("offered", len(offers)).Msg("hold queue processed")
+
+	return nil
+}
+
+// ExpireReady implements domain.BookHoldUsecase.
+func (h *BookHoldUsecase) ExpireReady(ctx context.Context) error {
+	bookIDs, err := h.holdRepo.ExpireReady(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to expire holds: %w", err)
+	}
+
+	// the expired holds are committed already, a book that fails here is picked up again the next time its
+	// stock changes
+	for _, bookID := range bookIDs {
+		if err := h.ProcessQueue(ctx, bookID); err != nil {
+			log.Error().Err(err).Str("layer", "usecase").Int64("book_id", bookID).Msg("failed to process hold queue")
+		}
+	}
+
+	log.Info().Str("layer", "usecase").Int("books", len(bookIDs)).Msg("expired holds released")
+
+	return nil
+}
+
+func NewBookHoldUsecase(holdRepo domain.BookHoldRepository, copyRepo domain.BookCopyRepository, bookRepo domain.BookRepository, taskDistributor tasks.TaskDistributor, pickupWindow time.Duration) domain.BookHoldUsecase {
+	return &BookHoldUsecase{
+		holdRepo:        holdRepo,
+		copyRepo:        copyRepo,
+		bookRepo:        bookRepo,
+		taskDistributor: taskDistributor,
+		pickupWindow:    pickupWindow,
+	}
+}
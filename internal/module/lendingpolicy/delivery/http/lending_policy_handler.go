@@ -0,0 +1,118 @@
+package http
+
+import (
+	"backend-layout/internal/domain"
+	"backend-layout/internal/httpcontext"
+	"backend-layout/internal/middleware"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type LendingPolicyHandler struct {
+	lendingPolicyUsecase domain.LendingPolicyUsecase
+}
+
+func NewLendingPolicyHandler(r *echo.Group, lu domain.LendingPolicyUsecase, rbac *middleware.RBACMiddleware) {
+	handler := &LendingPolicyHandler{
+		lendingPolicyUsecase: lu,
+	}
+
+	r.GET("/lending-policies/me", handler.Mine)
+	r.GET("/lending-policies", handler.List, rbac.RequiredPermission("lending_policy:manage"))
+	r.POST("/lending-policies", handler.Store, rbac.RequiredPermission("lending_policy:manage"))
+	r.PATCH("/lending-policies/:id", handler.Update, rbac.RequiredPermission("lending_policy:manage"))
+	r.DELETE("/lending-policies/:id", handler.Delete, rbac.RequiredPermission("lending_policy:manage"))
+}
+
+func (h *LendingPolicyHandler) Mine(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
+	ctx := c.Request().Context()
+
+	resp, err := h.lendingPolicyUsecase.GetForUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *LendingPolicyHandler) List(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	resp, err := h.lendingPolicyUsecase.Fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *LendingPolicyHandler) Store(c echo.Context) error {
+	req := new(domain.StoreLendingPolicyRequest)
+
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	resp, err := h.lendingPolicyUsecase.Store(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, echo.Map{"message": "lending policy created successfully", "data": resp})
+}
+
+func (h *LendingPolicyHandler) Update(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid lending policy ID format")
+	}
+
+	req := new(domain.UpdateLendingPolicyRequest)
+
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	req.ID = id
+
+	ctx := c.Request().Context()
+
+	resp, err := h.lendingPolicyUsecase.Update(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "lending policy updated successfully", "data": resp})
+}
+
+func (h *LendingPolicyHandler) Delete(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid lending policy ID format")
+	}
+
+	ctx := c.Request().Context()
+
+	if err := h.lendingPolicyUsecase.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "lending policy deleted successfully"})
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"backend-layout/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrLendingPolicyNotFound  = errors.New("lending policy not found")
+	ErrLendingPolicyDuplicate = errors.New("duplicate entry: role already has a lending policy")
+	ErrRoleNotFound           = errors.New("role not found")
+)
+
+type postgresLendingPolicyRepository struct {
+	conn *pgxpool.Pool
+}
+
+const lendingPolicyColumns = `lp.id, lp.role_id, r.name, lp.priority, lp.max_loans, lp.loan_days, lp.max_renewals,
+				lp.daily_fine, lp.price_multiplier, lp.created_at, lp.updated_at`
+
+func scanLendingPolicy(row pgx.Row) (*domain.LendingPolicy, error) {
+	var lp domain.LendingPolicy
+
+	err := row.Scan(&lp.Id, &lp.RoleID, &lp.RoleName, &lp.Priority, &lp.MaxLoans, &lp.LoanDays, &lp.MaxRenewals,
+		&lp.DailyFine, &lp.PriceMultiplier, &lp.CreatedAt, &lp.UpdatedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrLendingPolicyNotFound
+		}
+
+		return nil, err
+	}
+
+	return &lp, nil
+}
+
+// Fetch implements domain.LendingPolicyRepository.
+func (p *postgresLendingPolicyRepository) Fetch(ctx context.Context) ([]domain.LendingPolicy, error) {
+	query := `SELECT ` + lendingPolicyColumns + `
+			  FROM lending_policies lp
+			  LEFT JOIN roles r ON r.id = lp.role_id
+			  ORDER BY lp.role_id NULLS FIRST, lp.id;`
+
+	rows, err := p.conn.Query(ctx, query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := make([]domain.LendingPolicy, 0)
+
+	for rows.Next() {
+		lp, err := scanLendingPolicy(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, *lp)
+	}
+
+	return result, rows.Err()
+}
+
+// GetByID implements domain.LendingPolicyRepository.
+func (p *postgresLendingPolicyRepository) GetByID(ctx context.Context, id int64) (*domain.LendingPolicy, error) {
+	query := `SELECT ` + lendingPolicyColumns + `
+			  FROM lending_policies lp
+			  LEFT JOIN roles r ON r.id = lp.role_id
+			  WHERE lp.id = $1;`
+
+	return scanLendingPolicy(p.conn.QueryRow(ctx, query, id))
+}
+
+// Store implements domain.LendingPolicyRepository.
+func (p *postgresLendingPolicyRepository) Store(ctx context.Context, policy *domain.LendingPolicy) (id int64, err error) {
+	query := `INSERT INTO lending_policies (role_id, priority, max_loans, loan_days, max_renewals, daily_fine, price_multiplier)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7)
+			  RETURNING id;`
+
+	err = p.conn.QueryRow(ctx, query, policy.RoleID, policy.Priority, policy.MaxLoans, policy.LoanDays, policy.MaxRenewals,
+		policy.DailyFine, policy.PriceMultiplier).Scan(&id)
+
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok {
+			switch pgErr.Code {
+			case "23505":
+				return 0, ErrLendingPolicyDuplicate
+			case "23503":
+				return 0, ErrRoleNotFound
+			}
+		}
+
+		return 0, fmt.Errorf("failed to insert lending policy: %w", err)
+	}
+
+	return
+}
+
+// Update implements domain.LendingPolicyRepository.
+func (p *postgresLendingPolicyRepository) Update(ctx context.Context, policy *domain.LendingPolicy) error {
+	query := `UPDATE lending_policies
+			  SET priority = $1, max_loans = $2, loan_days = $3, max_renewals = $4, daily_fine = $5,
+				price_multiplier = $6, updated_at = now()
+			  WHERE id = $7;`
+
+	row, err := p.conn.Exec(ctx, query, policy.Priority, policy.MaxLoans, policy.LoanDays, policy.MaxRenewals,
+		policy.DailyFine, policy.PriceMultiplier, policy.Id)
+
+	if err != nil {
+		return fmt.Errorf("failed to update lending policy: %w", err)
+	}
+
+	if row.RowsAffected() == 0 {
+		return ErrLendingPolicyNotFound
+	}
+
+	return nil
+}
+
+// Delete implements domain.LendingPolicyRepository. The default policy is never removed.
+func (p *postgresLendingPolicyRepository) Delete(ctx context.Context, id int64) error {
+	row, err := p.conn.Exec(ctx, `DELETE FROM lending_policies WHERE id = $1 AND role_id IS NOT NULL;`, id)
+
+	if err != nil {
+		return fmt.Errorf("failed to delete lending policy: %w", err)
+	}
+
+	if row.RowsAffected() == 0 {
+		return ErrLendingPolicyNotFound
+	}
+
+	return nil
+}
+
+// ResolveForUser implements domain.LendingPolicyRepository. The choice is made by the lending_policy_for_user
+// function, late fees are charged by the same.
+func (p *postgresLendingPolicyRepository) ResolveForUser(ctx context.Context, userID int64) (*domain.LendingPolicy, error) {
+	query := `SELECT ` + lendingPolicyColumns + `
+			  FROM lending_policies lp
+			  LEFT JOIN roles r ON r.id = lp.role_id
+			  WHERE lp.id = lending_policy_for_user($1);`
+
+	return scanLendingPolicy(p.conn.QueryRow(ctx, query, userID))
+}
+
+func NewPostgresLendingPolicyRepository(conn *pgxpool.Pool) domain.LendingPolicyRepository {
+	return &postgresLendingPolicyRepository{
+		conn: conn,
+	}
+}
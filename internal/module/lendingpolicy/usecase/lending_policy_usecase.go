@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	baseErr "backend-layout/internal/adapter/errors"
+	"backend-layout/internal/domain"
+	"backend-layout/internal/module/lendingpolicy/repository"
+	"context"
+	"errors"
+
+	"github.com/rs/zerolog/log"
+)
+
+type LendingPolicyUsecase struct {
+	policyRepo domain.LendingPolicyRepository
+}
+
+// Fetch implements domain.LendingPolicyUsecase.
+func (l *LendingPolicyUsecase) Fetch(ctx context.Context) ([]domain.LendingPolicyResponse, error) {
+	policies, err := l.policyRepo.Fetch(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to get lending policies")
+
+		return nil, baseErr.NewInternalServerError("failed to get lending policies")
+	}
+
+	result := make([]domain.LendingPolicyResponse, len(policies))
+
+	for i := range policies {
+		result[i] = domain.LendingPolicyToResponse(&policies[i])
+	}
+
+	return result, nil
+}
+
+// Store implements domain.LendingPolicyUsecase.
+func (l *LendingPolicyUsecase) Store(ctx context.Context, input *domain.StoreLendingPolicyRequest) (domain.LendingPolicyResponse, error) {
+	policy := domain.LendingPolicy{
+		RoleID:          &input.RoleID,
+		Priority:        input.Priority,
+		MaxLoans:        input.MaxLoans,
+		LoanDays:        input.LoanDays,
+		MaxRenewals:     input.MaxRenewals,
+		DailyFine:       input.DailyFine,
+		PriceMultiplier: input.PriceMultiplier,
+	}
+
+	id, err := l.policyRepo.Store(ctx, &policy)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrLendingPolicyDuplicate):
+			return domain.LendingPolicyResponse{}, baseErr.NewConflictError("role already has a lending policy")
+		case errors.Is(err, repository.ErrRoleNotFound):
+			return domain.LendingPolicyResponse{}, baseErr.NewNotFoundError("role not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("role_id", input.RoleID).Msg("failed to store lending policy")
+
+		return domain.LendingPolicyResponse{}, baseErr.NewInternalServerError("failed to store lending policy")
+	}
+
+	return l.get(ctx, id)
+}
+
+// Update implements domain.LendingPolicyUsecase.
+func (l *LendingPolicyUsecase) Update(ctx context.Context, input *domain.UpdateLendingPolicyRequest) (domain.LendingPolicyResponse, error) {
+	policy := domain.LendingPolicy{
+		Id:              input.ID,
+		Priority:        input.Priority,
+		MaxLoans:        input.MaxLoans,
+		LoanDays:        input.LoanDays,
+		MaxRenewals:     input.MaxRenewals,
+		DailyFine:       input.DailyFine,
+		PriceMultiplier: input.PriceMultiplier,
+	}
+
+	if err := l.policyRepo.Update(ctx, &policy); err != nil {
+		if errors.Is(err, repository.ErrLendingPolicyNotFound) {
+			return domain.LendingPolicyResponse{}, baseErr.NewNotFoundError("lending policy not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("lending_policy_id", input.ID).Msg("failed to update lending policy")
+
+		return domain.LendingPolicyResponse{}, baseErr.NewInternalServerError("failed to update lending policy")
+	}
+
+	return l.get(ctx, input.ID)
+}
+
+// Delete implements domain.LendingPolicyUsecase. Users of the role fall back to the default policy.
+func (l *LendingPolicyUsecase) Delete(ctx context.Context, id int64) error {
+	policy, err := l.policyRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrLendingPolicyNotFound) {
+			return baseErr.NewNotFoundError("lending policy not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("lending_policy_id", id).Msg("failed to get lending policy")
+
+		return baseErr.NewInternalServerError("failed to delete lending policy")
+	}
+
+	if policy.RoleID == nil {
+		return baseErr.NewBadRequestError("the default lending policy can not be deleted")
+	}
+
+	if err := l.policyRepo.Delete(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrLendingPolicyNotFound) {
+			return baseErr.NewNotFoundError("lending policy not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("lending_policy_id", id).Msg("failed to delete lending policy")
+
+		return baseErr.NewInternalServerError("failed to delete lending policy")
+	}
+
+	return nil
+}
+
+// GetForUser implements domain.LendingPolicyUsecase.
+func (l *LendingPolicyUsecase) GetForUser(ctx context.Context, userID int64) (domain.LendingPolicyResponse, error) {
+	policy, err := l.policyRepo.ResolveForUser(ctx, userID)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("user_id", userID).Msg("failed to resolve lending policy")
+
+		return domain.LendingPolicyResponse{}, baseErr.NewInternalServerError("failed to get lending policy")
+	}
+
+	return domain.LendingPolicyToResponse(policy), nil
+}
+
+func (l *LendingPolicyUsecase) get(ctx context.Context, id int64) (domain.LendingPolicyResponse, error) {
+	policy, err := l.policyRepo.GetByID(ctx, id)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("lending_policy_id", id).Msg("failed to get lending policy")
+
+		return domain.LendingPolicyResponse{}, baseErr.NewInternalServerError("failed to get lending policy")
+	}
+
+	return domain.LendingPolicyToResponse(policy), nil
+}
+
+func NewLendingPolicyUsecase(policyRepo domain.LendingPolicyRepository) domain.LendingPolicyUsecase {
+	return &LendingPolicyUsecase{policyRepo: policyRepo}
+}
@@ -3,6 +3,7 @@ package http
 import (
 	"backend-layout/internal/domain"
 	"backend-layout/internal/httpcontext"
+	"backend-layout/internal/middleware"
 	"net/http"
 	"strconv"
 
@@ -13,14 +14,20 @@ type OrderHandler struct {
 	orderUsecase domain.OrderUsecase
 }
 
-func NewOrderHandler(r *echo.Group, ou domain.OrderUsecase) {
+func NewOrderHandler(r *echo.Group, ou domain.OrderUsecase, rbac *middleware.RBACMiddleware, idempotency *middleware.IdempotencyMiddleware) {
 	h := &OrderHandler{
 		orderUsecase: ou,
 	}
 
-	r.POST("/orders", h.CreateOrder)
+	r.POST("/orders", h.CreateOrder, idempotency.Idempotent())
 	r.GET("/orders", h.ListOrders)
 	r.GET("/orders/:id", h.GetOrder)
+	r.POST("/orders/:id/cancel", h.Cancel, idempotency.Idempotent())
+	r.POST("/orders/:id/ready", h.MarkReadyForPickup, rbac.RequiredPermission("order:fulfill"))
+	r.POST("/orders/:id/fulfill", h.Fulfill, rbac.RequiredPermission("order:fulfill"))
+	r.POST("/orders/check-in", h.CheckIn, rbac.RequiredPermission("order:checkin"))
+	r.POST("/orders/:id/items/:detailId/renew", h.Renew)
+	r.POST("/admin/orders/:id/refunds", h.Refund, rbac.RequiredPermission("payment:refund"), idempotency.Idempotent())
 
 }
 
@@ -45,8 +52,20 @@ func (h *OrderHandler) CreateOrder(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
 	}
 
+	req := new(domain.CreateOrderRequest)
+
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	req.UserID = user.ID
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
 	ctx := c.Request().Context()
-	resp, err := h.orderUsecase.CreateOrder(ctx, user.ID)
+	resp, err := h.orderUsecase.CreateOrder(ctx, req)
 	if err != nil {
 		return err
 	}
@@ -73,3 +92,166 @@ func (h *OrderHandler) GetOrder(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, resp)
 }
+
+// Cancel withdraws an order of the user that is still waiting for payment
+func (h *OrderHandler) Cancel(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid order ID format")
+	}
+
+	ctx := c.Request().Context()
+	resp, err := h.orderUsecase.Cancel(ctx, id, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "order cancelled successfully", "data": resp})
+}
+
+// MarkReadyForPickup flags a paid order whose copies are waiting at the desk
+func (h *OrderHandler) MarkReadyForPickup(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid order ID format")
+	}
+
+	ctx := c.Request().Context()
+	resp, err := h.orderUsecase.MarkReadyForPickup(ctx, id, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// Refund gives back all or part of the payment of an order
+func (h *OrderHandler) Refund(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid order ID format")
+	}
+
+	req := new(domain.RefundOrderRequest)
+
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	req.OrderID = id
+	req.StaffID = user.ID
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	resp, err := h.orderUsecase.Refund(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// Fulfill hands out the copy with the scanned barcode for a paid order
+func (h *OrderHandler) Fulfill(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid order ID format")
+	}
+
+	req := new(domain.FulfillOrderRequest)
+
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	req.OrderID = id
+	req.StaffID = user.ID
+
+	ctx := c.Request().Context()
+	resp, err := h.orderUsecase.Fulfill(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// CheckIn takes back a borrowed copy, scanned by barcode or picked by order detail
+func (h *OrderHandler) CheckIn(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
+	req := new(domain.CheckInRequest)
+
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	req.StaffID = user.ID
+
+	ctx := c.Request().Context()
+	resp, err := h.orderUsecase.CheckIn(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// Renew pushes the due date of a borrowed item out by another loan period
+func (h *OrderHandler) Renew(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid order ID format")
+	}
+
+	detailID, err := strconv.ParseInt(c.Param("detailId"), 10, 64)
+	if err != nil || detailID <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid order item ID format")
+	}
+
+	ctx := c.Request().Context()
+	resp, err := h.orderUsecase.Renew(ctx, id, detailID, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -15,17 +16,18 @@ type postgresOrderRepository struct {
 }
 
 var (
-	ErrOrderNotFound = errors.New("order not found")
+	ErrOrderNotFound       = errors.New("order not found")
+	ErrOrderDetailNotFound = errors.New("order detail not found")
 )
 
 // GetByID implements domain.OrderRepository.
 func (p *postgresOrderRepository) GetByIDAndUserID(ctx context.Context, id, userId int64) (*domain.Order, error) {
-	query := `SELECT id, order_number, user_id, payment_status, payment_date, payment_method, created_at, updated_at 
+	query := `SELECT id, order_number, user_id, status, payment_status, subtotal, discount, total, payment_date, payment_method, completed_at, created_at, updated_at 
 	          FROM orders 
 			  WHERE id = $1 AND user_id = $2;`
 
 	var o domain.Order
-	err := p.conn.QueryRow(ctx, query, id, userId).Scan(&o.Id, &o.OrderNumber, &o.UserId, &o.PaymentStatus, &o.PaymentDate, &o.PaymentMethod, &o.CreatedAt, &o.UpdatedAt)
+	err := p.conn.QueryRow(ctx, query, id, userId).Scan(&o.Id, &o.OrderNumber, &o.UserId, &o.Status, &o.PaymentStatus, &o.Subtotal, &o.Discount, &o.Total, &o.PaymentDate, &o.PaymentMethod, &o.CompletedAt, &o.CreatedAt, &o.UpdatedAt)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -37,25 +39,91 @@ func (p *postgresOrderRepository) GetByIDAndUserID(ctx context.Context, id, user
 	return &o, nil
 }
 
-// GetPendingOrder implements domain.OrderRepository.
-func (p *postgresOrderRepository) GetPendingOrder(ctx context.Context, orderNumber string, userId int64) (bool, error) {
-	query := `SELECT EXISTS(SELECT 1 FROM orders WHERE order_number = $1 AND payment_status = 'Pending' AND user_id = $2);`
-	var exists bool
-	err := p.conn.QueryRow(ctx, query, orderNumber, userId).Scan(&exists)
+// GetByID implements domain.OrderRepository.
+func (p *postgresOrderRepository) GetByID(ctx context.Context, id int64) (*domain.Order, error) {
+	query := `SELECT id, order_number, user_id, status, payment_status, subtotal, discount, total, payment_date, payment_method, completed_at, created_at, updated_at
+	          FROM orders
+			  WHERE id = $1;`
+
+	var o domain.Order
+	err := p.conn.QueryRow(ctx, query, id).Scan(&o.Id, &o.OrderNumber, &o.UserId, &o.Status, &o.PaymentStatus, &o.Subtotal, &o.Discount, &o.Total, &o.PaymentDate, &o.PaymentMethod, &o.CompletedAt, &o.CreatedAt, &o.UpdatedAt)
+
 	if err != nil {
-		return false, err
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
 	}
-	return exists, nil
+
+	return &o, nil
+}
+
+// GetForUpdate implements domain.OrderRepository.
+func (p *postgresOrderRepository) GetForUpdate(ctx context.Context, tx pgx.Tx, id int64) (*domain.Order, error) {
+	query := `SELECT id, order_number, user_id, status, payment_status, subtotal, discount, total, payment_date, payment_method, completed_at, created_at, updated_at
+	          FROM orders
+			  WHERE id = $1
+			  FOR UPDATE;`
+
+	var o domain.Order
+	err := tx.QueryRow(ctx, query, id).Scan(&o.Id, &o.OrderNumber, &o.UserId, &o.Status, &o.PaymentStatus, &o.Subtotal, &o.Discount, &o.Total, &o.PaymentDate, &o.PaymentMethod, &o.CompletedAt, &o.CreatedAt, &o.UpdatedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+
+	return &o, nil
+}
+
+// GetByNumberAndUserID implements domain.OrderRepository.
+func (p *postgresOrderRepository) GetByNumberAndUserID(ctx context.Context, orderNumber string, userId int64) (*domain.Order, error) {
+	query := `SELECT id, order_number, user_id, status, payment_status, subtotal, discount, total, payment_date, payment_method, completed_at, created_at, updated_at
+	          FROM orders
+			  WHERE order_number = $1 AND user_id = $2;`
+
+	var o domain.Order
+	err := p.conn.QueryRow(ctx, query, orderNumber, userId).Scan(&o.Id, &o.OrderNumber, &o.UserId, &o.Status, &o.PaymentStatus, &o.Subtotal, &o.Discount, &o.Total, &o.PaymentDate, &o.PaymentMethod, &o.CompletedAt, &o.CreatedAt, &o.UpdatedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+
+	return &o, nil
+}
+
+// GetByNumber implements domain.OrderRepository.
+func (p *postgresOrderRepository) GetByNumber(ctx context.Context, orderNumber string) (*domain.Order, error) {
+	query := `SELECT id, order_number, user_id, status, payment_status, subtotal, discount, total, payment_date, payment_method, completed_at, created_at, updated_at
+	          FROM orders
+			  WHERE order_number = $1;`
+
+	var o domain.Order
+	err := p.conn.QueryRow(ctx, query, orderNumber).Scan(&o.Id, &o.OrderNumber, &o.UserId, &o.Status, &o.PaymentStatus, &o.Subtotal, &o.Discount, &o.Total, &o.PaymentDate, &o.PaymentMethod, &o.CompletedAt, &o.CreatedAt, &o.UpdatedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+
+	return &o, nil
 }
 
 // UpdateBorrowDates implements domain.PaymentRepository.
-func (p *postgresOrderRepository) UpdateBorrowDates(ctx context.Context, tx pgx.Tx, orderId int64) error {
+func (p *postgresOrderRepository) UpdateBorrowDates(ctx context.Context, tx pgx.Tx, orderId int64, loanDays int) error {
 	query := `UPDATE order_details
 	          SET borrowing_date = timezone('UTC', now()),
-			  return_date = timezone('UTC', now() + interval '7 days')
+			  return_date = timezone('UTC', now() + make_interval(days => $2))
 			  WHERE order_id = $1;`
 
-	cmdTag, err := tx.Exec(ctx, query, orderId)
+	cmdTag, err := tx.Exec(ctx, query, orderId, loanDays)
 
 	if err != nil {
 		return err
@@ -68,41 +136,442 @@ func (p *postgresOrderRepository) UpdateBorrowDates(ctx context.Context, tx pgx.
 	return nil
 }
 
-// UpdateStock implements domain.OrderRepository.
-func (p *postgresOrderRepository) UpdateStock(ctx context.Context, tx pgx.Tx, orderID int64) error {
-	query := `UPDATE books b 
-			  SET in_stock = in_stock - 1
+// CloseUnboundDetails implements domain.OrderRepository.
+func (p *postgresOrderRepository) CloseUnboundDetails(ctx context.Context, tx pgx.Tx, orderID int64) error {
+	query := `UPDATE order_details
+			  SET return_date = NULL, updated_at = now()
+			  WHERE order_id = $1 AND book_copy_id IS NULL AND returned_at IS NULL;`
+
+	if _, err := tx.Exec(ctx, query, orderID); err != nil {
+		return fmt.Errorf("failed to close unbound order details: %w", err)
+	}
+
+	return nil
+}
+
+// CountOpenLoans implements domain.OrderRepository.
+func (p *postgresOrderRepository) CountOpenLoans(ctx context.Context, tx pgx.Tx, userID int64) (int, error) {
+	query := `SELECT COUNT(1)
 			  FROM order_details od
-			  WHERE od.book_id = b.id AND b.in_stock > 0 AND od.order_id = $1;`
+			  JOIN orders o ON o.id = od.order_id
+			  WHERE o.user_id = $1 AND o.status IN ('pending_payment', 'paid', 'ready_for_pickup', 'on_loan')
+				AND od.returned_at IS NULL;`
 
-	cmdTag, err := tx.Exec(ctx, query, orderID)
+	var count int
+
+	if err := tx.QueryRow(ctx, query, userID).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// HasPlacedOrder implements domain.OrderRepository.
+func (p *postgresOrderRepository) HasPlacedOrder(ctx context.Context, tx pgx.Tx, userID int64) (bool, error) {
+	query := `SELECT EXISTS (
+				SELECT 1 FROM orders WHERE user_id = $1 AND status NOT IN ('cancelled', 'expired')
+			  );`
+
+	var placed bool
+
+	if err := tx.QueryRow(ctx, query, userID).Scan(&placed); err != nil {
+		return false, err
+	}
+
+	return placed, nil
+}
+
+// BindCopy implements domain.OrderRepository.
+func (p *postgresOrderRepository) BindCopy(ctx context.Context, tx pgx.Tx, orderID, bookID, copyID int64) (int64, error) {
+	query := `UPDATE order_details
+			  SET book_copy_id = $1, updated_at = now()
+			  WHERE id = (
+				SELECT id FROM order_details
+				WHERE order_id = $2 AND book_id = $3 AND book_copy_id IS NULL
+				ORDER BY id
+				LIMIT 1
+				FOR UPDATE
+			  )
+			  RETURNING id;`
+
+	var id int64
+
+	err := tx.QueryRow(ctx, query, copyID, orderID, bookID).Scan(&id)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrOrderDetailNotFound
+		}
+
+		return 0, err
+	}
+
+	return id, nil
+}
+
+const orderDetailColumns = `od.id, od.order_id, od.book_id, od.book_copy_id, od.borrowing_date, od.return_date, od.returned_at,
+				od.overdue_at, od.renewal_count, od.unit_price, od.fee, od.created_at, od.updated_at`
+
+func scanOrderDetail(row pgx.Row) (*domain.OrderDetail, error) {
+	var od domain.OrderDetail
+
+	err := row.Scan(&od.Id, &od.OrderId, &od.BookId, &od.BookCopyID, &od.BorrowingDate, &od.ReturnDate, &od.ReturnedAt,
+		&od.OverdueAt, &od.RenewalCount, &od.UnitPrice, &od.Fee, &od.CreatedAt, &od.UpdatedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrOrderDetailNotFound
+		}
+
+		return nil, err
+	}
+
+	return &od, nil
+}
+
+// GetOrderDetail implements domain.OrderRepository.
+func (p *postgresOrderRepository) GetOrderDetail(ctx context.Context, tx pgx.Tx, id int64) (*domain.OrderDetail, error) {
+	query := `SELECT ` + orderDetailColumns + ` FROM order_details od WHERE od.id = $1;`
+
+	return scanOrderDetail(tx.QueryRow(ctx, query, id))
+}
+
+// GetOrderDetailForUpdate implements domain.OrderRepository.
+func (p *postgresOrderRepository) GetOrderDetailForUpdate(ctx context.Context, tx pgx.Tx, id int64) (*domain.OrderDetail, error) {
+	query := `SELECT ` + orderDetailColumns + ` FROM order_details od WHERE od.id = $1 FOR UPDATE;`
+
+	return scanOrderDetail(tx.QueryRow(ctx, query, id))
+}
+
+// FetchRenewals implements domain.OrderRepository.
+func (p *postgresOrderRepository) FetchRenewals(ctx context.Context, orderID int64) ([]domain.OrderDetailRenewal, error) {
+	query := `SELECT r.id, r.order_detail_id, r.previous_return_date, r.new_return_date, r.created_at
+			  FROM order_detail_renewals r
+			  JOIN order_details od ON od.id = r.order_detail_id
+			  WHERE od.order_id = $1
+			  ORDER BY r.created_at, r.id;`
+
+	rows, err := p.conn.Query(ctx, query, orderID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := make([]domain.OrderDetailRenewal, 0)
+
+	for rows.Next() {
+		r := domain.OrderDetailRenewal{}
+
+		if err := rows.Scan(&r.Id, &r.OrderDetailID, &r.PreviousReturnDate, &r.NewReturnDate, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		result = append(result, r)
+	}
+
+	return result, rows.Err()
+}
+
+// Renew implements domain.OrderRepository.
+func (p *postgresOrderRepository) Renew(ctx context.Context, tx pgx.Tx, detailID int64, returnDate time.Time) error {
+	query := `WITH renewed AS (
+				UPDATE order_details od
+				SET return_date = $2, renewal_count = od.renewal_count + 1, reminded_at = NULL, updated_at = now()
+				FROM (SELECT id, return_date FROM order_details WHERE id = $1 FOR UPDATE) prev
+				WHERE od.id = prev.id AND prev.return_date IS NOT NULL
+				RETURNING od.id, prev.return_date AS previous_return_date
+			  )
+			  INSERT INTO order_detail_renewals (order_detail_id, previous_return_date, new_return_date)
+			  SELECT id, previous_return_date, $2 FROM renewed;`
+
+	row, err := tx.Exec(ctx, query, detailID, returnDate)
 
 	if err != nil {
 		return err
 	}
 
-	if cmdTag.RowsAffected() == 0 {
-		return fmt.Errorf("stock not enough")
+	if row.RowsAffected() == 0 {
+		return ErrOrderDetailNotFound
+	}
+
+	return nil
+}
+
+// GetOpenLoanByBarcode implements domain.OrderRepository.
+func (p *postgresOrderRepository) GetOpenLoanByBarcode(ctx context.Context, tx pgx.Tx, barcode string) (*domain.OrderDetail, error) {
+	query := `SELECT ` + orderDetailColumns + `
+			  FROM order_details od
+			  JOIN book_copies bc ON bc.id = od.book_copy_id
+			  WHERE bc.barcode = $1 AND od.returned_at IS NULL;`
+
+	return scanOrderDetail(tx.QueryRow(ctx, query, barcode))
+}
+
+// MarkReturned implements domain.OrderRepository.
+func (p *postgresOrderRepository) MarkReturned(ctx context.Context, tx pgx.Tx, detailID int64) (returnedAt time.Time, err error) {
+	query := `UPDATE order_details
+			  SET returned_at = now(), updated_at = now()
+			  WHERE id = $1 AND book_copy_id IS NOT NULL AND returned_at IS NULL
+			  RETURNING returned_at;`
+
+	err = tx.QueryRow(ctx, query, detailID).Scan(&returnedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return returnedAt, ErrOrderDetailNotFound
+		}
+
+		return returnedAt, err
+	}
+
+	return
+}
+
+// MarkDueSoon implements domain.OrderRepository.
+func (p *postgresOrderRepository) MarkDueSoon(ctx context.Context, dueBefore time.Time) ([]domain.LoanNotice, error) {
+	query := `WITH due AS (
+				UPDATE order_details
+				SET reminded_at = now()
+				WHERE returned_at IS NULL AND reminded_at IS NULL AND return_date > now() AND return_date <= $1
+					AND book_copy_id IS NOT NULL
+					AND order_id IN (SELECT id FROM orders WHERE status = 'on_loan')
+				RETURNING id, order_id, book_id, return_date
+			  )
+			  SELECT d.id, u.id, u.email, u.name, b.title, d.return_date
+			  FROM due d
+			  JOIN orders o ON o.id = d.order_id
+			  JOIN users u ON u.id = o.user_id
+			  JOIN books b ON b.id = d.book_id;`
+
+	return p.fetchLoanNotices(ctx, query, dueBefore)
+}
+
+// MarkOverdue implements domain.OrderRepository.
+func (p *postgresOrderRepository) MarkOverdue(ctx context.Context, now time.Time) ([]domain.LoanNotice, error) {
+	query := `WITH overdue AS (
+				UPDATE order_details
+				SET overdue_at = now()
+				WHERE returned_at IS NULL AND overdue_at IS NULL AND return_date < $1
+					AND book_copy_id IS NOT NULL
+					AND order_id IN (SELECT id FROM orders WHERE status = 'on_loan')
+				RETURNING id, order_id, book_id, return_date
+			  )
+			  SELECT d.id, u.id, u.email, u.name, b.title, d.return_date
+			  FROM overdue d
+			  JOIN orders o ON o.id = d.order_id
+			  JOIN users u ON u.id = o.user_id
+			  JOIN books b ON b.id = d.book_id;`
+
+	return p.fetchLoanNotices(ctx, query, now)
+}
+
+func (p *postgresOrderRepository) fetchLoanNotices(ctx context.Context, query string, args ...any) ([]domain.LoanNotice, error) {
+	rows, err := p.conn.Query(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := make([]domain.LoanNotice, 0)
+
+	for rows.Next() {
+		n := domain.LoanNotice{}
+
+		if err := rows.Scan(&n.OrderDetailID, &n.UserID, &n.Email, &n.Name, &n.BookTitle, &n.ReturnDate); err != nil {
+			return nil, err
+		}
+
+		result = append(result, n)
+	}
+
+	return result, rows.Err()
+}
+
+// FetchBookIDs implements domain.OrderRepository.
+func (p *postgresOrderRepository) FetchBookIDs(ctx context.Context, tx pgx.Tx, orderID int64) ([]int64, error) {
+	rows, err := tx.Query(ctx, `SELECT DISTINCT book_id FROM order_details WHERE order_id = $1 ORDER BY book_id;`, orderID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scanIDs(rows)
+}
+
+// FetchUnpaidBefore implements domain.OrderRepository.
+func (p *postgresOrderRepository) FetchUnpaidBefore(ctx context.Context, before time.Time) ([]int64, error) {
+	rows, err := p.conn.Query(ctx, `SELECT id FROM orders WHERE status = 'pending_payment' AND created_at < $1 ORDER BY id;`, before)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scanIDs(rows)
+}
+
+// CountItems implements domain.OrderRepository.
+func (p *postgresOrderRepository) CountItems(ctx context.Context, tx pgx.Tx, orderID int64) (domain.OrderItemCounts, error) {
+	query := `SELECT COUNT(1), COUNT(book_copy_id), COUNT(returned_at)
+			  FROM order_details
+			  WHERE order_id = $1;`
+
+	var c domain.OrderItemCounts
+
+	if err := tx.QueryRow(ctx, query, orderID).Scan(&c.Total, &c.Bound, &c.Returned); err != nil {
+		return c, err
+	}
+
+	return c, nil
+}
+
+// Transition implements domain.OrderRepository.
+func (p *postgresOrderRepository) Transition(ctx context.Context, tx pgx.Tx, orderID int64, to string, actorID *int64, reason string) error {
+	var from string
+
+	err := tx.QueryRow(ctx, `SELECT status FROM orders WHERE id = $1 FOR UPDATE;`, orderID).Scan(&from)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrOrderNotFound
+		}
+
+		return err
+	}
+
+	if err := domain.CheckOrderTransition(from, to); err != nil {
+		return err
+	}
+
+	// payment_status follows along so the payment outcome stays readable on its own
+	query := `UPDATE orders
+			  SET status = $1,
+				payment_status = CASE
+					WHEN $1 = 'paid' THEN 'Paid'
+					WHEN $1 = 'refunded' THEN 'Refunded'
+					WHEN $1 IN ('cancelled', 'expired') AND payment_status = 'Pending' THEN 'Failed'
+					ELSE payment_status
+				END,
+				completed_at = CASE WHEN $1 = 'returned' THEN now() ELSE completed_at END,
+				updated_at = now()
+			  WHERE id = $2;`
+
+	if _, err := tx.Exec(ctx, query, to, orderID); err != nil {
+		return fmt.Errorf("failed to update order status: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `INSERT INTO order_events (order_id, from_status, to_status, actor_id, reason) VALUES ($1, $2, $3, $4, $5);`,
+		orderID, from, to, actorID, reason)
+
+	if err != nil {
+		return fmt.Errorf("failed to record order event: %w", err)
 	}
 
 	return nil
 }
 
+// FetchEvents implements domain.OrderRepository.
+func (p *postgresOrderRepository) FetchEvents(ctx context.Context, orderID int64) ([]domain.OrderEvent, error) {
+	query := `SELECT e.id, e.order_id, e.from_status, e.to_status, e.actor_id, u.name, e.reason, e.created_at
+			  FROM order_events e
+			  LEFT JOIN users u ON u.id = e.actor_id
+			  WHERE e.order_id = $1
+			  ORDER BY e.created_at, e.id;`
+
+	rows, err := p.conn.Query(ctx, query, orderID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := make([]domain.OrderEvent, 0)
+
+	for rows.Next() {
+		e := domain.OrderEvent{}
+
+		if err := rows.Scan(&e.Id, &e.OrderId, &e.FromStatus, &e.ToStatus, &e.ActorID, &e.ActorName, &e.Reason, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		result = append(result, e)
+	}
+
+	return result, rows.Err()
+}
+
+// FetchForReconciliation implements domain.OrderRepository. Free orders never reach the gateway and are left out.
+func (p *postgresOrderRepository) FetchForReconciliation(ctx context.Context, since time.Time, afterID int64, limit int) ([]domain.Order, error) {
+	query := `SELECT id, order_number, user_id, status, payment_status, subtotal, discount, total, payment_date, payment_method, completed_at, created_at, updated_at
+			  FROM orders
+			  WHERE created_at >= $1 AND id > $2
+				AND payment_status IN ('Pending', 'Paid')
+				AND payment_method IS DISTINCT FROM 'free'
+			  ORDER BY id
+			  LIMIT $3;`
+
+	rows, err := p.conn.Query(ctx, query, since, afterID, limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := make([]domain.Order, 0)
+
+	for rows.Next() {
+		var o domain.Order
+
+		if err := rows.Scan(&o.Id, &o.OrderNumber, &o.UserId, &o.Status, &o.PaymentStatus, &o.Subtotal, &o.Discount, &o.Total, &o.PaymentDate, &o.PaymentMethod, &o.CompletedAt, &o.CreatedAt, &o.UpdatedAt); err != nil {
+			return nil, err
+		}
+
+		result = append(result, o)
+	}
+
+	return result, rows.Err()
+}
+
+// GetOwnerContact implements domain.OrderRepository.
+func (p *postgresOrderRepository) GetOwnerContact(ctx context.Context, orderID int64) (email, name string, err error) {
+	query := `SELECT u.email, u.name
+			  FROM orders o
+			  JOIN users u ON u.id = o.user_id
+			  WHERE o.id = $1;`
+
+	err = p.conn.QueryRow(ctx, query, orderID).Scan(&email, &name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", "", ErrOrderNotFound
+	}
+
+	return email, name, err
+}
+
 // GetOrderByUserID implements domain.OrderRepository.
 func (p *postgresOrderRepository) GetOrdersByUserID(ctx context.Context, userID int64) ([]domain.OrderWithDetailCount, error) {
 	query := `SELECT 
 				o.id AS order_id,
 				o.order_number,
 				o.user_id,
+				o.status,
 				o.payment_status,
+				o.subtotal,
+				o.discount,
+				o.total,
 				o.payment_date,
 				o.payment_method,
+				o.completed_at,
 				COUNT(od.id) AS total_order_details,
 				o.created_at
 			  FROM orders o
 			  LEFT JOIN order_details od ON o.id = od.order_id
 			  WHERE o.user_id = $1
-			  GROUP BY o.id, o.order_number, o.user_id, o.payment_status, o.payment_date, o.payment_method
+			  GROUP BY o.id, o.order_number, o.user_id, o.status, o.payment_status, o.subtotal, o.discount, o.total, o.payment_date, o.payment_method, o.completed_at
 			  ORDER BY o.created_at DESC;`
 
 	rows, err := p.conn.Query(ctx, query, userID)
@@ -121,9 +590,14 @@ func (p *postgresOrderRepository) GetOrdersByUserID(ctx context.Context, userID
 		err = rows.Scan(&orderDetail.Id,
 			&orderDetail.OrderNumber,
 			&orderDetail.UserId,
+			&orderDetail.Status,
 			&orderDetail.PaymentStatus,
+			&orderDetail.Subtotal,
+			&orderDetail.Discount,
+			&orderDetail.Total,
 			&orderDetail.PaymentDate,
 			&orderDetail.PaymentMethod,
+			&orderDetail.CompletedAt,
 			&orderDetail.TotalOrderDetail,
 			&orderDetail.CreatedAt)
 
@@ -143,8 +617,15 @@ func (p *postgresOrderRepository) GetOrderDetailWithBook(ctx context.Context, or
 				od.id, 
 				od.order_id, 
 				od.book_id,
+				od.book_copy_id,
+				bc.barcode,
 				od.borrowing_date,
 				od.return_date,
+				od.returned_at,
+				od.overdue_at,
+				od.renewal_count,
+				od.unit_price,
+				od.fee,
 				od.created_at,
 				od.updated_at, 
 				b.title, 
@@ -157,7 +638,9 @@ func (p *postgresOrderRepository) GetOrderDetailWithBook(ctx context.Context, or
 			  JOIN books b ON od.book_id = b.id
 			  JOIN authors a ON b.author_id = a.id
 			  JOIN publishers p ON b.publisher_id = p.id 
-			  WHERE od.order_id = $1;`
+			  LEFT JOIN book_copies bc ON od.book_copy_id = bc.id
+			  WHERE od.order_id = $1
+			  ORDER BY od.id;`
 
 	rows, err := p.conn.Query(ctx, query, orderID)
 
@@ -176,8 +659,15 @@ func (p *postgresOrderRepository) GetOrderDetailWithBook(ctx context.Context, or
 			&od.Id,
 			&od.OrderId,
 			&od.BookId,
+			&od.BookCopyID,
+			&od.Barcode,
 			&od.BorrowingDate,
 			&od.ReturnDate,
+			&od.ReturnedAt,
+			&od.OverdueAt,
+			&od.RenewalCount,
+			&od.UnitPrice,
+			&od.Fee,
 			&od.CreatedAt,
 			&od.UpdatedAt,
 			&od.BookTitle,
@@ -198,16 +688,10 @@ func (p *postgresOrderRepository) GetOrderDetailWithBook(ctx context.Context, or
 }
 
 // ClearCart implements domain.OrderRepository.
-func (p *postgresOrderRepository) ClearCart(ctx context.Context, tx pgx.Tx, userID int64) error {
-	query := `DELETE FROM carts 
-			  WHERE user_id = $1 AND id IN (
-			 	SELECT c.id
-				FROM carts c
-				JOIN books b ON c.book_id = b.id 
-				WHERE b.in_stock > 0
-			  );`
+func (p *postgresOrderRepository) ClearCart(ctx context.Context, tx pgx.Tx, userID int64, bookIDs []int64) error {
+	query := `DELETE FROM carts WHERE user_id = $1 AND book_id = ANY($2);`
 
-	row, err := tx.Exec(ctx, query, userID)
+	row, err := tx.Exec(ctx, query, userID, bookIDs)
 
 	if err != nil {
 		return err
@@ -222,12 +706,14 @@ func (p *postgresOrderRepository) ClearCart(ctx context.Context, tx pgx.Tx, user
 	return nil
 }
 
-// GetCartItems implements domain.OrderRepository.
+// GetCartItems implements domain.OrderRepository. Books out of stock are returned as well, the copy set aside for
+// a ready hold is not counted in in_stock and whatever stays unavailable is reported by the order.
 func (p *postgresOrderRepository) GetCartItems(ctx context.Context, tx pgx.Tx, userID int64) ([]*domain.CartItem, error) {
-	query := `SELECT c.book_id
+	query := `SELECT c.book_id, b.title, COALESCE(b.price, 0)
 			  FROM carts c
 			  JOIN books b ON c.book_id = b.id
-			  WHERE c.user_id = $1 and b.in_stock > 0;`
+			  WHERE c.user_id = $1 AND b.deleted_at IS NULL
+			  ORDER BY c.book_id;`
 
 	result := make([]*domain.CartItem, 0)
 
@@ -241,7 +727,7 @@ func (p *postgresOrderRepository) GetCartItems(ctx context.Context, tx pgx.Tx, u
 	for rows.Next() {
 		c := &domain.CartItem{}
 
-		err := rows.Scan(&c.BookId)
+		err := rows.Scan(&c.BookId, &c.BookTitle, &c.Price)
 
 		if err != nil {
 			return nil, err
@@ -272,9 +758,9 @@ func (p *postgresOrderRepository) SaveOrderDetailsFromCart(ctx context.Context,
 		newItems[i] = &newItem
 	}
 
-	_, err := tx.CopyFrom(ctx, pgx.Identifier{"order_details"}, []string{"book_id", "order_id"}, pgx.CopyFromSlice(len(newItems), func(i int) ([]any, error) {
+	_, err := tx.CopyFrom(ctx, pgx.Identifier{"order_details"}, []string{"book_id", "order_id", "unit_price", "fee"}, pgx.CopyFromSlice(len(newItems), func(i int) ([]any, error) {
 		item := newItems[i]
-		return []any{item.BookId, item.OrderId}, nil
+		return []any{item.BookId, item.OrderId, item.Price, item.Fee}, nil
 	}))
 
 	if err != nil {
@@ -297,9 +783,17 @@ func (p *postgresOrderRepository) GetTx(ctx context.Context) (pgx.Tx, error) {
 
 // SaveOrder implements domain.OrderRepository.
 func (p *postgresOrderRepository) SaveOrder(ctx context.Context, tx pgx.Tx, order *domain.Order) (id int64, err error) {
-	query := `INSERT INTO orders(order_number, user_id, payment_status) VALUES ($1, $2, $3) RETURNING id;`
-
-	err = tx.QueryRow(ctx, query, order.OrderNumber, order.UserId, order.PaymentStatus).Scan(&id)
+	query := `WITH o AS (
+				INSERT INTO orders(order_number, user_id, status, payment_status, subtotal, discount, total)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)
+				RETURNING id, user_id, status
+			  )
+			  INSERT INTO order_events (order_id, to_status, actor_id, reason)
+			  SELECT id, status, user_id, 'order placed' FROM o
+			  RETURNING order_id;`
+
+	err = tx.QueryRow(ctx, query, order.OrderNumber, order.UserId, order.Status, order.PaymentStatus,
+		order.Subtotal, order.Discount, order.Total).Scan(&id)
 
 	if err != nil {
 		return 0, fmt.Errorf("failed to insert order: %w", err)
@@ -308,6 +802,24 @@ func (p *postgresOrderRepository) SaveOrder(ctx context.Context, tx pgx.Tx, orde
 	return
 }
 
+func scanIDs(rows pgx.Rows) ([]int64, error) {
+	defer rows.Close()
+
+	ids := make([]int64, 0)
+
+	for rows.Next() {
+		var id int64
+
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
 func NewPostgresOrderRepository(conn *pgxpool.Pool) domain.OrderRepository {
 	return &postgresOrderRepository{conn: conn}
 }
@@ -4,16 +4,36 @@ import (
 	"backend-layout/helper"
 	baseErr "backend-layout/internal/adapter/errors"
 	"backend-layout/internal/domain"
+	bookRepository "backend-layout/internal/module/book/repository"
+	holdRepository "backend-layout/internal/module/hold/repository"
+	"backend-layout/internal/module/order/repository"
+	promotionRepository "backend-layout/internal/module/promotion/repository"
+	"backend-layout/internal/tasks"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/rs/zerolog/log"
 )
 
 type OrderUsecase struct {
-	orderRepo domain.OrderRepository
+	orderRepo       domain.OrderRepository
+	copyRepo        domain.BookCopyRepository
+	holdRepo        domain.BookHoldRepository
+	fineRepo        domain.FineRepository
+	policyRepo      domain.LendingPolicyRepository
+	paymentRepo     domain.PaymentRepository
+	promotionRepo   domain.PromotionRepository
+	payments        domain.PaymentGateway
+	taskDistributor tasks.TaskDistributor
+	finePolicy      domain.FinePolicy
+	paymentWindow   time.Duration
 }
 
 // GetUserOrderHistory implements domain.OrderUsecase.
@@ -29,8 +49,13 @@ func (o *OrderUsecase) GetUserOrderHistory(ctx context.Context, userID int64) ([
 		orderResponses = append(orderResponses, domain.OrderResponse{
 			Id:               order.Id,
 			OrderNumber:      order.OrderNumber,
+			Status:           order.Status,
 			PaymentStatus:    order.PaymentStatus,
+			Subtotal:         order.Subtotal,
+			Discount:         order.Discount,
+			Total:            order.Total,
 			PaymentDate:      order.PaymentDate,
+			CompletedAt:      order.CompletedAt,
 			UserId:           order.UserId,
 			TotalOrderDetail: order.TotalOrderDetail,
 			CreatedAt:        order.CreatedAt,
@@ -41,18 +66,51 @@ func (o *OrderUsecase) GetUserOrderHistory(ctx context.Context, userID int64) ([
 }
 
 // GetUserOrderDetails implements domain.OrderUsecase.
-func (o *OrderUsecase) GetUserOrderDetails(ctx context.Context, orderID, userID int64) ([]domain.OrderDetailResponse, error) {
+func (o *OrderUsecase) GetUserOrderDetails(ctx context.Context, orderID, userID int64) (domain.OrderDetailsResponse, error) {
 
-	_, err := o.orderRepo.GetByIDAndUserID(ctx, orderID, userID)
+	order, err := o.orderRepo.GetByIDAndUserID(ctx, orderID, userID)
 
 	if err != nil {
-		return nil, err
+		return domain.OrderDetailsResponse{}, err
 	}
 
 	orderDetailsWithBookInfo, err := o.orderRepo.GetOrderDetailWithBook(ctx, orderID)
 	if err != nil {
 		log.Error().Err(err).Str("layer", "usecase").Int64("userID", userID).Int64("orderID", orderID).Msg("failed to get order details")
-		return nil, baseErr.NewInternalServerError("failed to get order details")
+		return domain.OrderDetailsResponse{}, baseErr.NewInternalServerError("failed to get order details")
+	}
+
+	renewals, err := o.orderRepo.FetchRenewals(ctx, orderID)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("userID", userID).Int64("orderID", orderID).Msg("failed to get renewals")
+		return domain.OrderDetailsResponse{}, baseErr.NewInternalServerError("failed to get order details")
+	}
+
+	renewalsByDetail := make(map[int64][]domain.OrderDetailRenewalResponse)
+	for _, r := range renewals {
+		renewalsByDetail[r.OrderDetailID] = append(renewalsByDetail[r.OrderDetailID], domain.OrderDetailRenewalResponse{
+			PreviousReturnDate: r.PreviousReturnDate,
+			NewReturnDate:      r.NewReturnDate,
+			CreatedAt:          r.CreatedAt,
+		})
+	}
+
+	events, err := o.orderRepo.FetchEvents(ctx, orderID)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("userID", userID).Int64("orderID", orderID).Msg("failed to get order events")
+		return domain.OrderDetailsResponse{}, baseErr.NewInternalServerError("failed to get order details")
+	}
+
+	timeline := make([]domain.OrderEventResponse, 0, len(events))
+	for _, e := range events {
+		timeline = append(timeline, domain.OrderEventResponse{
+			FromStatus: e.FromStatus,
+			ToStatus:   e.ToStatus,
+			ActorID:    e.ActorID,
+			ActorName:  e.ActorName,
+			Reason:     e.Reason,
+			CreatedAt:  e.CreatedAt,
+		})
 	}
 
 	orderDetailResponses := make([]domain.OrderDetailResponse, 0, len(orderDetailsWithBookInfo))
@@ -61,8 +119,16 @@ func (o *OrderUsecase) GetUserOrderDetails(ctx context.Context, orderID, userID
 			Id:            od.Id,
 			OrderId:       od.OrderId,
 			BookId:        od.BookId,
+			BookCopyID:    od.BookCopyID,
+			Barcode:       od.Barcode,
 			BorrowingDate: od.BorrowingDate,
 			ReturnDate:    od.ReturnDate,
+			ReturnedAt:    od.ReturnedAt,
+			OverdueAt:     od.OverdueAt,
+			RenewalCount:  od.RenewalCount,
+			UnitPrice:     od.UnitPrice,
+			Fee:           od.Fee,
+			Renewals:      renewalsByDetail[od.Id],
 			CreatedAt:     od.CreatedAt,
 			UpdatedAt:     od.UpdatedAt,
 			BookTitle:     od.BookTitle,
@@ -75,12 +141,48 @@ func (o *OrderUsecase) GetUserOrderDetails(ctx context.Context, orderID, userID
 
 	}
 
-	return orderDetailResponses, nil
+	return domain.OrderDetailsResponse{
+		OrderResponse: domain.OrderResponse{
+			Id:               order.Id,
+			OrderNumber:      order.OrderNumber,
+			Status:           order.Status,
+			PaymentStatus:    order.PaymentStatus,
+			Subtotal:         order.Subtotal,
+			Discount:         order.Discount,
+			Total:            order.Total,
+			PaymentDate:      order.PaymentDate,
+			CompletedAt:      order.CompletedAt,
+			UserId:           order.UserId,
+			TotalOrderDetail: int64(len(orderDetailResponses)),
+			CreatedAt:        order.CreatedAt,
+		},
+		Items:    orderDetailResponses,
+		Timeline: timeline,
+	}, nil
 
 }
 
 // CreateOrder implements domain.OrderUsecase.
-func (o *OrderUsecase) CreateOrder(ctx context.Context, userID int64) (orderResp domain.OrderResponse, err error) {
+func (o *OrderUsecase) CreateOrder(ctx context.Context, input *domain.CreateOrderRequest) (orderResp domain.OrderResponse, err error) {
+	userID := input.UserID
+
+	outstanding, err := o.fineRepo.OutstandingTotal(ctx, userID)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("userID", userID).Msg("failed to get outstanding fines")
+
+		return domain.OrderResponse{}, baseErr.NewInternalServerError("failed to create order")
+	}
+
+	if outstanding > o.finePolicy.BlockThreshold {
+		return domain.OrderResponse{}, baseErr.NewForbiddenError(fmt.Sprintf("outstanding fines of %.2f must be settled before borrowing again", outstanding))
+	}
+
+	policy, err := o.policyRepo.ResolveForUser(ctx, userID)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("userID", userID).Msg("failed to resolve lending policy")
+
+		return domain.OrderResponse{}, baseErr.NewInternalServerError("failed to create order")
+	}
 
 	tx, err := o.orderRepo.GetTx(ctx)
 	if err != nil {
@@ -118,8 +220,23 @@ func (o *OrderUsecase) CreateOrder(ctx context.Context, userID int64) (orderResp
 		return domain.OrderResponse{}, baseErr.NewBadRequestError("empty cart")
 	}
 
-	if len(items) > 3 {
-		return domain.OrderResponse{}, baseErr.NewBadRequestError("maximum 3 items")
+	openLoans, err := o.orderRepo.CountOpenLoans(ctx, tx, userID)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("userID", userID).Msg("failed to count open loans")
+
+		return domain.OrderResponse{}, baseErr.NewInternalServerError("failed to create order")
+	}
+
+	if openLoans+len(items) > policy.MaxLoans {
+		return domain.OrderResponse{}, baseErr.NewBadRequestError(fmt.Sprintf("maximum %d items on loan, %d already borrowed or ordered", policy.MaxLoans, openLoans))
+	}
+
+	// prices are fixed when the order is placed, whatever happens to the books or the policy afterwards
+	var subtotal float64
+
+	for _, item := range items {
+		item.Fee = math.Round(item.Price * policy.PriceMultiplier)
+		subtotal += item.Fee
 	}
 
 	orderNumberStr := generateOrderNumber()
@@ -127,7 +244,22 @@ func (o *OrderUsecase) CreateOrder(ctx context.Context, userID int64) (orderResp
 	order := domain.Order{
 		UserId:        userID,
 		OrderNumber:   orderNumberStr,
+		Status:        domain.OrderPendingPayment,
 		PaymentStatus: "Pending",
+		Subtotal:      subtotal,
+		Total:         subtotal,
+	}
+
+	var redemption *domain.PromotionRedemption
+
+	if input.PromotionCode != "" {
+		redemption, err = o.applyPromotion(ctx, tx, userID, input.PromotionCode, items, subtotal)
+		if err != nil {
+			return domain.OrderResponse{}, err
+		}
+
+		order.Discount = redemption.Discount
+		order.Total = subtotal - redemption.Discount
 	}
 
 	id, err := o.orderRepo.SaveOrder(ctx, tx, &order)
@@ -137,25 +269,85 @@ func (o *OrderUsecase) CreateOrder(ctx context.Context, userID int64) (orderResp
 		return domain.OrderResponse{}, baseErr.NewInternalServerError("failed to create order")
 	}
 
-	err = o.orderRepo.SaveOrderDetailsFromCart(ctx, tx, items, id, userID)
-	if err != nil {
-		log.Error().Err(err).Str("layer", "usecase").Int64("userID", userID).Msg("failed to save order detail")
+	if redemption != nil {
+		redemption.OrderID = id
 
-		return domain.OrderResponse{}, baseErr.NewInternalServerError("failed to create order")
+		if err = o.promotionRepo.Redeem(ctx, tx, redemption); err != nil {
+			log.Error().Err(err).Str("layer", "usecase").Int64("userID", userID).Int64("promotionID", redemption.PromotionID).Msg("failed to redeem promotion")
+
+			return domain.OrderResponse{}, baseErr.NewInternalServerError("failed to create order")
+		}
+	}
+
+	bookIDs := make([]int64, 0, len(items))
+
+	for _, item := range items {
+		bookIDs = append(bookIDs, item.BookId)
 	}
 
-	err = o.orderRepo.ClearCart(ctx, tx, userID)
+	// only what was ordered leaves the cart, whether it is in stock is settled below
+	err = o.orderRepo.ClearCart(ctx, tx, userID, bookIDs)
 	if err != nil {
 		log.Error().Err(err).Str("layer", "usecase").Int64("userID", userID).Msg("failed to clear cart")
 
 		return domain.OrderResponse{}, baseErr.NewInternalServerError("failed to create order")
 	}
 
-	err = o.orderRepo.UpdateStock(ctx, tx, id)
+	// items come sorted by book. holds are claimed first, that locks their copies before any book as everywhere else
+	claimed := make(map[int64]bool, len(items))
+
+	for _, item := range items {
+		// a ready hold claims the copy that was set aside for it, back on the shelf it covers the new item
+		copyID, err := o.holdRepo.Claim(ctx, tx, userID, item.BookId)
+		if err == nil {
+			claimed[item.BookId] = true
+			err = o.copyRepo.SetStatus(ctx, tx, copyID, domain.BookCopyAvailable)
+		} else if errors.Is(err, holdRepository.ErrHoldNotFound) {
+			err = nil
+		}
+
+		if err != nil {
+			log.Error().Err(err).Str("layer", "usecase").Int64("userID", userID).Int64("bookID", item.BookId).Msg("failed to claim hold")
+
+			return domain.OrderResponse{}, baseErr.NewInternalServerError("failed to create order")
+		}
+	}
+
+	// every book stays locked until commit, so no other order can take the copies counted here
+	if err = o.copyRepo.LockBooks(ctx, tx, bookIDs); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("userID", userID).Msg("failed to lock books")
+
+		return domain.OrderResponse{}, baseErr.NewInternalServerError("failed to create order")
+	}
+
+	// the details go in once their books are locked. their foreign keys share lock the books, taken any earlier
+	// two orders for the same book would deadlock on the lock above
+	err = o.orderRepo.SaveOrderDetailsFromCart(ctx, tx, items, id, userID)
 	if err != nil {
-		log.Error().Err(err).Str("layer", "usecase").Int64("userID", userID).Msg("failed to update stock")
+		log.Error().Err(err).Str("layer", "usecase").Int64("userID", userID).Msg("failed to save order detail")
+
+		return domain.OrderResponse{}, baseErr.NewInternalServerError("failed to create order")
+	}
+
+	unavailable := make([]string, 0)
+
+	for _, item := range items {
+		stock, err := o.copyRepo.RefreshStock(ctx, tx, item.BookId)
+		if err != nil {
+			log.Error().Err(err).Str("layer", "usecase").Int64("userID", userID).Int64("bookID", item.BookId).Msg("failed to update stock")
 
-		return domain.OrderResponse{}, baseErr.NewInternalServerError("failed to update stock")
+			return domain.OrderResponse{}, baseErr.NewInternalServerError("failed to update stock")
+		}
+
+		// the new items already count as reserved, more reservations than copies on the shelf means we ran out.
+		// copies on the shelf belong to the hold queue first
+		if !claimed[item.BookId] && stock.Reserved+stock.Waiting > stock.Available {
+			unavailable = append(unavailable, item.BookTitle)
+		}
+	}
+
+	if len(unavailable) > 0 {
+		return domain.OrderResponse{}, baseErr.NewConflictError("not enough stock for: " + strings.Join(unavailable, ", "))
 	}
 
 	if err = tx.Commit(ctx); err != nil {
@@ -165,20 +357,986 @@ func (o *OrderUsecase) CreateOrder(ctx context.Context, userID int64) (orderResp
 	}
 
 	return domain.OrderResponse{
-		Id:            id,
-		OrderNumber:   orderNumberStr,
-		PaymentStatus: "Pending",
-		CreatedAt:     time.Now(),
+		Id:               id,
+		OrderNumber:      orderNumberStr,
+		Status:           domain.OrderPendingPayment,
+		PaymentStatus:    "Pending",
+		Subtotal:         order.Subtotal,
+		Discount:         order.Discount,
+		Total:            order.Total,
+		UserId:           userID,
+		TotalOrderDetail: int64(len(items)),
+		CreatedAt:        time.Now(),
 	}, nil
 
 }
 
-func generateOrderNumber() string {
-	str, _ := helper.GenerateRandomNumberString(10)
+// applyPromotion works out the discount of a promotion code on the items of a new order. The promotion stays
+// locked until the order is committed, so its usage limits hold however many orders take it at once.
+func (o *OrderUsecase) applyPromotion(ctx context.Context, tx pgx.Tx, userID int64, code string, items []*domain.CartItem, subtotal float64) (*domain.PromotionRedemption, error) {
+	promotion, err := o.promotionRepo.GetByCodeForUpdate(ctx, tx, strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil {
+		if errors.Is(err, promotionRepository.ErrPromotionNotFound) {
+			return nil, baseErr.NewNotFoundError("promotion code not found")
+		}
 
-	return "ORD-" + strings.ToUpper(str)
+		log.Error().Err(err).Str("layer", "usecase").Int64("userID", userID).Str("code", code).Msg("failed to get promotion")
+
+		return nil, baseErr.NewInternalServerError("failed to create order")
+	}
+
+	if !promotion.ValidAt(time.Now()) {
+		return nil, baseErr.NewBadRequestError("promotion code is not valid at this time")
+	}
+
+	if subtotal < promotion.MinSubtotal {
+		return nil, baseErr.NewBadRequestError(fmt.Sprintf("promotion code needs an order of at least %.2f", promotion.MinSubtotal))
+	}
+
+	total, byUser, err := o.promotionRepo.CountRedemptions(ctx, tx, promotion.Id, userID)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("userID", userID).Int64("promotionID", promotion.Id).Msg("failed to count promotion redemptions")
+
+		return nil, baseErr.NewInternalServerError("failed to create order")
+	}
+
+	if promotion.UsageLimit != nil && total >= *promotion.UsageLimit {
+		return nil, baseErr.NewConflictError("promotion code has been used up")
+	}
+
+	if promotion.PerUserLimit != nil && byUser >= *promotion.PerUserLimit {
+		return nil, baseErr.NewBadRequestError("promotion code was already used as often as allowed")
+	}
+
+	if promotion.FirstOrderOnly {
+		placed, err := o.orderRepo.HasPlacedOrder(ctx, tx, userID)
+		if err != nil {
+			log.Error().Err(err).Str("layer", "usecase").Int64("userID", userID).Msg("failed to check for earlier orders")
+
+			return nil, baseErr.NewInternalServerError("failed to create order")
+		}
+
+		if placed {
+			return nil, baseErr.NewBadRequestError("promotion code is only for a first order")
+		}
+	}
+
+	eligible := subtotal
+
+	if promotion.Scoped() {
+		bookIDs := make([]int64, 0, len(items))
+
+		for _, item := range items {
+			bookIDs = append(bookIDs, item.BookId)
+		}
+
+		inScope, err := o.promotionRepo.EligibleBooks(ctx, tx, promotion.Id, bookIDs)
+		if err != nil {
+			log.Error().Err(err).Str("layer", "usecase").Int64("userID", userID).Int64("promotionID", promotion.Id).Msg("failed to get books in promotion scope")
+
+			return nil, baseErr.NewInternalServerError("failed to create order")
+		}
+
+		eligible = 0
+
+		for _, item := range items {
+			if slices.Contains(inScope, item.BookId) {
+				eligible += item.Fee
+			}
+		}
+	}
+
+	if eligible <= 0 {
+		return nil, baseErr.NewBadRequestError("promotion code does not apply to any book in the cart")
+	}
+
+	return &domain.PromotionRedemption{
+		PromotionID: promotion.Id,
+		UserID:      userID,
+		Discount:    promotion.DiscountFor(eligible),
+	}, nil
+}
+
+// Fulfill implements domain.OrderUsecase.
+func (o *OrderUsecase) Fulfill(ctx context.Context, input *domain.FulfillOrderRequest) (resp domain.FulfillOrderResponse, err error) {
+	tx, err := o.orderRepo.GetTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", input.OrderID).Msg("failed to begin transaction")
+
+		return resp, baseErr.NewInternalServerError("failed to fulfill order")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	order, err := o.orderRepo.GetForUpdate(ctx, tx, input.OrderID)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderNotFound) {
+			return resp, baseErr.NewNotFoundError("order not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", input.OrderID).Msg("failed to get order")
+
+		return resp, baseErr.NewInternalServerError("failed to fulfill order")
+	}
+
+	if order.Status != domain.OrderPaid && order.Status != domain.OrderReadyForPickup {
+		return resp, baseErr.NewConflictError(fmt.Sprintf("order is %s and can not be handed out", order.Status))
+	}
+
+	bookCopy, err := o.copyRepo.GetByBarcodeForUpdate(ctx, tx, input.Barcode)
+	if err != nil {
+		if errors.Is(err, bookRepository.ErrBookCopyNotFound) {
+			return resp, baseErr.NewNotFoundError("book copy not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Str("barcode", input.Barcode).Msg("failed to get book copy")
+
+		return resp, baseErr.NewInternalServerError("failed to fulfill order")
+	}
+
+	if bookCopy.Status != domain.BookCopyAvailable {
+		return resp, baseErr.NewConflictError("book copy is not available")
+	}
+
+	detailID, err := o.orderRepo.BindCopy(ctx, tx, order.Id, bookCopy.BookID, bookCopy.Id)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderDetailNotFound) {
+			return resp, baseErr.NewConflictError("order has no item waiting for this book")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", order.Id).Msg("failed to bind book copy")
+
+		return resp, baseErr.NewInternalServerError("failed to fulfill order")
+	}
+
+	if err = o.copyRepo.SetStatus(ctx, tx, bookCopy.Id, domain.BookCopyOnLoan); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("copyID", bookCopy.Id).Msg("failed to lend book copy")
+
+		return resp, baseErr.NewInternalServerError("failed to fulfill order")
+	}
+
+	if _, err = o.copyRepo.RefreshStock(ctx, tx, bookCopy.BookID); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("bookID", bookCopy.BookID).Msg("failed to update stock")
+
+		return resp, baseErr.NewInternalServerError("failed to fulfill order")
+	}
+
+	counts, err := o.orderRepo.CountItems(ctx, tx, order.Id)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", order.Id).Msg("failed to count order items")
+
+		return resp, baseErr.NewInternalServerError("failed to fulfill order")
+	}
+
+	if counts.Bound == counts.Total {
+		if err = o.orderRepo.Transition(ctx, tx, order.Id, domain.OrderOnLoan, &input.StaffID, "all items handed out"); err != nil {
+			log.Error().Err(err).Str("layer", "usecase").Int64("orderID", order.Id).Msg("failed to move order on loan")
+
+			return resp, baseErr.NewInternalServerError("failed to fulfill order")
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", order.Id).Msg("failed to commit transaction")
+
+		return resp, baseErr.NewInternalServerError("failed to commit transaction")
+	}
+
+	return domain.FulfillOrderResponse{
+		OrderDetailID: detailID,
+		BookId:        bookCopy.BookID,
+		BookCopyID:    bookCopy.Id,
+		Barcode:       bookCopy.Barcode,
+	}, nil
+}
+
+// CheckIn implements domain.OrderUsecase.
+func (o *OrderUsecase) CheckIn(ctx context.Context, input *domain.CheckInRequest) (resp domain.CheckInResponse, err error) {
+	tx, err := o.orderRepo.GetTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to begin transaction")
+
+		return resp, baseErr.NewInternalServerError("failed to check in item")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	var detail *domain.OrderDetail
+
+	if input.OrderDetailID != 0 {
+		detail, err = o.orderRepo.GetOrderDetail(ctx, tx, input.OrderDetailID)
+	} else {
+		detail, err = o.orderRepo.GetOpenLoanByBarcode(ctx, tx, input.Barcode)
+	}
+
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderDetailNotFound) {
+			return resp, baseErr.NewNotFoundError("no open loan found for this item")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderDetailID", input.OrderDetailID).Str("barcode", input.Barcode).Msg("failed to get order detail")
+
+		return resp, baseErr.NewInternalServerError("failed to check in item")
+	}
+
+	if detail.BookCopyID == nil {
+		return resp, baseErr.NewConflictError("item has not been handed out")
+	}
+
+	// same lock order as Fulfill: order, copy, then the book through the stock recount
+	if _, err = o.orderRepo.GetForUpdate(ctx, tx, detail.OrderId); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", detail.OrderId).Msg("failed to get order")
+
+		return resp, baseErr.NewInternalServerError("failed to check in item")
+	}
+
+	bookCopy, err := o.copyRepo.GetForUpdate(ctx, tx, detail.BookId, *detail.BookCopyID)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("copyID", *detail.BookCopyID).Msg("failed to get book copy")
+
+		return resp, baseErr.NewInternalServerError("failed to check in item")
+	}
+
+	returnedAt, err := o.orderRepo.MarkReturned(ctx, tx, detail.Id)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderDetailNotFound) {
+			return resp, baseErr.NewConflictError("item is already returned")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderDetailID", detail.Id).Msg("failed to mark item returned")
+
+		return resp, baseErr.NewInternalServerError("failed to check in item")
+	}
+
+	// a copy written off as lost while on loan counts as found again
+	if err = o.copyRepo.SetStatus(ctx, tx, bookCopy.Id, domain.BookCopyAvailable); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("copyID", bookCopy.Id).Msg("failed to shelve book copy")
+
+		return resp, baseErr.NewInternalServerError("failed to check in item")
+	}
+
+	stock, err := o.copyRepo.RefreshStock(ctx, tx, detail.BookId)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("bookID", detail.BookId).Msg("failed to update stock")
+
+		return resp, baseErr.NewInternalServerError("failed to check in item")
+	}
+
+	counts, err := o.orderRepo.CountItems(ctx, tx, detail.OrderId)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", detail.OrderId).Msg("failed to count order items")
+
+		return resp, baseErr.NewInternalServerError("failed to check in item")
+	}
+
+	completed := counts.Returned == counts.Total
+
+	if completed {
+		if err = o.orderRepo.Transition(ctx, tx, detail.OrderId, domain.OrderReturned, &input.StaffID, "all items returned"); err != nil {
+			log.Error().Err(err).Str("layer", "usecase").Int64("orderID", detail.OrderId).Msg("failed to complete order")
+
+			return resp, baseErr.NewInternalServerError("failed to check in item")
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", detail.OrderId).Msg("failed to commit transaction")
+
+		return resp, baseErr.NewInternalServerError("failed to commit transaction")
+	}
+
+	tasks.StockChanged(ctx, o.taskDistributor, detail.BookId, stock)
+
+	return domain.CheckInResponse{
+		OrderDetailID:  detail.Id,
+		OrderId:        detail.OrderId,
+		BookId:         detail.BookId,
+		BookCopyID:     bookCopy.Id,
+		Barcode:        bookCopy.Barcode,
+		ReturnedAt:     returnedAt,
+		Late:           detail.ReturnDate != nil && returnedAt.After(*detail.ReturnDate),
+		OrderCompleted: completed,
+	}, nil
+}
+
+// Cancel implements domain.OrderUsecase.
+func (o *OrderUsecase) Cancel(ctx context.Context, orderID, userID int64) (domain.OrderResponse, error) {
+	order, err := o.closeUnpaid(ctx, orderID, &userID, domain.OrderCancelled, "cancelled by borrower")
+	if err != nil {
+		return domain.OrderResponse{}, err
+	}
+
+	return domain.OrderResponse{
+		Id:            order.Id,
+		OrderNumber:   order.OrderNumber,
+		Status:        domain.OrderCancelled,
+		PaymentStatus: "Failed",
+		Subtotal:      order.Subtotal,
+		Discount:      order.Discount,
+		Total:         order.Total,
+		UserId:        order.UserId,
+		CreatedAt:     order.CreatedAt,
+	}, nil
 }
 
-func NewOrderUsecase(orderRepo domain.OrderRepository) domain.OrderUsecase {
-	return &OrderUsecase{orderRepo: orderRepo}
+// ExpireUnpaid implements domain.OrderUsecase. An order that fails is left for the next run, it may have been
+// paid in the meantime or the gateway may be unreachable.
+func (o *OrderUsecase) ExpireUnpaid(ctx context.Context) error {
+	ids, err := o.orderRepo.FetchUnpaidBefore(ctx, time.Now().Add(-o.paymentWindow))
+	if err != nil {
+		return fmt.Errorf("failed to get unpaid orders: %w", err)
+	}
+
+	expired := 0
+
+	for _, id := range ids {
+		if _, err := o.closeUnpaid(ctx, id, nil, domain.OrderExpired, fmt.Sprintf("not paid within %s", o.paymentWindow)); err != nil {
+			log.Error().Err(err).Str("layer", "usecase").Int64("orderID", id).Msg("failed to expire unpaid order")
+
+			continue
+		}
+
+		expired++
+	}
+
+	log.Info().Str("layer", "usecase").Int("expired", expired).Int("unpaid", len(ids)).Msg("unpaid orders expired")
+
+	return nil
+}
+
+// closeUnpaid moves an order waiting for payment to cancelled or expired, recounts the stock of its books and voids
+// the gateway transaction in one go. userID is the owner the order must belong to, nil when the system closes it
+func (o *OrderUsecase) closeUnpaid(ctx context.Context, orderID int64, userID *int64, to, reason string) (order *domain.Order, err error) {
+	tx, err := o.orderRepo.GetTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", orderID).Msg("failed to begin transaction")
+
+		return nil, baseErr.NewInternalServerError("failed to cancel order")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	order, err = o.orderRepo.GetForUpdate(ctx, tx, orderID)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderNotFound) {
+			return nil, baseErr.NewNotFoundError("order not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", orderID).Msg("failed to get order")
+
+		return nil, baseErr.NewInternalServerError("failed to cancel order")
+	}
+
+	if userID != nil && order.UserId != *userID {
+		return nil, baseErr.NewNotFoundError("order not found")
+	}
+
+	if order.Status != domain.OrderPendingPayment {
+		return nil, baseErr.NewConflictError(fmt.Sprintf("order is %s, only orders waiting for payment can be cancelled", order.Status))
+	}
+
+	released, err := o.releaseUnpaid(ctx, tx, order, to, userID, reason)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", order.Id).Str("status", to).Msg("failed to close order")
+
+		return nil, baseErr.NewInternalServerError("failed to cancel order")
+	}
+
+	// voided last so a gateway that refuses, e.g. because the payment just settled, leaves the order untouched
+	if err = o.payments.CancelTransaction(ctx, order.OrderNumber); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Str("orderNumber", order.OrderNumber).Msg("failed to cancel payment")
+
+		return nil, baseErr.NewConflictError("the payment of this order could not be cancelled, check its payment status")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", order.Id).Msg("failed to commit transaction")
+
+		return nil, baseErr.NewInternalServerError("failed to commit transaction")
+	}
+
+	released()
+
+	return order, nil
+}
+
+// CloseByGateway implements domain.OrderUsecase. The transaction is already closed at the gateway, so unlike
+// closeUnpaid there is nothing to void.
+func (o *OrderUsecase) CloseByGateway(ctx context.Context, orderID int64, to, reason string) (err error) {
+	tx, err := o.orderRepo.GetTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	// the lock makes a notification and a poll arriving together apply one after the other
+	order, err := o.orderRepo.GetForUpdate(ctx, tx, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to get order: %w", err)
+	}
+
+	if order.Status != domain.OrderPendingPayment {
+		return tx.Commit(ctx)
+	}
+
+	released, err := o.releaseUnpaid(ctx, tx, order, to, nil, reason)
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	released()
+
+	return nil
+}
+
+// releaseUnpaid moves an order waiting for payment to cancelled or expired inside tx and recounts the stock of
+// its books. The returned func offers the freed copies to holds and restock subscribers, it is called once tx is
+// committed
+func (o *OrderUsecase) releaseUnpaid(ctx context.Context, tx pgx.Tx, order *domain.Order, to string, actorID *int64, reason string) (func(), error) {
+	bookIDs, err := o.orderRepo.FetchBookIDs(ctx, tx, order.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order books: %w", err)
+	}
+
+	if err := o.orderRepo.Transition(ctx, tx, order.Id, to, actorID, reason); err != nil {
+		return nil, err
+	}
+
+	// the items of a closed order no longer count as reserved, the recount puts them back on the shelf
+	stocks := make(map[int64]domain.BookStock, len(bookIDs))
+
+	for _, bookID := range bookIDs {
+		stocks[bookID], err = o.copyRepo.RefreshStock(ctx, tx, bookID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update stock of book %d: %w", bookID, err)
+		}
+	}
+
+	return func() {
+		for bookID, stock := range stocks {
+			tasks.StockChanged(ctx, o.taskDistributor, bookID, stock)
+		}
+	}, nil
+}
+
+// MarkReadyForPickup implements domain.OrderUsecase.
+func (o *OrderUsecase) MarkReadyForPickup(ctx context.Context, orderID, staffID int64) (resp domain.OrderResponse, err error) {
+	tx, err := o.orderRepo.GetTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", orderID).Msg("failed to begin transaction")
+
+		return resp, baseErr.NewInternalServerError("failed to update order")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	err = o.orderRepo.Transition(ctx, tx, orderID, domain.OrderReadyForPickup, &staffID, "copies set aside at the desk")
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrOrderNotFound):
+			return resp, baseErr.NewNotFoundError("order not found")
+		case errors.Is(err, domain.ErrOrderTransition):
+			return resp, baseErr.NewConflictError(err.Error())
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", orderID).Msg("failed to mark order ready for pickup")
+
+		return resp, baseErr.NewInternalServerError("failed to update order")
+	}
+
+	order, err := o.orderRepo.GetForUpdate(ctx, tx, orderID)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", orderID).Msg("failed to get order")
+
+		return resp, baseErr.NewInternalServerError("failed to update order")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", orderID).Msg("failed to commit transaction")
+
+		return resp, baseErr.NewInternalServerError("failed to commit transaction")
+	}
+
+	return domain.OrderResponse{
+		Id:            order.Id,
+		OrderNumber:   order.OrderNumber,
+		Status:        order.Status,
+		PaymentStatus: order.PaymentStatus,
+		Subtotal:      order.Subtotal,
+		Discount:      order.Discount,
+		Total:         order.Total,
+		PaymentDate:   order.PaymentDate,
+		CompletedAt:   order.CompletedAt,
+		UserId:        order.UserId,
+		CreatedAt:     order.CreatedAt,
+	}, nil
+}
+
+// Refund implements domain.OrderUsecase. A refund the gateway turns down is still written to the ledger, after the
+// order is unlocked since the ledger row points at it.
+func (o *OrderUsecase) Refund(ctx context.Context, input *domain.RefundOrderRequest) (domain.RefundOrderResponse, error) {
+	resp, failed, err := o.refund(ctx, input)
+
+	if failed != nil {
+		if err := o.paymentRepo.StoreTransaction(ctx, failed); err != nil {
+			log.Error().Err(err).Str("layer", "usecase").Int64("orderID", input.OrderID).Msg("failed to record refund")
+		}
+	}
+
+	if err != nil {
+		return resp, err
+	}
+
+	email, name, errContact := o.orderRepo.GetOwnerContact(ctx, resp.OrderID)
+	if errContact != nil {
+		log.Error().Err(errContact).Str("layer", "usecase").Int64("orderID", resp.OrderID).Msg("failed to get order owner")
+
+		return resp, nil
+	}
+
+	errNotify := o.taskDistributor.DistributeTaskSendNotification(ctx, &tasks.PayloadSendNotification{
+		Email:   email,
+		Name:    name,
+		Subject: "Your payment was refunded",
+		Message: fmt.Sprintf("We refunded %.0f of your payment for order %s: %s", resp.Amount, resp.OrderNumber, input.Reason),
+	})
+
+	if errNotify != nil {
+		log.Error().Err(errNotify).Str("layer", "usecase").Int64("orderID", resp.OrderID).Msg("failed to enqueue refund notification")
+	}
+
+	return resp, nil
+}
+
+// refund does the work of Refund in one transaction, with the order locked so two refunds cannot both take the
+// same money. failed is the ledger row to write when the gateway refused.
+func (o *OrderUsecase) refund(ctx context.Context, input *domain.RefundOrderRequest) (resp domain.RefundOrderResponse, failed *domain.PaymentTransaction, err error) {
+	tx, err := o.orderRepo.GetTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", input.OrderID).Msg("failed to begin transaction")
+
+		return resp, nil, baseErr.NewInternalServerError("failed to refund order")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	order, err := o.orderRepo.GetForUpdate(ctx, tx, input.OrderID)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderNotFound) {
+			return resp, nil, baseErr.NewNotFoundError("order not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", input.OrderID).Msg("failed to get order")
+
+		return resp, nil, baseErr.NewInternalServerError("failed to refund order")
+	}
+
+	if order.PaymentStatus != "Paid" {
+		return resp, nil, baseErr.NewConflictError(fmt.Sprintf("order is %s and has no payment to refund", order.Status))
+	}
+
+	count, refunded, err := o.paymentRepo.CountRefunds(ctx, tx, order.Id)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", order.Id).Msg("failed to count refunds")
+
+		return resp, nil, baseErr.NewInternalServerError("failed to refund order")
+	}
+
+	// the gateway deals in whole rupiah
+	remaining := int64(math.Round(order.Total - refunded))
+	amount := remaining
+
+	if input.Amount != nil {
+		amount = int64(math.Round(*input.Amount))
+	}
+
+	if remaining <= 0 {
+		return resp, nil, baseErr.NewConflictError("nothing is left to refund on this order")
+	}
+
+	if amount <= 0 || amount > remaining {
+		return resp, nil, baseErr.NewBadRequestError(fmt.Sprintf("amount must be between 1 and the %d left to refund", remaining))
+	}
+
+	full := amount == remaining
+
+	// a refund in full ends the order, its copies have to be back first
+	if full {
+		if err = domain.CheckOrderTransition(order.Status, domain.OrderRefunded); err != nil {
+			return resp, nil, baseErr.NewConflictError(fmt.Sprintf("order is %s, check its copies in before refunding it in full", order.Status))
+		}
+
+		var counts domain.OrderItemCounts
+
+		counts, err = o.orderRepo.CountItems(ctx, tx, order.Id)
+		if err != nil {
+			log.Error().Err(err).Str("layer", "usecase").Int64("orderID", order.Id).Msg("failed to count order items")
+
+			return resp, nil, baseErr.NewInternalServerError("failed to refund order")
+		}
+
+		if counts.Bound > counts.Returned {
+			return resp, nil, baseErr.NewConflictError("copies of this order are still handed out, check them in before refunding it in full")
+		}
+	}
+
+	transaction, err := o.payments.RefundTransaction(ctx, order.OrderNumber, &domain.GatewayRefund{
+		// the same key for a retry after a failed commit, the gateway then refunds only once
+		RefundKey: fmt.Sprintf("%s-refund-%d", order.OrderNumber, count+1),
+		Amount:    amount,
+		Reason:    input.Reason,
+	})
+
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Str("orderNumber", order.OrderNumber).Int64("amount", amount).Msg("failed to refund payment")
+
+		payload, _ := json.Marshal(map[string]string{"error": err.Error()})
+
+		return resp, &domain.PaymentTransaction{
+			OrderId:    order.Id,
+			Gateway:    o.payments.Name(),
+			Source:     domain.PaymentSourceRefund,
+			Amount:     float64(amount),
+			Currency:   "IDR",
+			Status:     domain.TransactionError,
+			RawPayload: payload,
+		}, baseErr.NewBadGatewayError("the payment gateway refused the refund")
+	}
+
+	ledger := &domain.PaymentTransaction{
+		OrderId:    order.Id,
+		Gateway:    o.payments.Name(),
+		Amount:     float64(amount),
+		Currency:   transaction.Currency,
+		Status:     transaction.TransactionStatus,
+		RawPayload: transaction.Raw,
+	}
+
+	if transaction.TransactionID != "" {
+		ledger.ExternalID = &transaction.TransactionID
+	}
+
+	if ledger.Currency == "" {
+		ledger.Currency = "IDR"
+	}
+
+	if err = o.paymentRepo.RecordRefund(ctx, tx, ledger); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", order.Id).Msg("failed to record refund")
+
+		return resp, nil, baseErr.NewInternalServerError("failed to refund order")
+	}
+
+	status := order.Status
+	stocks := make(map[int64]domain.BookStock)
+
+	if full {
+		if err = o.orderRepo.Transition(ctx, tx, order.Id, domain.OrderRefunded, &input.StaffID, input.Reason); err != nil {
+			log.Error().Err(err).Str("layer", "usecase").Int64("orderID", order.Id).Msg("failed to mark order refunded")
+
+			return resp, nil, baseErr.NewInternalServerError("failed to refund order")
+		}
+
+		// the items never handed out keep the due date set at payment
+		if err = o.orderRepo.CloseUnboundDetails(ctx, tx, order.Id); err != nil {
+			log.Error().Err(err).Str("layer", "usecase").Int64("orderID", order.Id).Msg("failed to close order details")
+
+			return resp, nil, baseErr.NewInternalServerError("failed to refund order")
+		}
+
+		// a paid order still holds its books, they go back on the shelf
+		if order.Status == domain.OrderPaid || order.Status == domain.OrderReadyForPickup {
+			var bookIDs []int64
+
+			bookIDs, err = o.orderRepo.FetchBookIDs(ctx, tx, order.Id)
+			if err != nil {
+				log.Error().Err(err).Str("layer", "usecase").Int64("orderID", order.Id).Msg("failed to get order books")
+
+				return resp, nil, baseErr.NewInternalServerError("failed to refund order")
+			}
+
+			for _, bookID := range bookIDs {
+				stocks[bookID], err = o.copyRepo.RefreshStock(ctx, tx, bookID)
+				if err != nil {
+					log.Error().Err(err).Str("layer", "usecase").Int64("bookID", bookID).Msg("failed to update stock")
+
+					return resp, nil, baseErr.NewInternalServerError("failed to refund order")
+				}
+			}
+		}
+
+		status = domain.OrderRefunded
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", order.Id).Msg("failed to commit transaction")
+
+		return resp, nil, baseErr.NewInternalServerError("failed to commit transaction")
+	}
+
+	for bookID, stock := range stocks {
+		tasks.StockChanged(ctx, o.taskDistributor, bookID, stock)
+	}
+
+	return domain.RefundOrderResponse{
+		OrderID:       order.Id,
+		OrderNumber:   order.OrderNumber,
+		Status:        status,
+		Amount:        float64(amount),
+		TotalRefunded: refunded + float64(amount),
+		Remaining:     float64(remaining - amount),
+	}, nil, nil
+}
+
+// Renew implements domain.OrderUsecase.
+func (o *OrderUsecase) Renew(ctx context.Context, orderID, detailID, userID int64) (resp domain.RenewLoanResponse, err error) {
+	tx, err := o.orderRepo.GetTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to begin transaction")
+
+		return resp, baseErr.NewInternalServerError("failed to renew loan")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	order, err := o.orderRepo.GetForUpdate(ctx, tx, orderID)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderNotFound) {
+			return resp, baseErr.NewNotFoundError("order not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", orderID).Msg("failed to get order")
+
+		return resp, baseErr.NewInternalServerError("failed to renew loan")
+	}
+
+	if order.UserId != userID {
+		return resp, baseErr.NewNotFoundError("order not found")
+	}
+
+	detail, err := o.orderRepo.GetOrderDetailForUpdate(ctx, tx, detailID)
+	if err != nil && !errors.Is(err, repository.ErrOrderDetailNotFound) {
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderDetailID", detailID).Msg("failed to get order detail")
+
+		return resp, baseErr.NewInternalServerError("failed to renew loan")
+	}
+
+	if detail == nil || detail.OrderId != order.Id {
+		return resp, baseErr.NewNotFoundError("order item not found")
+	}
+
+	if detail.ReturnedAt != nil {
+		return resp, baseErr.NewConflictError("item is already returned")
+	}
+
+	// the due date is set at payment, only a copy that was handed out is actually on loan
+	if order.Status != domain.OrderOnLoan || detail.BookCopyID == nil || detail.ReturnDate == nil {
+		return resp, baseErr.NewConflictError("item is not on loan")
+	}
+
+	if time.Now().After(*detail.ReturnDate) {
+		return resp, baseErr.NewConflictError("loan is overdue and can no longer be renewed")
+	}
+
+	policy, err := o.policyRepo.ResolveForUser(ctx, userID)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("userID", userID).Msg("failed to resolve lending policy")
+
+		return resp, baseErr.NewInternalServerError("failed to renew loan")
+	}
+
+	if detail.RenewalCount >= policy.MaxRenewals {
+		return resp, baseErr.NewConflictError("renewal limit reached")
+	}
+
+	queued, err := o.holdRepo.HasOthersQueued(ctx, tx, detail.BookId, userID)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("bookID", detail.BookId).Msg("failed to check holds")
+
+		return resp, baseErr.NewInternalServerError("failed to renew loan")
+	}
+
+	if queued {
+		return resp, baseErr.NewConflictError("another reader is waiting for this book")
+	}
+
+	returnDate := detail.ReturnDate.Add(policy.LoanPeriod())
+
+	if err = o.orderRepo.Renew(ctx, tx, detail.Id, returnDate); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderDetailID", detail.Id).Msg("failed to renew loan")
+
+		return resp, baseErr.NewInternalServerError("failed to renew loan")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", order.Id).Msg("failed to commit transaction")
+
+		return resp, baseErr.NewInternalServerError("failed to commit transaction")
+	}
+
+	return domain.RenewLoanResponse{
+		OrderDetailID: detail.Id,
+		ReturnDate:    returnDate,
+		RenewalCount:  detail.RenewalCount + 1,
+		RenewalsLeft:  policy.MaxRenewals - detail.RenewalCount - 1,
+	}, nil
+}
+
+// ProcessOverdueLoans implements domain.OrderUsecase.
+func (o *OrderUsecase) ProcessOverdueLoans(ctx context.Context) error {
+	now := time.Now()
+
+	dueSoon, err := o.orderRepo.MarkDueSoon(ctx, now.Add(o.finePolicy.ReminderBefore))
+	if err != nil {
+		return fmt.Errorf("failed to mark loans due soon: %w", err)
+	}
+
+	for _, n := range dueSoon {
+		o.notifyLoan(ctx, n, "Your loan is due soon",
+			fmt.Sprintf("Please return %q by %s, or renew it if nobody is waiting for it.", n.BookTitle, n.ReturnDate.Format("2 Jan 2006 15:04 MST")))
+	}
+
+	overdue, err := o.orderRepo.MarkOverdue(ctx, now)
+	if err != nil {
+		return fmt.Errorf("failed to mark overdue loans: %w", err)
+	}
+
+	for _, n := range overdue {
+		o.notifyLoan(ctx, n, "Your loan is overdue",
+			fmt.Sprintf("%q was due on %s. A late fee is charged for every day until it is returned.",
+				n.BookTitle, n.ReturnDate.Format("2 Jan 2006 15:04 MST")))
+	}
+
+	accrued, err := o.fineRepo.AccrueLateFees(ctx, now)
+	if err != nil {
+		return fmt.Errorf("failed to accrue late fees: %w", err)
+	}
+
+	log.Info().Str("layer", "usecase").Int("reminded", len(dueSoon)).Int("overdue", len(overdue)).Int64("fees", accrued).Msg("overdue loans processed")
+
+	return nil
+}
+
+// notifyLoan mails the borrower about a loan, the loan is already flagged so a failure is only logged
+func (o *OrderUsecase) notifyLoan(ctx context.Context, n domain.LoanNotice, subject, message string) {
+	err := o.taskDistributor.DistributeTaskSendNotification(ctx, &tasks.PayloadSendNotification{
+		Email:   n.Email,
+		Name:    n.Name,
+		Subject: subject,
+		Message: message,
+	})
+
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderDetailID", n.OrderDetailID).Msg("failed to enqueue loan notification")
+	}
+}
+
+func generateOrderNumber() string {
+	str, _ := helper.GenerateRandomNumberString(10)
+
+	return "ORD-" + strings.ToUpper(str)
+}
+
+func NewOrderUsecase(orderRepo domain.OrderRepository, copyRepo domain.BookCopyRepository, holdRepo domain.BookHoldRepository, fineRepo domain.FineRepository, policyRepo domain.LendingPolicyRepository, paymentRepo domain.PaymentRepository, promotionRepo domain.PromotionRepository, payments domain.PaymentGateway, taskDistributor tasks.TaskDistributor, finePolicy domain.FinePolicy, paymentWindow time.Duration) domain.OrderUsecase {
+	return &OrderUsecase{
+		orderRepo:       orderRepo,
+		copyRepo:        copyRepo,
+		holdRepo:        holdRepo,
+		fineRepo:        fineRepo,
+		policyRepo:      policyRepo,
+		paymentRepo:     paymentRepo,
+		promotionRepo:   promotionRepo,
+		payments:        payments,
+		taskDistributor: taskDistributor,
+		finePolicy:      finePolicy,
+		paymentWindow:   paymentWindow,
+	}
 }
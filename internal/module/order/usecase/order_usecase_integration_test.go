@@ -0,0 +1,264 @@
+package usecase
+
+import (
+	baseErr "backend-layout/internal/adapter/errors"
+	"backend-layout/internal/domain"
+	bookRepository "backend-layout/internal/module/book/repository"
+	fineRepository "backend-layout/internal/module/fine/repository"
+	holdRepository "backend-layout/internal/module/hold/repository"
+	lendingPolicyRepository "backend-layout/internal/module/lendingpolicy/repository"
+	orderRepository "backend-layout/internal/module/order/repository"
+	paymentRepository "backend-layout/internal/module/payment/repository"
+	promotionRepository "backend-layout/internal/module/promotion/repository"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TestCreateOrderConcurrentNoOversell races more orders than there are copies of one book. It needs a migrated
+// database in TEST_DATABASE_URL and cleans up what it creates.
+func TestCreateOrderConcurrentNoOversell(t *testing.T) {
+	const (
+		copies = 3
+		buyers = 12
+	)
+
+	ctx := context.Background()
+	pool := connectTestDatabase(t, ctx)
+
+	title := fmt.Sprintf("Oversell Test %d", time.Now().UnixNano())
+	bookID, userIDs := seedOversell(t, ctx, pool, title, copies, buyers)
+
+	orders := newPostgresOrderUsecase(pool)
+
+	// in_stock is sampled for as long as the orders run
+	var negative atomic.Bool
+
+	stop := make(chan struct{})
+	sampled := make(chan struct{})
+
+	go func() {
+		defer close(sampled)
+
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			var inStock int
+			if err := pool.QueryRow(ctx, `SELECT in_stock FROM books WHERE id = $1;`, bookID).Scan(&inStock); err == nil && inStock < 0 {
+				negative.Store(true)
+			}
+		}
+	}()
+
+	var (
+		wg        sync.WaitGroup
+		succeeded atomic.Int32
+		conflicts atomic.Int32
+		start     = make(chan struct{})
+		unexpect  = make(chan error, buyers)
+	)
+
+	for _, userID := range userIDs {
+		wg.Add(1)
+
+		go func(userID int64) {
+			defer wg.Done()
+
+			<-start
+
+			_, err := orders.CreateOrder(ctx, &domain.CreateOrderRequest{UserID: userID})
+
+			var be baseErr.BaseError
+
+			switch {
+			case err == nil:
+				succeeded.Add(1)
+			case errors.As(err, &be) && be.Code == http.StatusConflict && strings.Contains(be.Message, title):
+				conflicts.Add(1)
+			default:
+				unexpect <- err
+			}
+		}(userID)
+	}
+
+	close(start)
+	wg.Wait()
+	close(stop)
+	<-sampled
+	close(unexpect)
+
+	for err := range unexpect {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if got := succeeded.Load(); got != copies {
+		t.Errorf("succeeded orders = %d, want %d", got, copies)
+	}
+
+	if got := conflicts.Load(); got != buyers-copies {
+		t.Errorf("conflicting orders = %d, want %d", got, buyers-copies)
+	}
+
+	if negative.Load() {
+		t.Error("in_stock went below 0")
+	}
+
+	var inStock, reserved int
+
+	err := pool.QueryRow(ctx, `SELECT b.in_stock, (SELECT COUNT(1) FROM order_details WHERE book_id = b.id) FROM books b WHERE b.id = $1;`, bookID).
+		Scan(&inStock, &reserved)
+	if err != nil {
+		t.Fatalf("read stock: %v", err)
+	}
+
+	if inStock != 0 || reserved != copies {
+		t.Errorf("in_stock = %d, reserved = %d, want 0 and %d", inStock, reserved, copies)
+	}
+}
+
+// TestCreateOrderClaimsReadyHold orders the copy set aside for the user's hold. The book is out of stock for
+// everyone else, the order must still take it from the cart and claim the hold.
+func TestCreateOrderClaimsReadyHold(t *testing.T) {
+	ctx := context.Background()
+	pool := connectTestDatabase(t, ctx)
+
+	title := fmt.Sprintf("Ready Hold Test %d", time.Now().UnixNano())
+	bookID, userIDs := seedOversell(t, ctx, pool, title, 1, 1)
+
+	_, err := pool.Exec(ctx, `WITH held AS (
+				UPDATE book_copies SET status = 'on_hold' WHERE book_id = $1 RETURNING id
+			  )
+			  INSERT INTO book_holds (book_id, user_id, status, book_copy_id, ready_at, expires_at)
+			  SELECT $1, $2, 'ready', id, now(), now() + interval '1 day' FROM held;`, bookID, userIDs[0])
+	if err != nil {
+		t.Fatalf("seed hold: %v", err)
+	}
+
+	if _, err := pool.Exec(ctx, `UPDATE books SET in_stock = 0 WHERE id = $1;`, bookID); err != nil {
+		t.Fatalf("seed stock: %v", err)
+	}
+
+	if _, err := newPostgresOrderUsecase(pool).CreateOrder(ctx, &domain.CreateOrderRequest{UserID: userIDs[0]}); err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+
+	var status string
+	var inCart int
+
+	err = pool.QueryRow(ctx, `SELECT (SELECT status FROM book_holds WHERE book_id = $1 AND user_id = $2),
+				(SELECT COUNT(1) FROM carts WHERE book_id = $1 AND user_id = $2);`, bookID, userIDs[0]).Scan(&status, &inCart)
+	if err != nil {
+		t.Fatalf("read hold: %v", err)
+	}
+
+	if status != "claimed" || inCart != 0 {
+		t.Errorf("hold = %s, in cart = %d, want claimed and 0", status, inCart)
+	}
+}
+
+// connectTestDatabase skips the test unless TEST_DATABASE_URL points to a migrated database
+func connectTestDatabase(t *testing.T, ctx context.Context) *pgxpool.Pool {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+
+	// registered first so it runs after the cleanup of the seed
+	t.Cleanup(pool.Close)
+
+	return pool
+}
+
+func newPostgresOrderUsecase(pool *pgxpool.Pool) domain.OrderUsecase {
+	return NewOrderUsecase(orderRepository.NewPostgresOrderRepository(pool), bookRepository.NewPostgresBookCopyRepository(pool),
+		holdRepository.NewPostgresBookHoldRepository(pool), fineRepository.NewPostgresFineRepository(pool),
+		lendingPolicyRepository.NewPostgresLendingPolicyRepository(pool), paymentRepository.NewPostgresPaymentRepository(pool),
+		promotionRepository.NewPostgresPromotionRepository(pool), nil, nil, domain.FinePolicy{BlockThreshold: 1e9}, time.Hour)
+}
+
+func seedOversell(t *testing.T, ctx context.Context, pool *pgxpool.Pool, title string, copies, buyers int) (int64, []int64) {
+	t.Helper()
+
+	var authorID, publisherID, bookID int64
+
+	if err := pool.QueryRow(ctx, `INSERT INTO authors (name) VALUES ($1) RETURNING id;`, title).Scan(&authorID); err != nil {
+		t.Fatalf("seed author: %v", err)
+	}
+
+	if err := pool.QueryRow(ctx, `INSERT INTO publishers (name) VALUES ($1) RETURNING id;`, title).Scan(&publisherID); err != nil {
+		t.Fatalf("seed publisher: %v", err)
+	}
+
+	err := pool.QueryRow(ctx, `INSERT INTO books (title, slug, author_id, publisher_id, publish_year, total_page, sku, isbn, price, total_stock, in_stock)
+			  VALUES ($1, $1, $2, $3, 2024, 100, $1, $1, 10000, $4, $4)
+			  RETURNING id;`, title, authorID, publisherID, copies).Scan(&bookID)
+	if err != nil {
+		t.Fatalf("seed book: %v", err)
+	}
+
+	_, err = pool.Exec(ctx, `INSERT INTO book_copies (book_id, barcode)
+			  SELECT $1, format('%s-%s', $2::TEXT, n) FROM generate_series(1, $3) AS n;`, bookID, title, copies)
+	if err != nil {
+		t.Fatalf("seed copies: %v", err)
+	}
+
+	userIDs := make([]int64, 0, buyers)
+
+	for i := 0; i < buyers; i++ {
+		var userID int64
+
+		err := pool.QueryRow(ctx, `INSERT INTO users (name, email, password) VALUES ($1, $2, 'x') RETURNING id;`,
+			title, fmt.Sprintf("%d-%s@example.com", i, strings.ReplaceAll(title, " ", "-"))).Scan(&userID)
+		if err != nil {
+			t.Fatalf("seed user: %v", err)
+		}
+
+		if _, err := pool.Exec(ctx, `INSERT INTO carts (user_id, book_id, quantity) VALUES ($1, $2, 1);`, userID, bookID); err != nil {
+			t.Fatalf("seed cart: %v", err)
+		}
+
+		userIDs = append(userIDs, userID)
+	}
+
+	t.Cleanup(func() {
+		statements := []struct {
+			query string
+			arg   any
+		}{
+			{`DELETE FROM order_details WHERE book_id = $1;`, bookID},
+			{`DELETE FROM orders WHERE user_id = ANY($1);`, userIDs},
+			{`DELETE FROM users WHERE id = ANY($1);`, userIDs},
+			{`DELETE FROM books WHERE id = $1;`, bookID},
+			{`DELETE FROM authors WHERE id = $1;`, authorID},
+			{`DELETE FROM publishers WHERE id = $1;`, publisherID},
+		}
+
+		for _, s := range statements {
+			if _, err := pool.Exec(ctx, s.query, s.arg); err != nil {
+				t.Errorf("cleanup: %v", err)
+			}
+		}
+	})
+
+	return bookID, userIDs
+}
@@ -3,7 +3,10 @@ package http
 import (
 	"backend-layout/internal/domain"
 	"backend-layout/internal/httpcontext"
+	"backend-layout/internal/middleware"
+	"bytes"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 
@@ -14,16 +17,22 @@ type PaymentHandler struct {
 	paymentUsecase domain.PaymentUsecase
 }
 
-func NewPaymentHandler(r *echo.Group, pu domain.PaymentUsecase) {
+func NewPaymentHandler(p *echo.Group, r *echo.Group, pu domain.PaymentUsecase, rbac *middleware.RBACMiddleware, idempotency *middleware.IdempotencyMiddleware) {
 	handler := &PaymentHandler{paymentUsecase: pu}
 
-	r.POST("/payment", handler.CreatePayment)
+	// called by Midtrans itself, the signature of the body stands in for a login
+	p.POST("/payment/notifications/midtrans", handler.MidtransNotification)
+
+	r.POST("/payment", handler.CreatePayment, idempotency.Idempotent())
 	r.GET("/payment/status/:order_id", handler.PaymentStatus)
+	r.GET("/admin/orders/:id/payments", handler.Transactions, rbac.RequiredPermission("payment:view"))
+	r.GET("/admin/payment-reconciliations", handler.Reconciliations, rbac.RequiredPermission("payment:view"))
+	r.GET("/admin/payment-reconciliations/:id", handler.Reconciliation, rbac.RequiredPermission("payment:view"))
+	r.GET("/admin/payment-reconciliations/:id/download", handler.DownloadReconciliation, rbac.RequiredPermission("payment:view"))
 }
 
 func (h *PaymentHandler) CreatePayment(c echo.Context) error {
 	req := new(domain.PaymentRequest)
-	req.Amount = 100000
 
 	userJWT, ok := httpcontext.GetUserJWT(c)
 
@@ -35,13 +44,13 @@ func (h *PaymentHandler) CreatePayment(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusUnauthorized, "user not found")
 	}
 
-	req.UserId = userJWT.ID
-	req.Email = userJWT.Email
-
 	if err := c.Bind(req); err != nil {
 		return err
 	}
 
+	req.UserId = userJWT.ID
+	req.Email = userJWT.Email
+
 	if err := c.Validate(req); err != nil {
 		return err
 	}
@@ -84,3 +93,85 @@ func (h *PaymentHandler) PaymentStatus(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, resp)
 }
+
+func (h *PaymentHandler) MidtransNotification(c echo.Context) error {
+	req := new(domain.PaymentNotification)
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to read request body")
+	}
+
+	c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	req.Raw = body
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	if err := h.paymentUsecase.HandleNotification(c.Request().Context(), req); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "notification processed"})
+}
+
+func (h *PaymentHandler) Transactions(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid order ID format")
+	}
+
+	resp, err := h.paymentUsecase.FetchTransactions(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *PaymentHandler) Reconciliations(c echo.Context) error {
+	resp, err := h.paymentUsecase.FetchReconciliations(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *PaymentHandler) Reconciliation(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid reconciliation ID format")
+	}
+
+	resp, err := h.paymentUsecase.GetReconciliation(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *PaymentHandler) DownloadReconciliation(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid reconciliation ID format")
+	}
+
+	report, err := h.paymentUsecase.DownloadReconciliation(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	defer report.Close()
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=payment-reconciliation-%d.csv", id))
+
+	return c.Stream(http.StatusOK, "text/csv", report)
+}
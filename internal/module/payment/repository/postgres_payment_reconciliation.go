@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"backend-layout/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrPaymentReconciliationNotFound = errors.New("payment reconciliation not found")
+)
+
+type postgresPaymentReconciliationRepository struct {
+	conn *pgxpool.Pool
+}
+
+// Store implements domain.PaymentReconciliationRepository.
+func (p *postgresPaymentReconciliationRepository) Store(ctx context.Context, r *domain.PaymentReconciliation) (id int64, err error) {
+	query := `INSERT INTO payment_reconciliations (gateway, status, since)
+			  VALUES ($1, $2, $3)
+			  RETURNING id;`
+
+	err = p.conn.QueryRow(ctx, query, r.Gateway, r.Status, r.Since).Scan(&id)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert payment reconciliation: %w", err)
+	}
+
+	return
+}
+
+// GetByID implements domain.PaymentReconciliationRepository.
+func (p *postgresPaymentReconciliationRepository) GetByID(ctx context.Context, id int64) (*domain.PaymentReconciliation, error) {
+	query := `SELECT id, gateway, status, since, checked, fixed, discrepancies, file_key, error_message, created_at, updated_at
+			  FROM payment_reconciliations
+			  WHERE id = $1;`
+
+	var r domain.PaymentReconciliation
+
+	err := p.conn.QueryRow(ctx, query, id).Scan(&r.Id, &r.Gateway, &r.Status, &r.Since, &r.Checked, &r.Fixed,
+		&r.Discrepancies, &r.FileKey, &r.ErrorMessage, &r.CreatedAt, &r.UpdatedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPaymentReconciliationNotFound
+		}
+
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// Fetch implements domain.PaymentReconciliationRepository.
+func (p *postgresPaymentReconciliationRepository) Fetch(ctx context.Context, limit int) ([]domain.PaymentReconciliation, error) {
+	query := `SELECT id, gateway, status, since, checked, fixed, discrepancies, file_key, error_message, created_at, updated_at
+			  FROM payment_reconciliations
+			  ORDER BY created_at DESC, id DESC
+			  LIMIT $1;`
+
+	rows, err := p.conn.Query(ctx, query, limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := make([]domain.PaymentReconciliation, 0)
+
+	for rows.Next() {
+		r := domain.PaymentReconciliation{}
+
+		if err := rows.Scan(&r.Id, &r.Gateway, &r.Status, &r.Since, &r.Checked, &r.Fixed,
+			&r.Discrepancies, &r.FileKey, &r.ErrorMessage, &r.CreatedAt, &r.UpdatedAt); err != nil {
+			return nil, err
+		}
+
+		result = append(result, r)
+	}
+
+	return result, rows.Err()
+}
+
+// SetStatus implements domain.PaymentReconciliationRepository.
+func (p *postgresPaymentReconciliationRepository) SetStatus(ctx context.Context, id int64, status string, errorMessage *string) error {
+	query := `UPDATE payment_reconciliations SET status = $1, error_message = $2, updated_at = now() WHERE id = $3;`
+
+	return p.exec(ctx, query, status, errorMessage, id)
+}
+
+// Complete implements domain.PaymentReconciliationRepository.
+func (p *postgresPaymentReconciliationRepository) Complete(ctx context.Context, id int64, fileKey string, checked, fixed, discrepancies int) error {
+	query := `UPDATE payment_reconciliations
+			  SET status = $1, file_key = $2, checked = $3, fixed = $4, discrepancies = $5, updated_at = now()
+			  WHERE id = $6;`
+
+	return p.exec(ctx, query, domain.ReconciliationStatusCompleted, fileKey, checked, fixed, discrepancies, id)
+}
+
+func (p *postgresPaymentReconciliationRepository) exec(ctx context.Context, query string, args ...any) error {
+	row, err := p.conn.Exec(ctx, query, args...)
+
+	if err != nil {
+		return err
+	}
+
+	if row.RowsAffected() == 0 {
+		return ErrPaymentReconciliationNotFound
+	}
+
+	return nil
+}
+
+func NewPostgresPaymentReconciliationRepository(conn *pgxpool.Pool) domain.PaymentReconciliationRepository {
+	return &postgresPaymentReconciliationRepository{conn: conn}
+}
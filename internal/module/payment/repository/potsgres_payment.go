@@ -13,55 +13,98 @@ type PostgresPaymentRepository struct {
 	conn *pgxpool.Pool
 }
 
-// UpdatePaymentStatus implements domain.PaymentRepository.
-func (p *PostgresPaymentRepository) SetPaymentFailed(ctx context.Context, tx pgx.Tx, orderId int64) error {
+// GetTx implements domain.OrderRepository.
+func (p *PostgresPaymentRepository) GetTx(ctx context.Context) (pgx.Tx, error) {
+	tx, err := p.conn.Begin(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// ProcessPayment implements domain.PaymentRepository. The order itself moves to paid through
+// domain.OrderRepository.Transition.
+func (p *PostgresPaymentRepository) ProcessPayment(ctx context.Context, tx pgx.Tx, userId int64, paymentMethod, orderNumber string) error {
 	query := `UPDATE orders 
-	          SET payment_status = 'failure' 
-			  WHERE id = $1 AND payment_status = 'Pending';`
+	          SET payment_date = timezone('UTC', now()), payment_method = $1 
+			  WHERE order_number = $2 AND user_id = $3 AND status = 'pending_payment';`
 
-	cmdTag, err := tx.Exec(ctx, query, orderId)
+	cmdTag, err := tx.Exec(ctx, query, paymentMethod, orderNumber, userId)
 
 	if err != nil {
 		return err
 	}
 
 	if cmdTag.RowsAffected() == 0 {
-		return fmt.Errorf("payment not processed: order not found or already paid")
+		return fmt.Errorf("order already paid")
 	}
 
 	return nil
 }
 
-const paymentStatusPaid = "Paid"
+// StoreTransaction implements domain.PaymentRepository.
+func (p *PostgresPaymentRepository) StoreTransaction(ctx context.Context, t *domain.PaymentTransaction) error {
+	query := `INSERT INTO payment_transactions (order_id, gateway, source, external_id, amount, currency, status, raw_payload)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			  RETURNING id, created_at;`
 
-// GetTx implements domain.OrderRepository.
-func (p *PostgresPaymentRepository) GetTx(ctx context.Context) (pgx.Tx, error) {
-	tx, err := p.conn.Begin(ctx)
+	return p.conn.QueryRow(ctx, query, t.OrderId, t.Gateway, t.Source, t.ExternalID, t.Amount, t.Currency, t.Status, t.RawPayload).
+		Scan(&t.Id, &t.CreatedAt)
+}
+
+// FetchTransactions implements domain.PaymentRepository.
+func (p *PostgresPaymentRepository) FetchTransactions(ctx context.Context, orderID int64) ([]domain.PaymentTransaction, error) {
+	query := `SELECT id, order_id, gateway, source, external_id, amount, currency, status, raw_payload, created_at
+			  FROM payment_transactions
+			  WHERE order_id = $1
+			  ORDER BY created_at, id;`
+
+	rows, err := p.conn.Query(ctx, query, orderID)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return tx, nil
-}
+	defer rows.Close()
 
-// ProcessPayment implements domain.PaymentRepository.
-func (p *PostgresPaymentRepository) ProcessPayment(ctx context.Context, tx pgx.Tx, userId int64, paymentMethod, orderNumber string) error {
-	query := `UPDATE orders 
-	          SET payment_status = $1, payment_date = timezone('UTC', now()), payment_method = $2 
-			  WHERE order_number = $3 AND user_id = $4 AND payment_status = 'Pending';`
+	result := make([]domain.PaymentTransaction, 0)
 
-	cmdTag, err := tx.Exec(ctx, query, paymentStatusPaid, paymentMethod, orderNumber, userId)
+	for rows.Next() {
+		t := domain.PaymentTransaction{}
 
-	if err != nil {
-		return err
-	}
+		if err := rows.Scan(&t.Id, &t.OrderId, &t.Gateway, &t.Source, &t.ExternalID, &t.Amount, &t.Currency, &t.Status, &t.RawPayload, &t.CreatedAt); err != nil {
+			return nil, err
+		}
 
-	if cmdTag.RowsAffected() == 0 {
-		return fmt.Errorf("order already paid")
+		result = append(result, t)
 	}
 
-	return nil
+	return result, rows.Err()
+}
+
+// RecordRefund implements domain.PaymentRepository.
+func (p *PostgresPaymentRepository) RecordRefund(ctx context.Context, tx pgx.Tx, t *domain.PaymentTransaction) error {
+	query := `INSERT INTO payment_transactions (order_id, gateway, source, external_id, amount, currency, status, raw_payload)
+			  VALUES ($1, $2, 'refund', $3, $4, $5, $6, $7)
+			  RETURNING id, created_at;`
+
+	t.Source = domain.PaymentSourceRefund
+
+	return tx.QueryRow(ctx, query, t.OrderId, t.Gateway, t.ExternalID, t.Amount, t.Currency, t.Status, t.RawPayload).
+		Scan(&t.Id, &t.CreatedAt)
+}
+
+// CountRefunds implements domain.PaymentRepository.
+func (p *PostgresPaymentRepository) CountRefunds(ctx context.Context, tx pgx.Tx, orderID int64) (count int, amount float64, err error) {
+	query := `SELECT COUNT(*), COALESCE(SUM(amount), 0)
+			  FROM payment_transactions
+			  WHERE order_id = $1 AND source = 'refund' AND status <> 'error';`
+
+	err = tx.QueryRow(ctx, query, orderID).Scan(&count, &amount)
+
+	return count, amount, err
 }
 
 func NewPostgresPaymentRepository(conn *pgxpool.Pool) domain.PaymentRepository {
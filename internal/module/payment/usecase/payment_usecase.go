@@ -2,24 +2,45 @@ package usecase
 
 import (
 	baseErr "backend-layout/internal/adapter/errors"
-	paymentgateway "backend-layout/internal/adapter/payment_gateway"
+	"backend-layout/internal/adapter/storage"
 	"backend-layout/internal/domain"
+	orderRepository "backend-layout/internal/module/order/repository"
+	paymentRepository "backend-layout/internal/module/payment/repository"
 	"context"
+	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/jackc/pgx/v5"
-	"github.com/labstack/echo/v4"
-	"github.com/midtrans/midtrans-go"
-	"github.com/midtrans/midtrans-go/snap"
 	"github.com/rs/zerolog/log"
 )
 
+const (
+	// paymentMethodFree is recorded for orders that cost nothing and never reach the gateway
+	paymentMethodFree = "free"
+	defaultCurrency   = "IDR"
+
+	reconciliationPageSize     = 100
+	reconciliationHistoryLimit = 30
+)
+
 type PaymentUsecase struct {
-	paymentRepo    domain.PaymentRepository
-	orderRepo      domain.OrderRepository
-	midtransClient *paymentgateway.MidtransClient
+	paymentRepo domain.PaymentRepository
+	orderRepo   domain.OrderRepository
+	// orderUsecase releases the books of an order the gateway closed
+	orderUsecase domain.OrderUsecase
+	policyRepo   domain.LendingPolicyRepository
+	reconRepo    domain.PaymentReconciliationRepository
+	gateway      domain.PaymentGateway
+	storage      storage.Uploader
+	// reconcileLookback is how far back a reconciliation looks for orders
+	reconcileLookback time.Duration
 }
 
 // CheckPaymentStatus implements domain.PaymentUsecase.
@@ -33,14 +54,283 @@ func (p *PaymentUsecase) CheckPaymentStatus(ctx context.Context, input *domain.P
 		return domain.PaymentStatusResponse{}, baseErr.NewInternalServerError(err.Error())
 	}
 
-	transactionStatusResp, errCheckTrx := p.midtransClient.Coreapi.CheckTransaction(order.OrderNumber)
-	if errCheckTrx != nil {
-		return domain.PaymentStatusResponse{}, err
+	transaction, err := p.gateway.CheckTransaction(ctx, order.OrderNumber)
+	if err != nil {
+		// the user has not picked a payment method yet
+		if errors.Is(err, domain.ErrGatewayTransactionNotFound) {
+			return domain.PaymentStatusResponse{}, nil
+		}
+
+		p.recordError(ctx, order, domain.PaymentSourceCheck, err)
+
+		log.Error().Err(err).Str("layer", "usecase").Str("gateway", p.gateway.Name()).Str("orderNumber", order.OrderNumber).Msg("failed to check transaction")
+
+		return domain.PaymentStatusResponse{}, baseErr.NewBadGatewayError("failed to check payment status")
+	}
+
+	p.record(ctx, &domain.PaymentTransaction{
+		OrderId:    order.Id,
+		Source:     domain.PaymentSourceCheck,
+		ExternalID: &transaction.TransactionID,
+		Amount:     float64(transaction.GrossAmount),
+		Currency:   transaction.Currency,
+		Status:     transaction.TransactionStatus,
+		RawPayload: transaction.Raw,
+	})
+
+	err = p.applyTransactionStatus(ctx, order, transaction.TransactionStatus, transaction.FraudStatus, transaction.PaymentType)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("userID", input.UserId).Int64("orderId", input.OrderId).Msg("failed to apply payment status")
+
+		return domain.PaymentStatusResponse{}, baseErr.NewInternalServerError("failed to check payment status")
+	}
+
+	return domain.PaymentStatusResponse{
+		PaymentType:   transaction.PaymentType,
+		PaymentStatus: transaction.TransactionStatus,
+	}, nil
+}
+
+// HandleNotification implements domain.PaymentUsecase.
+func (p *PaymentUsecase) HandleNotification(ctx context.Context, input *domain.PaymentNotification) error {
+	if !p.gateway.VerifyNotification(input) {
+		log.Warn().Str("layer", "usecase").Str("gateway", p.gateway.Name()).Str("orderNumber", input.OrderID).Msg("payment notification with invalid signature")
+
+		return baseErr.NewForbiddenError("invalid signature")
+	}
+
+	order, err := p.orderRepo.GetByNumber(ctx, input.OrderID)
+	if err != nil {
+		if errors.Is(err, orderRepository.ErrOrderNotFound) {
+			return baseErr.NewNotFoundError("order not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Str("orderNumber", input.OrderID).Msg("failed to get order")
+
+		return baseErr.NewInternalServerError("failed to process notification")
+	}
+
+	grossAmount, errAmount := strconv.ParseFloat(input.GrossAmount, 64)
+
+	p.record(ctx, &domain.PaymentTransaction{
+		OrderId:    order.Id,
+		Source:     domain.PaymentSourceNotification,
+		ExternalID: &input.TransactionID,
+		Amount:     grossAmount,
+		Status:     input.TransactionStatus,
+		RawPayload: input.Raw,
+	})
+
+	if errAmount != nil || math.Round(grossAmount) != math.Round(order.Total) {
+		log.Warn().Str("layer", "usecase").Str("orderNumber", input.OrderID).Str("grossAmount", input.GrossAmount).Float64("total", order.Total).Msg("payment notification amount does not match the order")
+
+		return baseErr.NewBadRequestError("gross_amount does not match the order")
+	}
+
+	if err := p.applyTransactionStatus(ctx, order, input.TransactionStatus, input.FraudStatus, input.PaymentType); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Str("orderNumber", input.OrderID).Str("transactionStatus", input.TransactionStatus).Msg("failed to apply payment notification")
+
+		return baseErr.NewInternalServerError("failed to process notification")
+	}
+
+	return nil
+}
+
+// applyTransactionStatus moves an order waiting for payment along with the status the gateway reports for it. Once
+// the order has left pending_payment there is nothing left to apply, so the same status can arrive any number of
+// times from polling and from notifications.
+func (p *PaymentUsecase) applyTransactionStatus(ctx context.Context, order *domain.Order, transactionStatus, fraudStatus, paymentType string) (err error) {
+	to := orderStatusFor(transactionStatus, fraudStatus)
+
+	switch to {
+	case "":
+		return nil
+	case domain.OrderCancelled, domain.OrderExpired:
+		// a closed order gives its books back like one cancelled or expired by the order usecase
+		return p.orderUsecase.CloseByGateway(ctx, order.Id, to, closedReasons[transactionStatus])
+	}
+
+	policy, err := p.policyRepo.ResolveForUser(ctx, order.UserId)
+	if err != nil {
+		return fmt.Errorf("failed to resolve lending policy: %w", err)
+	}
+
+	tx, err := p.paymentRepo.GetTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	// the lock makes a notification and a poll arriving together apply one after the other
+	current, err := p.orderRepo.GetForUpdate(ctx, tx, order.Id)
+	if err != nil {
+		return err
+	}
+
+	if current.Status != domain.OrderPendingPayment {
+		return tx.Commit(ctx)
+	}
+
+	reason := "payment " + paymentType + " settled"
+	if transactionStatus == domain.TransactionCapture {
+		reason = "payment " + paymentType + " captured"
+	}
+
+	if err = p.markPaid(ctx, tx, current, policy.LoanDays, paymentType, reason); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// closedReasons are recorded on the orders closed for a status of their gateway transaction
+var closedReasons = map[string]string{
+	domain.TransactionCancel: "payment cancelled",
+	domain.TransactionDeny:   "payment denied",
+	domain.TransactionExpire: "payment expired",
+	// a capture is only closed when the fraud check denied it
+	domain.TransactionCapture: "payment denied",
+}
+
+// orderStatusFor is where an order waiting for payment goes for a status of its gateway transaction, empty while
+// it keeps waiting. A capture the fraud check challenged is still under review and only counts once accepted.
+func orderStatusFor(transactionStatus, fraudStatus string) string {
+	switch transactionStatus {
+	case domain.TransactionCapture:
+		switch fraudStatus {
+		case domain.FraudAccept:
+			return domain.OrderPaid
+		case domain.FraudDeny:
+			return domain.OrderCancelled
+		}
+	case domain.TransactionSettlement:
+		return domain.OrderPaid
+	case domain.TransactionCancel, domain.TransactionDeny:
+		return domain.OrderCancelled
+	case domain.TransactionExpire:
+		return domain.OrderExpired
+	}
+
+	return ""
+}
+
+func (p *PaymentUsecase) markPaid(ctx context.Context, tx pgx.Tx, order *domain.Order, loanDays int, paymentType, reason string) error {
+	if err := p.paymentRepo.ProcessPayment(ctx, tx, order.UserId, paymentType, order.OrderNumber); err != nil {
+		return err
+	}
+
+	if err := p.orderRepo.UpdateBorrowDates(ctx, tx, order.Id, loanDays); err != nil {
+		return err
+	}
+
+	return p.orderRepo.Transition(ctx, tx, order.Id, domain.OrderPaid, nil, reason)
+}
+
+// CreatePayment implements domain.PaymentUsecase. The amount and the items are taken from the order as it was
+// placed, an order with nothing to pay is settled right away without going through the gateway.
+func (p *PaymentUsecase) CreatePayment(ctx context.Context, input *domain.PaymentRequest) (domain.PaymentResponse, error) {
+	order, err := p.orderRepo.GetByNumberAndUserID(ctx, input.OrderNumber, input.UserId)
+	if err != nil {
+		if errors.Is(err, orderRepository.ErrOrderNotFound) {
+			return domain.PaymentResponse{}, baseErr.NewNotFoundError("order not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Str("orderNumber", input.OrderNumber).Msg("failed to get order")
+
+		return domain.PaymentResponse{}, baseErr.NewInternalServerError("failed to create payment")
+	}
+
+	if order.Status != domain.OrderPendingPayment {
+		return domain.PaymentResponse{}, baseErr.NewConflictError(fmt.Sprintf("order is %s and no longer waiting for payment", order.Status))
+	}
+
+	amount := int64(math.Round(order.Total))
+
+	if amount <= 0 {
+		if err := p.settleFree(ctx, order); err != nil {
+			return domain.PaymentResponse{}, err
+		}
+
+		return domain.PaymentResponse{OrderStatus: domain.OrderPaid}, nil
+	}
+
+	details, err := p.orderRepo.GetOrderDetailWithBook(ctx, order.Id)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", order.Id).Msg("failed to get order details")
+
+		return domain.PaymentResponse{}, baseErr.NewInternalServerError("failed to create payment")
+	}
+
+	charge := &domain.GatewayCharge{
+		OrderNumber: order.OrderNumber,
+		Amount:      amount,
+		Discount:    int64(math.Round(order.Discount)),
+		Email:       input.Email,
+		Items:       make([]domain.GatewayItem, 0, len(details)),
+	}
+
+	for _, d := range details {
+		charge.Items = append(charge.Items, domain.GatewayItem{
+			ID:    strconv.FormatInt(d.BookId, 10),
+			Name:  d.BookTitle,
+			Price: int64(math.Round(d.Fee)),
+			Qty:   1,
+		})
+	}
+
+	checkout, err := p.gateway.CreateTransaction(ctx, charge)
+	if err != nil {
+		p.recordError(ctx, order, domain.PaymentSourceCreate, err)
+
+		log.Error().Err(err).Str("layer", "usecase").Str("gateway", p.gateway.Name()).Str("orderNumber", order.OrderNumber).Msg("failed to create gateway transaction")
+
+		return domain.PaymentResponse{}, baseErr.NewInternalServerError("failed to create payment")
+	}
+
+	p.record(ctx, &domain.PaymentTransaction{
+		OrderId:    order.Id,
+		Source:     domain.PaymentSourceCreate,
+		Amount:     float64(amount),
+		Status:     domain.TransactionPending,
+		RawPayload: checkout.Raw,
+	})
+
+	return domain.PaymentResponse{
+		OrderStatus: domain.OrderPendingPayment,
+		Amount:      amount,
+		Token:       checkout.Token,
+		PaymentURL:  checkout.RedirectURL,
+	}, nil
+
+}
+
+// settleFree marks an order that costs nothing as paid and starts its loans
+func (p *PaymentUsecase) settleFree(ctx context.Context, order *domain.Order) (err error) {
+	policy, err := p.policyRepo.ResolveForUser(ctx, order.UserId)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("userID", order.UserId).Msg("failed to resolve lending policy")
+
+		return baseErr.NewInternalServerError("failed to create payment")
 	}
 
 	tx, err := p.paymentRepo.GetTx(ctx)
 	if err != nil {
-		return domain.PaymentStatusResponse{}, err
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", order.Id).Msg("failed to begin transaction")
+
+		return baseErr.NewInternalServerError("failed to create payment")
 	}
 
 	defer func() {
@@ -57,93 +347,367 @@ func (p *PaymentUsecase) CheckPaymentStatus(ctx context.Context, input *domain.P
 		}
 	}()
 
-	if transactionStatusResp != nil {
-		if transactionStatusResp.TransactionStatus == "capture" {
-			if transactionStatusResp.FraudStatus == "challenge" {
-				if err := p.paymentRepo.ProcessPayment(ctx, tx, input.UserId, transactionStatusResp.PaymentType, order.OrderNumber); err != nil {
-					return domain.PaymentStatusResponse{}, echo.NewHTTPError(http.StatusOK, err.Error())
-				}
-
-				if err := p.orderRepo.UpdateBorrowDates(ctx, tx, order.Id); err != nil {
-					return domain.PaymentStatusResponse{}, echo.NewHTTPError(http.StatusOK, err.Error())
-				}
-			} else if transactionStatusResp.FraudStatus == "accept" {
-				if err := p.paymentRepo.ProcessPayment(ctx, tx, input.UserId, transactionStatusResp.PaymentType, order.OrderNumber); err != nil {
-					return domain.PaymentStatusResponse{}, echo.NewHTTPError(http.StatusOK, err.Error())
-				}
-
-				if err := p.orderRepo.UpdateBorrowDates(ctx, tx, order.Id); err != nil {
-					return domain.PaymentStatusResponse{}, echo.NewHTTPError(http.StatusOK, err.Error())
-				}
+	if err = p.paymentRepo.ProcessPayment(ctx, tx, order.UserId, paymentMethodFree, order.OrderNumber); err != nil {
+		return baseErr.NewConflictError(err.Error())
+	}
+
+	if err = p.orderRepo.UpdateBorrowDates(ctx, tx, order.Id, policy.LoanDays); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", order.Id).Msg("failed to start loans")
+
+		return baseErr.NewInternalServerError("failed to create payment")
+	}
+
+	if err = p.orderRepo.Transition(ctx, tx, order.Id, domain.OrderPaid, nil, "nothing to pay"); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", order.Id).Msg("failed to mark order paid")
+
+		return baseErr.NewInternalServerError("failed to create payment")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", order.Id).Msg("failed to commit transaction")
+
+		return baseErr.NewInternalServerError("failed to commit transaction")
+	}
+
+	return nil
+}
+
+// FetchTransactions implements domain.PaymentUsecase.
+func (p *PaymentUsecase) FetchTransactions(ctx context.Context, orderID int64) ([]domain.PaymentTransactionResponse, error) {
+	if _, err := p.orderRepo.GetByID(ctx, orderID); err != nil {
+		if errors.Is(err, orderRepository.ErrOrderNotFound) {
+			return nil, baseErr.NewNotFoundError("order not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", orderID).Msg("failed to get order")
+
+		return nil, baseErr.NewInternalServerError("failed to fetch payment transactions")
+	}
+
+	transactions, err := p.paymentRepo.FetchTransactions(ctx, orderID)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", orderID).Msg("failed to fetch payment transactions")
+
+		return nil, baseErr.NewInternalServerError("failed to fetch payment transactions")
+	}
+
+	result := make([]domain.PaymentTransactionResponse, 0, len(transactions))
+
+	for _, t := range transactions {
+		result = append(result, domain.PaymentTransactionToResponse(t))
+	}
+
+	return result, nil
+}
+
+// record adds an exchange with the gateway to the ledger. The ledger is there to look back at, failing to write
+// it does not fail the payment.
+func (p *PaymentUsecase) record(ctx context.Context, t *domain.PaymentTransaction) {
+	t.Gateway = p.gateway.Name()
+
+	if t.Currency == "" {
+		t.Currency = defaultCurrency
+	}
+
+	if t.ExternalID != nil && *t.ExternalID == "" {
+		t.ExternalID = nil
+	}
+
+	if err := p.paymentRepo.StoreTransaction(ctx, t); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("orderID", t.OrderId).Str("source", t.Source).Msg("failed to record payment transaction")
+	}
+}
+
+// recordError adds a call the gateway did not answer to the ledger
+func (p *PaymentUsecase) recordError(ctx context.Context, order *domain.Order, source string, err error) {
+	payload, _ := json.Marshal(map[string]string{"error": err.Error()})
+
+	p.record(ctx, &domain.PaymentTransaction{
+		OrderId:    order.Id,
+		Source:     source,
+		Amount:     order.Total,
+		Status:     domain.TransactionError,
+		RawPayload: payload,
+	})
+}
+
+// Reconcile implements domain.PaymentUsecase. The report is written to a temporary file first so the storage
+// upload always receives a complete file.
+func (p *PaymentUsecase) Reconcile(ctx context.Context) error {
+	run := domain.PaymentReconciliation{
+		Gateway: p.gateway.Name(),
+		Status:  domain.ReconciliationStatusProcessing,
+		Since:   time.Now().Add(-p.reconcileLookback),
+	}
+
+	var err error
+
+	run.Id, err = p.reconRepo.Store(ctx, &run)
+	if err != nil {
+		return fmt.Errorf("failed to create payment reconciliation: %w", err)
+	}
+
+	if err := p.reconcile(ctx, &run); err != nil {
+		msg := err.Error()
+
+		if statusErr := p.reconRepo.SetStatus(ctx, run.Id, domain.ReconciliationStatusFailed, &msg); statusErr != nil {
+			log.Error().Err(statusErr).Int64("reconciliation_id", run.Id).Msg("failed to mark payment reconciliation as failed")
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (p *PaymentUsecase) reconcile(ctx context.Context, run *domain.PaymentReconciliation) error {
+	file, err := os.CreateTemp("", "payment-reconciliation-*.csv")
+	if err != nil {
+		return fmt.Errorf("failed to create report file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+
+	if err := w.Write(reconciliationReportHeader); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	var afterID int64
+
+	for {
+		orders, err := p.orderRepo.FetchForReconciliation(ctx, run.Since, afterID, reconciliationPageSize)
+		if err != nil {
+			return fmt.Errorf("failed to get orders to reconcile: %w", err)
+		}
+
+		for i := range orders {
+			run.Checked++
+
+			d := p.reconcileOrder(ctx, &orders[i])
+			if d == nil {
+				continue
 			}
-		} else if transactionStatusResp.TransactionStatus == "settlement" {
-			if err := p.paymentRepo.ProcessPayment(ctx, tx, input.UserId, transactionStatusResp.PaymentType, order.OrderNumber); err != nil {
-				return domain.PaymentStatusResponse{}, echo.NewHTTPError(http.StatusOK, err.Error())
+
+			run.Discrepancies++
+
+			if d.Resolution == domain.DiscrepancyFixed {
+				run.Fixed++
 			}
 
-			if err := p.orderRepo.UpdateBorrowDates(ctx, tx, order.Id); err != nil {
-				return domain.PaymentStatusResponse{}, err
+			if err := w.Write(reconciliationReportRecord(d)); err != nil {
+				return fmt.Errorf("failed to write report: %w", err)
 			}
-		} else if transactionStatusResp.TransactionStatus == "cancel" || transactionStatusResp.TransactionStatus == "expire" {
-			if err := p.paymentRepo.SetPaymentFailed(ctx, tx, order.Id); err != nil {
-				return domain.PaymentStatusResponse{}, err
+		}
+
+		if len(orders) < reconciliationPageSize {
+			break
+		}
+
+		afterID = orders[len(orders)-1].Id
+	}
+
+	w.Flush()
+
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read report file: %w", err)
+	}
+
+	key := fmt.Sprintf("reconciliations/%d/payments.csv", run.Id)
+
+	if _, err := p.storage.Put(ctx, key, file, "text/csv"); err != nil {
+		return fmt.Errorf("failed to store report file: %w", err)
+	}
+
+	if err := p.reconRepo.Complete(ctx, run.Id, key, run.Checked, run.Fixed, run.Discrepancies); err != nil {
+		return fmt.Errorf("failed to complete payment reconciliation: %w", err)
+	}
+
+	log.Info().Int64("reconciliation_id", run.Id).Int("checked", run.Checked).Int("fixed", run.Fixed).Int("discrepancies", run.Discrepancies).Msg("payment reconciliation completed")
+
+	return nil
+}
+
+// reconcileOrder compares an order with its transaction at the gateway and moves an order still waiting for
+// payment to where the gateway says it is. It returns nil when both agree.
+func (p *PaymentUsecase) reconcileOrder(ctx context.Context, order *domain.Order) *domain.PaymentDiscrepancy {
+	d := &domain.PaymentDiscrepancy{
+		OrderID:       order.Id,
+		OrderNumber:   order.OrderNumber,
+		OrderStatus:   order.Status,
+		PaymentStatus: order.PaymentStatus,
+		OrderTotal:    order.Total,
+		Resolution:    domain.DiscrepancyNeedsReview,
+	}
+
+	paid := order.PaymentStatus == "Paid"
+
+	transaction, err := p.gateway.CheckTransaction(ctx, order.OrderNumber)
+	if err != nil {
+		if errors.Is(err, domain.ErrGatewayTransactionNotFound) {
+			// the user has not picked a payment method yet
+			if !paid {
+				return nil
 			}
+
+			d.Problem = "paid here but unknown to the gateway"
+
+			return d
 		}
+
+		p.recordError(ctx, order, domain.PaymentSourceCheck, err)
+
+		d.GatewayStatus = domain.TransactionError
+		d.Problem = "the gateway could not be asked: " + err.Error()
+
+		return d
 	}
 
-	if err = tx.Commit(ctx); err != nil {
-		log.Error().Err(err).Str("layer", "usecase").Int64("userID", input.UserId).Int64("orderId", input.OrderId).Msg("failed to commit transaction")
+	p.record(ctx, &domain.PaymentTransaction{
+		OrderId:    order.Id,
+		Source:     domain.PaymentSourceCheck,
+		ExternalID: &transaction.TransactionID,
+		Amount:     float64(transaction.GrossAmount),
+		Currency:   transaction.Currency,
+		Status:     transaction.TransactionStatus,
+		RawPayload: transaction.Raw,
+	})
+
+	d.GatewayStatus = transaction.TransactionStatus
+	d.FraudStatus = transaction.FraudStatus
+	d.GatewayAmount = transaction.GrossAmount
 
-		return domain.PaymentStatusResponse{}, baseErr.NewInternalServerError("failed to commit transaction")
+	target := orderStatusFor(transaction.TransactionStatus, transaction.FraudStatus)
+
+	if target == domain.OrderPaid && transaction.GrossAmount != int64(math.Round(order.Total)) {
+		d.Problem = fmt.Sprintf("the gateway took %d for an order of %.0f", transaction.GrossAmount, order.Total)
+
+		return d
 	}
 
-	return domain.PaymentStatusResponse{
-		PaymentType:   transactionStatusResp.PaymentType,
-		PaymentStatus: transactionStatusResp.TransactionStatus,
-	}, err
+	switch {
+	case !paid && target != "":
+		if err := p.applyTransactionStatus(ctx, order, transaction.TransactionStatus, transaction.FraudStatus, transaction.PaymentType); err != nil {
+			log.Error().Err(err).Str("layer", "usecase").Str("orderNumber", order.OrderNumber).Msg("failed to apply reconciled payment status")
+
+			d.Problem = fmt.Sprintf("%s at the gateway, moving the order failed: %v", transaction.TransactionStatus, err)
+
+			return d
+		}
+
+		d.Problem = fmt.Sprintf("%s at the gateway but waiting for payment here", transaction.TransactionStatus)
+		d.Resolution = domain.DiscrepancyFixed
+
+		return d
+	case paid && target != domain.OrderPaid && transaction.TransactionStatus != domain.TransactionPartialRefund:
+		// a paid order cannot go back, this one is for an admin to look at
+		d.Problem = fmt.Sprintf("paid here but %s at the gateway", transaction.TransactionStatus)
+
+		return d
+	}
+
+	return nil
 }
 
-// CreatePayment implements domain.PaymentUsecase.
-func (p *PaymentUsecase) CreatePayment(ctx context.Context, input *domain.PaymentRequest) (domain.PaymentResponse, error) {
+var reconciliationReportHeader = []string{
+	"order_id", "order_number", "order_status", "payment_status", "order_total",
+	"gateway_status", "fraud_status", "gateway_amount", "problem", "resolution",
+}
 
-	isOrder, err := p.orderRepo.GetPendingOrder(ctx, input.OrderNumber, input.UserId)
+func reconciliationReportRecord(d *domain.PaymentDiscrepancy) []string {
+	return []string{
+		strconv.FormatInt(d.OrderID, 10),
+		d.OrderNumber,
+		d.OrderStatus,
+		d.PaymentStatus,
+		strconv.FormatFloat(d.OrderTotal, 'f', 2, 64),
+		d.GatewayStatus,
+		d.FraudStatus,
+		strconv.FormatInt(d.GatewayAmount, 10),
+		d.Problem,
+		d.Resolution,
+	}
+}
 
+// FetchReconciliations implements domain.PaymentUsecase.
+func (p *PaymentUsecase) FetchReconciliations(ctx context.Context) ([]domain.PaymentReconciliationResponse, error) {
+	runs, err := p.reconRepo.Fetch(ctx, reconciliationHistoryLimit)
 	if err != nil {
-		return domain.PaymentResponse{}, err
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to fetch payment reconciliations")
+
+		return nil, baseErr.NewInternalServerError("failed to fetch payment reconciliations")
 	}
 
-	if !isOrder {
-		return domain.PaymentResponse{}, baseErr.NewNotFoundError("order not found")
+	result := make([]domain.PaymentReconciliationResponse, 0, len(runs))
+
+	for i := range runs {
+		result = append(result, domain.PaymentReconciliationToResponse(&runs[i]))
 	}
 
-	snapReq := &snap.Request{
-		TransactionDetails: midtrans.TransactionDetails{
-			OrderID:  input.OrderNumber,
-			GrossAmt: input.Amount,
-		},
-		CreditCard: &snap.CreditCardDetails{
-			Secure: true,
-		},
-		CustomerDetail: &midtrans.CustomerDetails{
-			Email: input.Email,
-		},
-		EnabledPayments: snap.AllSnapPaymentType,
+	return result, nil
+}
+
+// GetReconciliation implements domain.PaymentUsecase.
+func (p *PaymentUsecase) GetReconciliation(ctx context.Context, id int64) (domain.PaymentReconciliationResponse, error) {
+	run, err := p.getReconciliation(ctx, id)
+	if err != nil {
+		return domain.PaymentReconciliationResponse{}, err
 	}
 
-	snapResp, _ := p.midtransClient.SnapClient.CreateTransaction(snapReq)
+	return domain.PaymentReconciliationToResponse(run), nil
+}
 
-	return domain.PaymentResponse{
-		Amount:     input.Amount,
-		Token:      snapResp.Token,
-		PaymentURL: snapResp.RedirectURL,
-	}, nil
+// DownloadReconciliation implements domain.PaymentUsecase.
+func (p *PaymentUsecase) DownloadReconciliation(ctx context.Context, id int64) (io.ReadCloser, error) {
+	run, err := p.getReconciliation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if run.FileKey == nil {
+		return nil, baseErr.NewNotFoundError("reconciliation report is not ready yet")
+	}
+
+	file, err := p.storage.Open(ctx, *run.FileKey)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("reconciliation_id", id).Msg("failed to open reconciliation report")
+
+		return nil, baseErr.NewInternalServerError("failed to download reconciliation report")
+	}
+
+	return file, nil
+}
+
+func (p *PaymentUsecase) getReconciliation(ctx context.Context, id int64) (*domain.PaymentReconciliation, error) {
+	run, err := p.reconRepo.GetByID(ctx, id)
+
+	if err != nil {
+		if errors.Is(err, paymentRepository.ErrPaymentReconciliationNotFound) {
+			return nil, baseErr.NewNotFoundError("reconciliation not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("reconciliation_id", id).Msg("failed to get reconciliation")
+
+		return nil, baseErr.NewInternalServerError("failed to get reconciliation")
+	}
 
+	return run, nil
 }
 
-func NewPaymentUsecase(paymentRepo domain.PaymentRepository, orderRepo domain.OrderRepository, midtransClient *paymentgateway.MidtransClient) domain.PaymentUsecase {
+func NewPaymentUsecase(paymentRepo domain.PaymentRepository, orderRepo domain.OrderRepository, orderUsecase domain.OrderUsecase, policyRepo domain.LendingPolicyRepository, reconRepo domain.PaymentReconciliationRepository, gateway domain.PaymentGateway, storage storage.Uploader, reconcileLookback time.Duration) domain.PaymentUsecase {
 	return &PaymentUsecase{
-		paymentRepo:    paymentRepo,
-		orderRepo:      orderRepo,
-		midtransClient: midtransClient,
+		paymentRepo:       paymentRepo,
+		orderRepo:         orderRepo,
+		orderUsecase:      orderUsecase,
+		policyRepo:        policyRepo,
+		reconRepo:         reconRepo,
+		gateway:           gateway,
+		storage:           storage,
+		reconcileLookback: reconcileLookback,
 	}
 }
@@ -0,0 +1,325 @@
+package usecase
+
+import (
+	paymentgateway "backend-layout/internal/adapter/payment_gateway"
+	"backend-layout/internal/config"
+	"backend-layout/internal/domain"
+	orderUsecase "backend-layout/internal/module/order/usecase"
+	"backend-layout/internal/tasks"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5"
+)
+
+// the embedded interfaces are left nil, the tests only reach the methods written out below
+
+type fakeTx struct {
+	pgx.Tx
+}
+
+func (t *fakeTx) Commit(ctx context.Context) error {
+	return nil
+}
+
+func (t *fakeTx) Rollback(ctx context.Context) error {
+	return nil
+}
+
+type memoryOrderRepo struct {
+	domain.OrderRepository
+	mu      sync.Mutex
+	order   domain.Order
+	bookIDs []int64
+	reasons []string
+}
+
+func (r *memoryOrderRepo) GetTx(ctx context.Context) (pgx.Tx, error) {
+	return &fakeTx{}, nil
+}
+
+func (r *memoryOrderRepo) get() *domain.Order {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	order := r.order
+
+	return &order
+}
+
+func (r *memoryOrderRepo) GetForUpdate(ctx context.Context, tx pgx.Tx, id int64) (*domain.Order, error) {
+	return r.get(), nil
+}
+
+func (r *memoryOrderRepo) GetByIDAndUserID(ctx context.Context, id, userId int64) (*domain.Order, error) {
+	return r.get(), nil
+}
+
+func (r *memoryOrderRepo) GetByNumber(ctx context.Context, orderNumber string) (*domain.Order, error) {
+	return r.get(), nil
+}
+
+func (r *memoryOrderRepo) GetByNumberAndUserID(ctx context.Context, orderNumber string, userID int64) (*domain.Order, error) {
+	return r.get(), nil
+}
+
+func (r *memoryOrderRepo) FetchBookIDs(ctx context.Context, tx pgx.Tx, orderID int64) ([]int64, error) {
+	return r.bookIDs, nil
+}
+
+func (r *memoryOrderRepo) Transition(ctx context.Context, tx pgx.Tx, orderID int64, to string, actorID *int64, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := domain.CheckOrderTransition(r.order.Status, to); err != nil {
+		return err
+	}
+
+	r.order.Status = to
+	r.reasons = append(r.reasons, reason)
+
+	return nil
+}
+
+func (r *memoryOrderRepo) UpdateBorrowDates(ctx context.Context, tx pgx.Tx, orderID int64, loanDays int) error {
+	return nil
+}
+
+type memoryPaymentRepo struct {
+	domain.PaymentRepository
+	mu           sync.Mutex
+	transactions []domain.PaymentTransaction
+}
+
+func (r *memoryPaymentRepo) StoreTransaction(ctx context.Context, t *domain.PaymentTransaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.transactions = append(r.transactions, *t)
+
+	return nil
+}
+
+func (r *memoryPaymentRepo) GetTx(ctx context.Context) (pgx.Tx, error) {
+	return &fakeTx{}, nil
+}
+
+func (r *memoryPaymentRepo) ProcessPayment(ctx context.Context, tx pgx.Tx, userID int64, paymentType, orderNumber string) error {
+	return nil
+}
+
+type defaultPolicyRepo struct {
+	domain.LendingPolicyRepository
+}
+
+func (r *defaultPolicyRepo) ResolveForUser(ctx context.Context, userID int64) (*domain.LendingPolicy, error) {
+	return &domain.LendingPolicy{LoanDays: 14}, nil
+}
+
+// shelfCopyRepo puts the copy of every released book back on an empty shelf
+type shelfCopyRepo struct {
+	domain.BookCopyRepository
+	refreshed []int64
+}
+
+func (r *shelfCopyRepo) RefreshStock(ctx context.Context, tx pgx.Tx, bookID int64) (domain.BookStock, error) {
+	r.refreshed = append(r.refreshed, bookID)
+
+	return domain.BookStock{Before: 0, After: 1, TotalStock: 1, Available: 1}, nil
+}
+
+type recordingDistributor struct {
+	tasks.TaskDistributor
+	restocked []int64
+}
+
+func (d *recordingDistributor) DistributeTaskNotifyBookRestocked(ctx context.Context, payload *tasks.PayloadNotifyBookRestocked, opts ...asynq.Option) error {
+	d.restocked = append(d.restocked, payload.BookID)
+
+	return nil
+}
+
+type gatewayCloseFixture struct {
+	gateway  *paymentgateway.FakeGateway
+	payments domain.PaymentUsecase
+	orders   *memoryOrderRepo
+	copies   *shelfCopyRepo
+	tasks    *recordingDistributor
+	token    string
+}
+
+// newGatewayCloseFixture wires the payment usecase to the real order usecase over in memory repositories and to the
+// fake gateway. With notify the gateway posts its notifications to the webhook the way Midtrans would, without it
+// the order only learns about a change by polling.
+func newGatewayCloseFixture(t *testing.T, notify bool) *gatewayCloseFixture {
+	t.Helper()
+
+	f := &gatewayCloseFixture{
+		orders: &memoryOrderRepo{
+			order:   domain.Order{Id: 1, OrderNumber: "ORD-1", UserId: 7, Status: domain.OrderPendingPayment, Subtotal: 15000, Total: 15000},
+			bookIDs: []int64{3},
+		},
+		copies: &shelfCopyRepo{},
+		tasks:  &recordingDistributor{},
+	}
+
+	conf := config.PaymentConfig{FakeServerKey: "test-key"}
+
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		n := domain.PaymentNotification{Raw: body}
+		if err := json.Unmarshal(body, &n); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := f.payments.HandleNotification(r.Context(), &n); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(webhook.Close)
+
+	if notify {
+		conf.FakeNotificationURL = webhook.URL
+	}
+
+	f.gateway = paymentgateway.NewFakeGateway(conf)
+
+	orders := orderUsecase.NewOrderUsecase(f.orders, f.copies, nil, nil, nil, nil, nil, f.gateway, f.tasks, domain.FinePolicy{}, time.Hour)
+	f.payments = NewPaymentUsecase(&memoryPaymentRepo{}, f.orders, orders, &defaultPolicyRepo{}, nil, f.gateway, nil, time.Hour)
+
+	checkout, err := f.gateway.CreateTransaction(context.Background(), &domain.GatewayCharge{OrderNumber: "ORD-1", Amount: 15000})
+	if err != nil {
+		t.Fatalf("create transaction: %v", err)
+	}
+
+	f.token = checkout.Token
+
+	return f
+}
+
+// press submits an action on the checkout page of the order
+func (f *gatewayCloseFixture) press(t *testing.T, action string) {
+	t.Helper()
+
+	form := url.Values{"action": {action}}
+	req := httptest.NewRequest(http.MethodPost, "/checkout/"+f.token, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+
+	f.gateway.Handler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("%s on the checkout = %d %s", action, rec.Code, rec.Body.String())
+	}
+}
+
+func (f *gatewayCloseFixture) assertReleased(t *testing.T, status, reason string) {
+	t.Helper()
+
+	order := f.orders.get()
+
+	if order.Status != status {
+		t.Fatalf("order status = %s, want %s", order.Status, status)
+	}
+
+	if len(f.orders.reasons) != 1 || f.orders.reasons[0] != reason {
+		t.Errorf("transitions = %v, want one with %q", f.orders.reasons, reason)
+	}
+
+	if len(f.copies.refreshed) != 1 || f.copies.refreshed[0] != 3 {
+		t.Errorf("recounted books = %v, want [3]", f.copies.refreshed)
+	}
+
+	if len(f.tasks.restocked) != 1 || f.tasks.restocked[0] != 3 {
+		t.Errorf("restock notices = %v, want [3]", f.tasks.restocked)
+	}
+}
+
+func TestGatewayCancelReleasesOrderOnPoll(t *testing.T) {
+	f := newGatewayCloseFixture(t, false)
+
+	f.press(t, "cancel")
+
+	resp, err := f.payments.CheckPaymentStatus(context.Background(), &domain.PaymentStatusRequest{OrderId: 1, UserId: 7})
+	if err != nil {
+		t.Fatalf("check payment status: %v", err)
+	}
+
+	if resp.PaymentStatus != domain.TransactionCancel {
+		t.Errorf("payment status = %s, want %s", resp.PaymentStatus, domain.TransactionCancel)
+	}
+
+	f.assertReleased(t, domain.OrderCancelled, "payment cancelled")
+
+	// a later poll finds the order closed and changes nothing
+	if _, err := f.payments.CheckPaymentStatus(context.Background(), &domain.PaymentStatusRequest{OrderId: 1, UserId: 7}); err != nil {
+		t.Fatalf("check payment status again: %v", err)
+	}
+
+	f.assertReleased(t, domain.OrderCancelled, "payment cancelled")
+}
+
+func TestGatewayExpireReleasesOrderOnNotification(t *testing.T) {
+	f := newGatewayCloseFixture(t, true)
+
+	f.press(t, "expire")
+
+	f.assertReleased(t, domain.OrderExpired, "payment expired")
+}
+
+func TestChallengedCaptureWaitsForReview(t *testing.T) {
+	f := newGatewayCloseFixture(t, true)
+
+	f.press(t, "challenge")
+
+	if status := f.orders.get().Status; status != domain.OrderPendingPayment {
+		t.Fatalf("order status after a challenge = %s, want %s", status, domain.OrderPendingPayment)
+	}
+
+	f.press(t, "accept")
+
+	if status := f.orders.get().Status; status != domain.OrderPaid {
+		t.Errorf("order status after the review accepted = %s, want %s", status, domain.OrderPaid)
+	}
+}
+
+func TestDeniedCaptureReleasesOrder(t *testing.T) {
+	f := newGatewayCloseFixture(t, true)
+
+	f.press(t, "challenge")
+	f.press(t, "deny")
+
+	f.assertReleased(t, domain.OrderCancelled, "payment denied")
+}
+
+func TestCreatePaymentSettlesFreeOrder(t *testing.T) {
+	f := newGatewayCloseFixture(t, false)
+	f.orders.order.OrderNumber, f.orders.order.Discount, f.orders.order.Total = "ORD-2", 15000, 0
+
+	resp, err := f.payments.CreatePayment(context.Background(), &domain.PaymentRequest{OrderNumber: "ORD-2", UserId: 7})
+	if err != nil {
+		t.Fatalf("create payment: %v", err)
+	}
+
+	if resp != (domain.PaymentResponse{OrderStatus: domain.OrderPaid}) {
+		t.Errorf("response = %+v, want the order paid with nothing to pay", resp)
+	}
+
+	if status := f.orders.get().Status; status != domain.OrderPaid {
+		t.Errorf("order status = %s, want %s", status, domain.OrderPaid)
+	}
+}
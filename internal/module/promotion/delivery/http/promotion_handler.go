@@ -0,0 +1,117 @@
+package http
+
+import (
+	"backend-layout/internal/domain"
+	"backend-layout/internal/middleware"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type PromotionHandler struct {
+	promotionUsecase domain.PromotionUsecase
+}
+
+func NewPromotionHandler(r *echo.Group, pu domain.PromotionUsecase, rbac *middleware.RBACMiddleware) {
+	handler := &PromotionHandler{
+		promotionUsecase: pu,
+	}
+
+	r.GET("/admin/promotions", handler.List, rbac.RequiredPermission("promotion:manage"))
+	r.GET("/admin/promotions/:id", handler.Get, rbac.RequiredPermission("promotion:manage"))
+	r.POST("/admin/promotions", handler.Store, rbac.RequiredPermission("promotion:manage"))
+	r.PATCH("/admin/promotions/:id", handler.Update, rbac.RequiredPermission("promotion:manage"))
+	r.DELETE("/admin/promotions/:id", handler.Delete, rbac.RequiredPermission("promotion:manage"))
+}
+
+func (h *PromotionHandler) List(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	resp, err := h.promotionUsecase.Fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *PromotionHandler) Get(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid promotion ID format")
+	}
+
+	ctx := c.Request().Context()
+
+	resp, err := h.promotionUsecase.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *PromotionHandler) Store(c echo.Context) error {
+	req := new(domain.StorePromotionRequest)
+
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	resp, err := h.promotionUsecase.Store(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, echo.Map{"message": "promotion created successfully", "data": resp})
+}
+
+func (h *PromotionHandler) Update(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid promotion ID format")
+	}
+
+	req := new(domain.UpdatePromotionRequest)
+
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	req.ID = id
+
+	ctx := c.Request().Context()
+
+	resp, err := h.promotionUsecase.Update(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "promotion updated successfully", "data": resp})
+}
+
+func (h *PromotionHandler) Delete(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid promotion ID format")
+	}
+
+	ctx := c.Request().Context()
+
+	if err := h.promotionUsecase.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "promotion deleted successfully"})
+}
@@ -0,0 +1,278 @@
+package repository
+
+import (
+	"backend-layout/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrPromotionNotFound      = errors.New("promotion not found")
+	ErrPromotionDuplicate     = errors.New("duplicate entry: promotion code already exists")
+	ErrPromotionScopeNotFound = errors.New("category or book of the promotion not found")
+	ErrPromotionRedeemed      = errors.New("promotion has been redeemed")
+)
+
+type postgresPromotionRepository struct {
+	conn *pgxpool.Pool
+}
+
+// redemptions of cancelled and expired orders are given back
+const promotionColumns = `p.id, p.code, p.description, p.discount_type, p.discount_value, p.min_subtotal, p.starts_at,
+				p.ends_at, p.usage_limit, p.per_user_limit, p.first_order_only, p.active,
+				ARRAY(SELECT category_id FROM promotion_categories WHERE promotion_id = p.id ORDER BY category_id),
+				ARRAY(SELECT book_id FROM promotion_books WHERE promotion_id = p.id ORDER BY book_id),
+				(SELECT COUNT(1) FROM promotion_redemptions pr JOIN orders o ON o.id = pr.order_id
+				 WHERE pr.promotion_id = p.id AND o.status NOT IN ('cancelled', 'expired')),
+				p.created_at, p.updated_at`
+
+func scanPromotion(row pgx.Row) (*domain.Promotion, error) {
+	var p domain.Promotion
+
+	err := row.Scan(&p.Id, &p.Code, &p.Description, &p.DiscountType, &p.DiscountValue, &p.MinSubtotal, &p.StartsAt,
+		&p.EndsAt, &p.UsageLimit, &p.PerUserLimit, &p.FirstOrderOnly, &p.Active, &p.CategoryIDs, &p.BookIDs,
+		&p.Redeemed, &p.CreatedAt, &p.UpdatedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPromotionNotFound
+		}
+
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// GetTx implements domain.PromotionRepository.
+func (p *postgresPromotionRepository) GetTx(ctx context.Context) (pgx.Tx, error) {
+	tx, err := p.conn.Begin(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// Fetch implements domain.PromotionRepository.
+func (p *postgresPromotionRepository) Fetch(ctx context.Context) ([]domain.Promotion, error) {
+	query := `SELECT ` + promotionColumns + `
+			  FROM promotions p
+			  ORDER BY p.created_at DESC, p.id DESC;`
+
+	rows, err := p.conn.Query(ctx, query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := make([]domain.Promotion, 0)
+
+	for rows.Next() {
+		promotion, err := scanPromotion(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, *promotion)
+	}
+
+	return result, rows.Err()
+}
+
+// GetByID implements domain.PromotionRepository.
+func (p *postgresPromotionRepository) GetByID(ctx context.Context, id int64) (*domain.Promotion, error) {
+	query := `SELECT ` + promotionColumns + `
+			  FROM promotions p
+			  WHERE p.id = $1;`
+
+	return scanPromotion(p.conn.QueryRow(ctx, query, id))
+}
+
+// Store implements domain.PromotionRepository.
+func (p *postgresPromotionRepository) Store(ctx context.Context, tx pgx.Tx, promotion *domain.Promotion) (id int64, err error) {
+	query := `INSERT INTO promotions (code, description, discount_type, discount_value, min_subtotal, starts_at, ends_at,
+				usage_limit, per_user_limit, first_order_only, active)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			  RETURNING id;`
+
+	err = tx.QueryRow(ctx, query, promotion.Code, promotion.Description, promotion.DiscountType, promotion.DiscountValue,
+		promotion.MinSubtotal, promotion.StartsAt, promotion.EndsAt, promotion.UsageLimit, promotion.PerUserLimit,
+		promotion.FirstOrderOnly, promotion.Active).Scan(&id)
+
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
+			return 0, ErrPromotionDuplicate
+		}
+
+		return 0, fmt.Errorf("failed to insert promotion: %w", err)
+	}
+
+	if err = p.saveScope(ctx, tx, id, promotion); err != nil {
+		return 0, err
+	}
+
+	return
+}
+
+// Update implements domain.PromotionRepository.
+func (p *postgresPromotionRepository) Update(ctx context.Context, tx pgx.Tx, promotion *domain.Promotion) error {
+	query := `UPDATE promotions
+			  SET description = $1, discount_type = $2, discount_value = $3, min_subtotal = $4, starts_at = $5,
+				ends_at = $6, usage_limit = $7, per_user_limit = $8, first_order_only = $9, active = $10, updated_at = now()
+			  WHERE id = $11;`
+
+	row, err := tx.Exec(ctx, query, promotion.Description, promotion.DiscountType, promotion.DiscountValue,
+		promotion.MinSubtotal, promotion.StartsAt, promotion.EndsAt, promotion.UsageLimit, promotion.PerUserLimit,
+		promotion.FirstOrderOnly, promotion.Active, promotion.Id)
+
+	if err != nil {
+		return fmt.Errorf("failed to update promotion: %w", err)
+	}
+
+	if row.RowsAffected() == 0 {
+		return ErrPromotionNotFound
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM promotion_categories WHERE promotion_id = $1;`, promotion.Id); err != nil {
+		return fmt.Errorf("failed to delete promotion categories: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM promotion_books WHERE promotion_id = $1;`, promotion.Id); err != nil {
+		return fmt.Errorf("failed to delete promotion books: %w", err)
+	}
+
+	return p.saveScope(ctx, tx, promotion.Id, promotion)
+}
+
+func (p *postgresPromotionRepository) saveScope(ctx context.Context, tx pgx.Tx, id int64, promotion *domain.Promotion) error {
+	_, err := tx.CopyFrom(ctx, pgx.Identifier{"promotion_categories"}, []string{"promotion_id", "category_id"}, pgx.CopyFromSlice(len(promotion.CategoryIDs), func(i int) ([]any, error) {
+		return []any{id, promotion.CategoryIDs[i]}, nil
+	}))
+
+	if err != nil {
+		return scopeError("failed to insert promotion categories", err)
+	}
+
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"promotion_books"}, []string{"promotion_id", "book_id"}, pgx.CopyFromSlice(len(promotion.BookIDs), func(i int) ([]any, error) {
+		return []any{id, promotion.BookIDs[i]}, nil
+	}))
+
+	if err != nil {
+		return scopeError("failed to insert promotion books", err)
+	}
+
+	return nil
+}
+
+func scopeError(msg string, err error) error {
+	if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23503" {
+		return ErrPromotionScopeNotFound
+	}
+
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// Delete implements domain.PromotionRepository.
+func (p *postgresPromotionRepository) Delete(ctx context.Context, id int64) error {
+	row, err := p.conn.Exec(ctx, `DELETE FROM promotions WHERE id = $1;`, id)
+
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23503" {
+			return ErrPromotionRedeemed
+		}
+
+		return fmt.Errorf("failed to delete promotion: %w", err)
+	}
+
+	if row.RowsAffected() == 0 {
+		return ErrPromotionNotFound
+	}
+
+	return nil
+}
+
+// GetByCodeForUpdate implements domain.PromotionRepository.
+func (p *postgresPromotionRepository) GetByCodeForUpdate(ctx context.Context, tx pgx.Tx, code string) (*domain.Promotion, error) {
+	query := `SELECT ` + promotionColumns + `
+			  FROM promotions p
+			  WHERE p.code = $1
+			  FOR UPDATE OF p;`
+
+	return scanPromotion(tx.QueryRow(ctx, query, code))
+}
+
+// CountRedemptions implements domain.PromotionRepository.
+func (p *postgresPromotionRepository) CountRedemptions(ctx context.Context, tx pgx.Tx, promotionID, userID int64) (total, byUser int, err error) {
+	query := `SELECT COUNT(1), COUNT(1) FILTER (WHERE pr.user_id = $2)
+			  FROM promotion_redemptions pr
+			  JOIN orders o ON o.id = pr.order_id
+			  WHERE pr.promotion_id = $1 AND o.status NOT IN ('cancelled', 'expired');`
+
+	err = tx.QueryRow(ctx, query, promotionID, userID).Scan(&total, &byUser)
+
+	return
+}
+
+// EligibleBooks implements domain.PromotionRepository. A book is in scope when it is listed itself or has one of
+// the listed categories.
+func (p *postgresPromotionRepository) EligibleBooks(ctx context.Context, tx pgx.Tx, promotionID int64, bookIDs []int64) ([]int64, error) {
+	query := `SELECT b.id
+			  FROM unnest($2::BIGINT[]) AS b(id)
+			  WHERE EXISTS (SELECT 1 FROM promotion_books pb WHERE pb.promotion_id = $1 AND pb.book_id = b.id)
+				OR EXISTS (
+					SELECT 1 FROM book_category bc
+					JOIN promotion_categories pc ON pc.category_id = bc.category_id AND pc.promotion_id = $1
+					WHERE bc.book_id = b.id
+				)
+			  ORDER BY b.id;`
+
+	rows, err := tx.Query(ctx, query, promotionID, bookIDs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := make([]int64, 0)
+
+	for rows.Next() {
+		var id int64
+
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		result = append(result, id)
+	}
+
+	return result, rows.Err()
+}
+
+// Redeem implements domain.PromotionRepository.
+func (p *postgresPromotionRepository) Redeem(ctx context.Context, tx pgx.Tx, redemption *domain.PromotionRedemption) error {
+	query := `INSERT INTO promotion_redemptions (promotion_id, order_id, user_id, discount)
+			  VALUES ($1, $2, $3, $4);`
+
+	if _, err := tx.Exec(ctx, query, redemption.PromotionID, redemption.OrderID, redemption.UserID, redemption.Discount); err != nil {
+		return fmt.Errorf("failed to insert promotion redemption: %w", err)
+	}
+
+	return nil
+}
+
+func NewPostgresPromotionRepository(conn *pgxpool.Pool) domain.PromotionRepository {
+	return &postgresPromotionRepository{
+		conn: conn,
+	}
+}
@@ -0,0 +1,230 @@
+package usecase
+
+import (
+	baseErr "backend-layout/internal/adapter/errors"
+	"backend-layout/internal/domain"
+	"backend-layout/internal/module/promotion/repository"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+type PromotionUsecase struct {
+	promotionRepo domain.PromotionRepository
+}
+
+// Fetch implements domain.PromotionUsecase.
+func (p *PromotionUsecase) Fetch(ctx context.Context) ([]domain.PromotionResponse, error) {
+	promotions, err := p.promotionRepo.Fetch(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to get promotions")
+
+		return nil, baseErr.NewInternalServerError("failed to get promotions")
+	}
+
+	result := make([]domain.PromotionResponse, len(promotions))
+
+	for i := range promotions {
+		result[i] = domain.PromotionToResponse(&promotions[i])
+	}
+
+	return result, nil
+}
+
+// Get implements domain.PromotionUsecase.
+func (p *PromotionUsecase) Get(ctx context.Context, id int64) (domain.PromotionResponse, error) {
+	promotion, err := p.promotionRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrPromotionNotFound) {
+			return domain.PromotionResponse{}, baseErr.NewNotFoundError("promotion not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("promotion_id", id).Msg("failed to get promotion")
+
+		return domain.PromotionResponse{}, baseErr.NewInternalServerError("failed to get promotion")
+	}
+
+	return domain.PromotionToResponse(promotion), nil
+}
+
+// Store implements domain.PromotionUsecase. Codes are kept upper case.
+func (p *PromotionUsecase) Store(ctx context.Context, input *domain.StorePromotionRequest) (resp domain.PromotionResponse, err error) {
+	promotion := domain.Promotion{
+		Code:           strings.ToUpper(input.Code),
+		Description:    input.Description,
+		DiscountType:   input.DiscountType,
+		DiscountValue:  input.DiscountValue,
+		MinSubtotal:    input.MinSubtotal,
+		StartsAt:       time.Now(),
+		EndsAt:         input.EndsAt,
+		UsageLimit:     input.UsageLimit,
+		PerUserLimit:   input.PerUserLimit,
+		FirstOrderOnly: input.FirstOrderOnly,
+		Active:         true,
+		CategoryIDs:    uniqueIDs(input.CategoryIDs),
+		BookIDs:        uniqueIDs(input.BookIDs),
+	}
+
+	if input.StartsAt != nil {
+		promotion.StartsAt = *input.StartsAt
+	}
+
+	if err := validatePromotion(&promotion); err != nil {
+		return domain.PromotionResponse{}, err
+	}
+
+	tx, err := p.promotionRepo.GetTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to begin transaction")
+
+		return domain.PromotionResponse{}, baseErr.NewInternalServerError("failed to store promotion")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	id, err := p.promotionRepo.Store(ctx, tx, &promotion)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrPromotionDuplicate):
+			return domain.PromotionResponse{}, baseErr.NewConflictError("promotion code already exists")
+		case errors.Is(err, repository.ErrPromotionScopeNotFound):
+			return domain.PromotionResponse{}, baseErr.NewNotFoundError("category or book not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Str("code", promotion.Code).Msg("failed to store promotion")
+
+		return domain.PromotionResponse{}, baseErr.NewInternalServerError("failed to store promotion")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Str("code", promotion.Code).Msg("failed to commit transaction")
+
+		return domain.PromotionResponse{}, baseErr.NewInternalServerError("failed to store promotion")
+	}
+
+	return p.Get(ctx, id)
+}
+
+// Update implements domain.PromotionUsecase.
+func (p *PromotionUsecase) Update(ctx context.Context, input *domain.UpdatePromotionRequest) (resp domain.PromotionResponse, err error) {
+	promotion := domain.Promotion{
+		Id:             input.ID,
+		Description:    input.Description,
+		DiscountType:   input.DiscountType,
+		DiscountValue:  input.DiscountValue,
+		MinSubtotal:    input.MinSubtotal,
+		StartsAt:       input.StartsAt,
+		EndsAt:         input.EndsAt,
+		UsageLimit:     input.UsageLimit,
+		PerUserLimit:   input.PerUserLimit,
+		FirstOrderOnly: input.FirstOrderOnly,
+		Active:         input.Active,
+		CategoryIDs:    uniqueIDs(input.CategoryIDs),
+		BookIDs:        uniqueIDs(input.BookIDs),
+	}
+
+	if err := validatePromotion(&promotion); err != nil {
+		return domain.PromotionResponse{}, err
+	}
+
+	tx, err := p.promotionRepo.GetTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("promotion_id", input.ID).Msg("failed to begin transaction")
+
+		return domain.PromotionResponse{}, baseErr.NewInternalServerError("failed to update promotion")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	if err = p.promotionRepo.Update(ctx, tx, &promotion); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrPromotionNotFound):
+			return domain.PromotionResponse{}, baseErr.NewNotFoundError("promotion not found")
+		case errors.Is(err, repository.ErrPromotionScopeNotFound):
+			return domain.PromotionResponse{}, baseErr.NewNotFoundError("category or book not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("promotion_id", input.ID).Msg("failed to update promotion")
+
+		return domain.PromotionResponse{}, baseErr.NewInternalServerError("failed to update promotion")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("promotion_id", input.ID).Msg("failed to commit transaction")
+
+		return domain.PromotionResponse{}, baseErr.NewInternalServerError("failed to update promotion")
+	}
+
+	return p.Get(ctx, input.ID)
+}
+
+// Delete implements domain.PromotionUsecase. A promotion orders were placed with stays for their records and
+// can only be deactivated.
+func (p *PromotionUsecase) Delete(ctx context.Context, id int64) error {
+	if err := p.promotionRepo.Delete(ctx, id); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrPromotionNotFound):
+			return baseErr.NewNotFoundError("promotion not found")
+		case errors.Is(err, repository.ErrPromotionRedeemed):
+			return baseErr.NewConflictError("promotion has been redeemed, deactivate it instead")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("promotion_id", id).Msg("failed to delete promotion")
+
+		return baseErr.NewInternalServerError("failed to delete promotion")
+	}
+
+	return nil
+}
+
+func validatePromotion(promotion *domain.Promotion) error {
+	if promotion.DiscountType == domain.PromotionPercentage && promotion.DiscountValue > 100 {
+		return baseErr.NewBadRequestError("a percentage discount can not be more than 100")
+	}
+
+	if promotion.EndsAt != nil && !promotion.EndsAt.After(promotion.StartsAt) {
+		return baseErr.NewBadRequestError("ends_at must be after starts_at")
+	}
+
+	return nil
+}
+
+func uniqueIDs(ids []int64) []int64 {
+	result := slices.Clone(ids)
+	slices.Sort(result)
+
+	return slices.Compact(result)
+}
+
+func NewPromotionUsecase(promotionRepo domain.PromotionRepository) domain.PromotionUsecase {
+	return &PromotionUsecase{promotionRepo: promotionRepo}
+}
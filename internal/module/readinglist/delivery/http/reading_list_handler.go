@@ -0,0 +1,279 @@
+package http
+
+import (
+	"backend-layout/internal/domain"
+	"backend-layout/internal/httpcontext"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ReadingListHandler struct {
+	readingListUsecase domain.ReadingListUsecase
+}
+
+func NewReadingListHandler(p *echo.Group, r *echo.Group, ru domain.ReadingListUsecase) {
+	handler := &ReadingListHandler{
+		readingListUsecase: ru,
+	}
+
+	p.GET("/reading-lists/:id", handler.GetPublic)
+	r.GET("/reading-lists", handler.List)
+	r.POST("/reading-lists", handler.Store)
+	r.GET("/reading-lists/:id", handler.Get)
+	r.PATCH("/reading-lists/:id", handler.Update)
+	r.DELETE("/reading-lists/:id", handler.Delete)
+	r.POST("/reading-lists/:id/items", handler.AddItem)
+	r.PUT("/reading-lists/:id/items/order", handler.Reorder)
+	r.DELETE("/reading-lists/:id/items/:bookId", handler.RemoveItem)
+	r.POST("/reading-lists/:id/items/:bookId/move-to-cart", handler.MoveToCart)
+}
+
+func (h *ReadingListHandler) List(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
+	ctx := c.Request().Context()
+
+	resp, err := h.readingListUsecase.Fetch(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *ReadingListHandler) GetPublic(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid reading list ID format")
+	}
+
+	ctx := c.Request().Context()
+
+	resp, err := h.readingListUsecase.GetPublic(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *ReadingListHandler) Get(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid reading list ID format")
+	}
+
+	ctx := c.Request().Context()
+
+	resp, err := h.readingListUsecase.Get(ctx, id, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *ReadingListHandler) Store(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
+	req := new(domain.StoreReadingListRequest)
+
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	req.UserID = user.ID
+
+	ctx := c.Request().Context()
+
+	resp, err := h.readingListUsecase.Store(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, echo.Map{"message": "reading list created successfully", "data": resp})
+}
+
+func (h *ReadingListHandler) Update(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid reading list ID format")
+	}
+
+	req := new(domain.UpdateReadingListRequest)
+
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	req.ID = id
+	req.UserID = user.ID
+
+	ctx := c.Request().Context()
+
+	resp, err := h.readingListUsecase.Update(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "reading list updated successfully", "data": resp})
+}
+
+func (h *ReadingListHandler) Delete(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid reading list ID format")
+	}
+
+	ctx := c.Request().Context()
+
+	if err := h.readingListUsecase.Delete(ctx, id, user.ID); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "reading list deleted successfully"})
+}
+
+func (h *ReadingListHandler) AddItem(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid reading list ID format")
+	}
+
+	req := new(domain.AddReadingListItemRequest)
+
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	if err := h.readingListUsecase.AddItem(ctx, id, user.ID, req.BookID); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, echo.Map{"message": "book added to reading list"})
+}
+
+func (h *ReadingListHandler) RemoveItem(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid reading list ID format")
+	}
+
+	bookID, err := strconv.ParseInt(c.Param("bookId"), 10, 64)
+	if err != nil || bookID <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid book ID format")
+	}
+
+	ctx := c.Request().Context()
+
+	if err := h.readingListUsecase.RemoveItem(ctx, id, user.ID, bookID); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "book removed from reading list"})
+}
+
+func (h *ReadingListHandler) Reorder(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid reading list ID format")
+	}
+
+	req := new(domain.ReorderReadingListRequest)
+
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	if err := h.readingListUsecase.Reorder(ctx, id, user.ID, req.BookIDs); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "reading list reordered"})
+}
+
+func (h *ReadingListHandler) MoveToCart(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid reading list ID format")
+	}
+
+	bookID, err := strconv.ParseInt(c.Param("bookId"), 10, 64)
+	if err != nil || bookID <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid book ID format")
+	}
+
+	ctx := c.Request().Context()
+
+	cartID, err := h.readingListUsecase.MoveToCart(ctx, id, user.ID, bookID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, echo.Map{"message": "book moved to cart", "data": echo.Map{
+		"cart_id": cartID,
+	}})
+}
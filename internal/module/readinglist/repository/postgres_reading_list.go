@@ -0,0 +1,293 @@
+package repository
+
+import (
+	"backend-layout/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrReadingListNotFound      = errors.New("reading list not found")
+	ErrReadingListNameDuplicate = errors.New("duplicate entry: reading list name already used")
+	ErrReadingListItemDuplicate = errors.New("duplicate entry: book already on reading list")
+	ErrReadingListItemNotFound  = errors.New("reading list item not found")
+	ErrBookNotFound             = errors.New("book not found")
+)
+
+type postgresReadingListRepository struct {
+	conn *pgxpool.Pool
+}
+
+const readingListColumns = `l.id, l.user_id, l.name, l.is_public, l.is_default,
+				(SELECT COUNT(1) FROM reading_list_items i JOIN books b ON b.id = i.book_id
+				 WHERE i.reading_list_id = l.id AND b.deleted_at IS NULL),
+				l.created_at, l.updated_at`
+
+func scanReadingList(row pgx.Row) (*domain.ReadingList, error) {
+	var l domain.ReadingList
+
+	err := row.Scan(&l.Id, &l.UserID, &l.Name, &l.IsPublic, &l.IsDefault, &l.TotalItems, &l.CreatedAt, &l.UpdatedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrReadingListNotFound
+		}
+
+		return nil, err
+	}
+
+	return &l, nil
+}
+
+// GetTx implements domain.ReadingListRepository.
+func (p *postgresReadingListRepository) GetTx(ctx context.Context) (pgx.Tx, error) {
+	tx, err := p.conn.Begin(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// Store implements domain.ReadingListRepository.
+func (p *postgresReadingListRepository) Store(ctx context.Context, tx pgx.Tx, list *domain.ReadingList) (id int64, err error) {
+	query := `INSERT INTO reading_lists (user_id, name, is_public) VALUES ($1, $2, $3) RETURNING id;`
+
+	err = tx.QueryRow(ctx, query, list.UserID, list.Name, list.IsPublic).Scan(&id)
+
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
+			return 0, ErrReadingListNameDuplicate
+		}
+
+		return 0, fmt.Errorf("failed to insert reading list: %w", err)
+	}
+
+	return
+}
+
+// EnsureDefault implements domain.ReadingListRepository.
+func (p *postgresReadingListRepository) EnsureDefault(ctx context.Context, userID int64) error {
+	query := `INSERT INTO reading_lists (user_id, name, is_default)
+			  VALUES ($1, $2, TRUE)
+			  ON CONFLICT DO NOTHING;`
+
+	_, err := p.conn.Exec(ctx, query, userID, domain.DefaultReadingListName)
+
+	return err
+}
+
+// GetByID implements domain.ReadingListRepository.
+func (p *postgresReadingListRepository) GetByID(ctx context.Context, id int64) (*domain.ReadingList, error) {
+	query := `SELECT ` + readingListColumns + `
+			  FROM reading_lists l
+			  WHERE l.id = $1;`
+
+	return scanReadingList(p.conn.QueryRow(ctx, query, id))
+}
+
+// GetForUpdate implements domain.ReadingListRepository.
+func (p *postgresReadingListRepository) GetForUpdate(ctx context.Context, tx pgx.Tx, id int64) (*domain.ReadingList, error) {
+	query := `SELECT ` + readingListColumns + `
+			  FROM reading_lists l
+			  WHERE l.id = $1
+			  FOR UPDATE;`
+
+	return scanReadingList(tx.QueryRow(ctx, query, id))
+}
+
+// FetchByUserID implements domain.ReadingListRepository.
+func (p *postgresReadingListRepository) FetchByUserID(ctx context.Context, userID int64) ([]domain.ReadingList, error) {
+	query := `SELECT ` + readingListColumns + `
+			  FROM reading_lists l
+			  WHERE l.user_id = $1
+			  ORDER BY l.is_default DESC, l.id;`
+
+	rows, err := p.conn.Query(ctx, query, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := make([]domain.ReadingList, 0)
+
+	for rows.Next() {
+		l, err := scanReadingList(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, *l)
+	}
+
+	return result, rows.Err()
+}
+
+// Update implements domain.ReadingListRepository.
+func (p *postgresReadingListRepository) Update(ctx context.Context, tx pgx.Tx, list *domain.ReadingList) error {
+	query := `UPDATE reading_lists SET name = $1, is_public = $2, updated_at = now() WHERE id = $3;`
+
+	row, err := tx.Exec(ctx, query, list.Name, list.IsPublic, list.Id)
+
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
+			return ErrReadingListNameDuplicate
+		}
+
+		return err
+	}
+
+	if row.RowsAffected() == 0 {
+		return ErrReadingListNotFound
+	}
+
+	return nil
+}
+
+// Delete implements domain.ReadingListRepository.
+func (p *postgresReadingListRepository) Delete(ctx context.Context, tx pgx.Tx, id int64) error {
+	row, err := tx.Exec(ctx, `DELETE FROM reading_lists WHERE id = $1;`, id)
+
+	if err != nil {
+		return err
+	}
+
+	if row.RowsAffected() == 0 {
+		return ErrReadingListNotFound
+	}
+
+	return nil
+}
+
+// FetchItems implements domain.ReadingListRepository. Deleted books are left out.
+func (p *postgresReadingListRepository) FetchItems(ctx context.Context, listID int64) ([]domain.ReadingListItem, error) {
+	query := `SELECT i.id, i.reading_list_id, i.book_id, i.position, b.title, COALESCE(b.thumbnail_url, b.image_url, ''),
+				b.in_stock > 0, i.created_at
+			  FROM reading_list_items i
+			  JOIN books b ON b.id = i.book_id
+			  WHERE i.reading_list_id = $1 AND b.deleted_at IS NULL
+			  ORDER BY i.position, i.id;`
+
+	rows, err := p.conn.Query(ctx, query, listID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := make([]domain.ReadingListItem, 0)
+
+	for rows.Next() {
+		i := domain.ReadingListItem{}
+
+		err = rows.Scan(&i.Id, &i.ReadingListID, &i.BookID, &i.Position, &i.BookTitle, &i.ImageUrl, &i.IsAvailable, &i.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, i)
+	}
+
+	return result, rows.Err()
+}
+
+// AddItem implements domain.ReadingListRepository.
+func (p *postgresReadingListRepository) AddItem(ctx context.Context, tx pgx.Tx, listID, bookID int64) error {
+	query := `INSERT INTO reading_list_items (reading_list_id, book_id, position)
+			  SELECT $1, b.id, COALESCE((SELECT MAX(position) FROM reading_list_items WHERE reading_list_id = $1), 0) + 1
+			  FROM books b
+			  WHERE b.id = $2 AND b.deleted_at IS NULL;`
+
+	row, err := tx.Exec(ctx, query, listID, bookID)
+
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
+			return ErrReadingListItemDuplicate
+		}
+
+		return err
+	}
+
+	if row.RowsAffected() == 0 {
+		return ErrBookNotFound
+	}
+
+	return nil
+}
+
+// RemoveItem implements domain.ReadingListRepository.
+func (p *postgresReadingListRepository) RemoveItem(ctx context.Context, tx pgx.Tx, listID, bookID int64) error {
+	row, err := tx.Exec(ctx, `DELETE FROM reading_list_items WHERE reading_list_id = $1 AND book_id = $2;`, listID, bookID)
+
+	if err != nil {
+		return err
+	}
+
+	if row.RowsAffected() == 0 {
+		return ErrReadingListItemNotFound
+	}
+
+	return nil
+}
+
+// Reorder implements domain.ReadingListRepository.
+func (p *postgresReadingListRepository) Reorder(ctx context.Context, tx pgx.Tx, listID int64, bookIDs []int64) error {
+	query := `UPDATE reading_list_items i
+			  SET position = o.position
+			  FROM unnest($2::BIGINT[]) WITH ORDINALITY AS o(book_id, position)
+			  WHERE i.reading_list_id = $1 AND i.book_id = o.book_id;`
+
+	_, err := tx.Exec(ctx, query, listID, bookIDs)
+
+	return err
+}
+
+// FetchRestockWatchers implements domain.ReadingListRepository. Only wishlists are watched.
+func (p *postgresReadingListRepository) FetchRestockWatchers(ctx context.Context, bookID int64) ([]domain.RestockWatcher, error) {
+	query := `SELECT u.id, u.email, u.name, b.title
+			  FROM reading_list_items i
+			  JOIN reading_lists l ON l.id = i.reading_list_id AND l.is_default
+			  JOIN users u ON u.id = l.user_id
+			  JOIN books b ON b.id = i.book_id
+			  WHERE i.book_id = $1 AND b.deleted_at IS NULL
+			  ORDER BY u.id;`
+
+	rows, err := p.conn.Query(ctx, query, bookID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := make([]domain.RestockWatcher, 0)
+
+	for rows.Next() {
+		w := domain.RestockWatcher{}
+
+		if err := rows.Scan(&w.UserID, &w.Email, &w.Name, &w.BookTitle); err != nil {
+			return nil, err
+		}
+
+		result = append(result, w)
+	}
+
+	return result, rows.Err()
+}
+
+func NewPostgresReadingListRepository(conn *pgxpool.Pool) domain.ReadingListRepository {
+	return &postgresReadingListRepository{
+		conn: conn,
+	}
+}
@@ -0,0 +1,539 @@
+package usecase
+
+import (
+	baseErr "backend-layout/internal/adapter/errors"
+	"backend-layout/internal/domain"
+	"backend-layout/internal/module/readinglist/repository"
+	"backend-layout/internal/tasks"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+type ReadingListUsecase struct {
+	readingListRepo domain.ReadingListRepository
+	cartUsecase     domain.CartUsecase
+	taskDistributor tasks.TaskDistributor
+}
+
+// Fetch implements domain.ReadingListUsecase.
+func (r *ReadingListUsecase) Fetch(ctx context.Context, userID int64) ([]domain.ReadingListResponse, error) {
+	if err := r.readingListRepo.EnsureDefault(ctx, userID); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("user_id", userID).Msg("failed to create wishlist")
+
+		return nil, baseErr.NewInternalServerError("failed to get reading lists")
+	}
+
+	lists, err := r.readingListRepo.FetchByUserID(ctx, userID)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("user_id", userID).Msg("failed to get reading lists")
+
+		return nil, baseErr.NewInternalServerError("failed to get reading lists")
+	}
+
+	result := make([]domain.ReadingListResponse, len(lists))
+
+	for i := range lists {
+		result[i] = domain.ReadingListToResponse(&lists[i])
+	}
+
+	return result, nil
+}
+
+// Get implements domain.ReadingListUsecase. Other users' lists are only visible when they are public.
+func (r *ReadingListUsecase) Get(ctx context.Context, id, userID int64) (domain.ReadingListResponse, error) {
+	list, err := r.readingListRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrReadingListNotFound) {
+			return domain.ReadingListResponse{}, baseErr.NewNotFoundError("reading list not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("reading_list_id", id).Msg("failed to get reading list")
+
+		return domain.ReadingListResponse{}, baseErr.NewInternalServerError("failed to get reading list")
+	}
+
+	if list.UserID != userID && !list.IsPublic {
+		return domain.ReadingListResponse{}, baseErr.NewNotFoundError("reading list not found")
+	}
+
+	return r.withItems(ctx, list)
+}
+
+// GetPublic implements domain.ReadingListUsecase.
+func (r *ReadingListUsecase) GetPublic(ctx context.Context, id int64) (domain.ReadingListResponse, error) {
+	list, err := r.readingListRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrReadingListNotFound) {
+			return domain.ReadingListResponse{}, baseErr.NewNotFoundError("reading list not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("reading_list_id", id).Msg("failed to get reading list")
+
+		return domain.ReadingListResponse{}, baseErr.NewInternalServerError("failed to get reading list")
+	}
+
+	if !list.IsPublic {
+		return domain.ReadingListResponse{}, baseErr.NewNotFoundError("reading list not found")
+	}
+
+	return r.withItems(ctx, list)
+}
+
+func (r *ReadingListUsecase) withItems(ctx context.Context, list *domain.ReadingList) (domain.ReadingListResponse, error) {
+	items, err := r.readingListRepo.FetchItems(ctx, list.Id)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("reading_list_id", list.Id).Msg("failed to get reading list items")
+
+		return domain.ReadingListResponse{}, baseErr.NewInternalServerError("failed to get reading list")
+	}
+
+	resp := domain.ReadingListToResponse(list)
+	resp.Items = make([]domain.ReadingListItemResponse, len(items))
+
+	for i := range items {
+		resp.Items[i] = domain.ReadingListItemToResponse(&items[i])
+	}
+
+	return resp, nil
+}
+
+// Store implements domain.ReadingListUsecase.
+func (r *ReadingListUsecase) Store(ctx context.Context, input *domain.StoreReadingListRequest) (resp domain.ReadingListResponse, err error) {
+	// the wishlist claims its name first so a list created before it cannot take it
+	if err := r.readingListRepo.EnsureDefault(ctx, input.UserID); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("user_id", input.UserID).Msg("failed to create wishlist")
+
+		return resp, baseErr.NewInternalServerError("failed to create reading list")
+	}
+
+	tx, err := r.readingListRepo.GetTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to begin transaction")
+		return resp, baseErr.NewInternalServerError("failed to create reading list")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	id, err := r.readingListRepo.Store(ctx, tx, &domain.ReadingList{
+		UserID:   input.UserID,
+		Name:     input.Name,
+		IsPublic: input.IsPublic,
+	})
+
+	if err != nil {
+		if errors.Is(err, repository.ErrReadingListNameDuplicate) {
+			return resp, baseErr.NewConflictError("you already have a reading list with this name")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("user_id", input.UserID).Msg("failed to store reading list")
+
+		return resp, baseErr.NewInternalServerError("failed to create reading list")
+	}
+
+	list, err := r.readingListRepo.GetForUpdate(ctx, tx, id)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("reading_list_id", id).Msg("failed to get reading list")
+
+		return resp, baseErr.NewInternalServerError("failed to create reading list")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to commit transaction")
+
+		return resp, baseErr.NewInternalServerError("failed to create reading list")
+	}
+
+	return domain.ReadingListToResponse(list), nil
+}
+
+// Update implements domain.ReadingListUsecase.
+func (r *ReadingListUsecase) Update(ctx context.Context, input *domain.UpdateReadingListRequest) (resp domain.ReadingListResponse, err error) {
+	tx, err := r.readingListRepo.GetTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to begin transaction")
+		return resp, baseErr.NewInternalServerError("failed to update reading list")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	list, err := r.getOwnList(ctx, tx, input.ID, input.UserID)
+	if err != nil {
+		return resp, err
+	}
+
+	if list.IsDefault && input.Name != list.Name {
+		return resp, baseErr.NewBadRequestError("the wishlist cannot be renamed")
+	}
+
+	list.Name = input.Name
+	list.IsPublic = input.IsPublic
+
+	if err = r.readingListRepo.Update(ctx, tx, list); err != nil {
+		if errors.Is(err, repository.ErrReadingListNameDuplicate) {
+			return resp, baseErr.NewConflictError("you already have a reading list with this name")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("reading_list_id", input.ID).Msg("failed to update reading list")
+
+		return resp, baseErr.NewInternalServerError("failed to update reading list")
+	}
+
+	updated, err := r.readingListRepo.GetForUpdate(ctx, tx, input.ID)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("reading_list_id", input.ID).Msg("failed to get reading list")
+
+		return resp, baseErr.NewInternalServerError("failed to update reading list")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to commit transaction")
+
+		return resp, baseErr.NewInternalServerError("failed to update reading list")
+	}
+
+	return domain.ReadingListToResponse(updated), nil
+}
+
+// Delete implements domain.ReadingListUsecase.
+func (r *ReadingListUsecase) Delete(ctx context.Context, id, userID int64) (err error) {
+	tx, err := r.readingListRepo.GetTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to begin transaction")
+		return baseErr.NewInternalServerError("failed to delete reading list")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	list, err := r.getOwnList(ctx, tx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if list.IsDefault {
+		return baseErr.NewBadRequestError("the wishlist cannot be deleted")
+	}
+
+	if err = r.readingListRepo.Delete(ctx, tx, id); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("reading_list_id", id).Msg("failed to delete reading list")
+
+		return baseErr.NewInternalServerError("failed to delete reading list")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to commit transaction")
+
+		return baseErr.NewInternalServerError("failed to delete reading list")
+	}
+
+	return nil
+}
+
+// AddItem implements domain.ReadingListUsecase.
+func (r *ReadingListUsecase) AddItem(ctx context.Context, id, userID, bookID int64) (err error) {
+	tx, err := r.readingListRepo.GetTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to begin transaction")
+		return baseErr.NewInternalServerError("failed to add book to reading list")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	if _, err = r.getOwnList(ctx, tx, id, userID); err != nil {
+		return err
+	}
+
+	if err = r.readingListRepo.AddItem(ctx, tx, id, bookID); err != nil {
+		if errors.Is(err, repository.ErrBookNotFound) {
+			return baseErr.NewNotFoundError("book not found")
+		}
+
+		if errors.Is(err, repository.ErrReadingListItemDuplicate) {
+			return baseErr.NewConflictError("book is already on this reading list")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("reading_list_id", id).Int64("book_id", bookID).Msg("failed to add reading list item")
+
+		return baseErr.NewInternalServerError("failed to add book to reading list")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to commit transaction")
+
+		return baseErr.NewInternalServerError("failed to add book to reading list")
+	}
+
+	return nil
+}
+
+// RemoveItem implements domain.ReadingListUsecase.
+func (r *ReadingListUsecase) RemoveItem(ctx context.Context, id, userID, bookID int64) (err error) {
+	tx, err := r.readingListRepo.GetTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to begin transaction")
+		return baseErr.NewInternalServerError("failed to remove book from reading list")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	if _, err = r.getOwnList(ctx, tx, id, userID); err != nil {
+		return err
+	}
+
+	if err = r.readingListRepo.RemoveItem(ctx, tx, id, bookID); err != nil {
+		if errors.Is(err, repository.ErrReadingListItemNotFound) {
+			return baseErr.NewNotFoundError("book is not on this reading list")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("reading_list_id", id).Int64("book_id", bookID).Msg("failed to remove reading list item")
+
+		return baseErr.NewInternalServerError("failed to remove book from reading list")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to commit transaction")
+
+		return baseErr.NewInternalServerError("failed to remove book from reading list")
+	}
+
+	return nil
+}
+
+// Reorder implements domain.ReadingListUsecase.
+func (r *ReadingListUsecase) Reorder(ctx context.Context, id, userID int64, bookIDs []int64) (err error) {
+	tx, err := r.readingListRepo.GetTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to begin transaction")
+		return baseErr.NewInternalServerError("failed to reorder reading list")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	if _, err = r.getOwnList(ctx, tx, id, userID); err != nil {
+		return err
+	}
+
+	items, err := r.readingListRepo.FetchItems(ctx, id)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("reading_list_id", id).Msg("failed to get reading list items")
+
+		return baseErr.NewInternalServerError("failed to reorder reading list")
+	}
+
+	// the new order has to name every book on the list exactly once
+	onList := make(map[int64]bool, len(items))
+	for _, item := range items {
+		onList[item.BookID] = true
+	}
+
+	if len(bookIDs) != len(onList) {
+		return baseErr.NewBadRequestError("book_ids must contain every book on the list exactly once")
+	}
+
+	for _, bookID := range bookIDs {
+		if !onList[bookID] {
+			return baseErr.NewBadRequestError("book_ids must contain every book on the list exactly once")
+		}
+
+		delete(onList, bookID)
+	}
+
+	if err = r.readingListRepo.Reorder(ctx, tx, id, bookIDs); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("reading_list_id", id).Msg("failed to reorder reading list")
+
+		return baseErr.NewInternalServerError("failed to reorder reading list")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to commit transaction")
+
+		return baseErr.NewInternalServerError("failed to reorder reading list")
+	}
+
+	return nil
+}
+
+// MoveToCart implements domain.ReadingListUsecase. The book goes through the usual cart rules and only leaves
+// the list once it is in the cart.
+func (r *ReadingListUsecase) MoveToCart(ctx context.Context, id, userID, bookID int64) (int64, error) {
+	list, err := r.readingListRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrReadingListNotFound) {
+			return 0, baseErr.NewNotFoundError("reading list not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("reading_list_id", id).Msg("failed to get reading list")
+
+		return 0, baseErr.NewInternalServerError("failed to move book to cart")
+	}
+
+	if list.UserID != userID {
+		return 0, baseErr.NewNotFoundError("reading list not found")
+	}
+
+	items, err := r.readingListRepo.FetchItems(ctx, id)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("reading_list_id", id).Msg("failed to get reading list items")
+
+		return 0, baseErr.NewInternalServerError("failed to move book to cart")
+	}
+
+	onList := false
+	for _, item := range items {
+		if item.BookID == bookID {
+			onList = true
+			break
+		}
+	}
+
+	if !onList {
+		return 0, baseErr.NewNotFoundError("book is not on this reading list")
+	}
+
+	cartID, err := r.cartUsecase.StoreCart(ctx, &domain.StoreCartRequest{BookID: bookID, UserID: userID})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := r.RemoveItem(ctx, id, userID, bookID); err != nil {
+		return 0, err
+	}
+
+	return cartID, nil
+}
+
+// NotifyRestocked implements domain.ReadingListUsecase.
+func (r *ReadingListUsecase) NotifyRestocked(ctx context.Context, bookID int64) error {
+	watchers, err := r.readingListRepo.FetchRestockWatchers(ctx, bookID)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("book_id", bookID).Msg("failed to get restock watchers")
+
+		return fmt.Errorf("failed to get restock watchers: %w", err)
+	}
+
+	for _, w := range watchers {
+		// unique so a retry after a partial run does not mail the same user twice
+		err := r.taskDistributor.DistributeTaskSendNotification(ctx, &tasks.PayloadSendNotification{
+			Email:   w.Email,
+			Name:    w.Name,
+			Subject: "A book on your wishlist is available",
+			Message: fmt.Sprintf("Good news, %q is back in stock. Borrow it before it is gone again.", w.BookTitle),
+		}, asynq.Unique(time.Hour))
+
+		if err != nil && !errors.Is(err, asynq.ErrDuplicateTask) {
+			log.Error().Err(err).Str("layer", "usecase").Int64("book_id", bookID).Int64("user_id", w.UserID).Msg("failed to enqueue restock notification")
+
+			return fmt.Errorf("failed to enqueue restock notification: %w", err)
+		}
+	}
+
+	log.Info().Str("layer", "usecase").Int64("book_id", bookID).Int("notified", len(watchers)).Msg("restock notifications queued")
+
+	return nil
+}
+
+// getOwnList locks the list, other users' lists are reported as not found
+func (r *ReadingListUsecase) getOwnList(ctx context.Context, tx pgx.Tx, id, userID int64) (*domain.ReadingList, error) {
+	list, err := r.readingListRepo.GetForUpdate(ctx, tx, id)
+
+	if err != nil {
+		if errors.Is(err, repository.ErrReadingListNotFound) {
+			return nil, baseErr.NewNotFoundError("reading list not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("reading_list_id", id).Msg("failed to get reading list")
+
+		return nil, baseErr.NewInternalServerError("failed to get reading list")
+	}
+
+	if list.UserID != userID {
+		return nil, baseErr.NewNotFoundError("reading list not found")
+	}
+
+	return list, nil
+}
+
+func NewReadingListUsecase(readingListRepo domain.ReadingListRepository, cartUsecase domain.CartUsecase, taskDistributor tasks.TaskDistributor) domain.ReadingListUsecase {
+	return &ReadingListUsecase{
+		readingListRepo: readingListRepo,
+		cartUsecase:     cartUsecase,
+		taskDistributor: taskDistributor,
+	}
+}
@@ -0,0 +1,167 @@
+package http
+
+import (
+	"backend-layout/helper"
+	"backend-layout/internal/domain"
+	"backend-layout/internal/httpcontext"
+	"backend-layout/internal/middleware"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ReviewHandler struct {
+	reviewUsecase domain.ReviewUsecase
+}
+
+func NewReviewHandler(p *echo.Group, r *echo.Group, ru domain.ReviewUsecase, rbac *middleware.RBACMiddleware) {
+	handler := &ReviewHandler{
+		reviewUsecase: ru,
+	}
+
+	p.GET("/books/:id/reviews", handler.List)
+	r.POST("/books/:id/reviews", handler.Store)
+	r.PATCH("/reviews/:id", handler.Update)
+	r.DELETE("/reviews/:id", handler.Delete)
+	r.POST("/reviews/:id/hide", handler.Hide, rbac.RequiredPermission("review:moderate"))
+	r.POST("/reviews/:id/unhide", handler.Unhide, rbac.RequiredPermission("review:moderate"))
+}
+
+func (h *ReviewHandler) List(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid book ID format")
+	}
+
+	ctx := c.Request().Context()
+
+	params := helper.GetRequestParams(c)
+
+	reviews, total, err := h.reviewUsecase.Fetch(ctx, id, params)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, helper.Paginate(c, reviews, total, params))
+}
+
+func (h *ReviewHandler) Store(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid book ID format")
+	}
+
+	req := new(domain.StoreReviewRequest)
+
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	req.BookID = id
+	req.UserID = user.ID
+
+	ctx := c.Request().Context()
+
+	review, err := h.reviewUsecase.Store(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, echo.Map{"message": "review created successfully", "data": review})
+}
+
+func (h *ReviewHandler) Update(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid review ID format")
+	}
+
+	req := new(domain.UpdateReviewRequest)
+
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	req.ID = id
+	req.UserID = user.ID
+
+	ctx := c.Request().Context()
+
+	review, err := h.reviewUsecase.Update(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "review updated successfully", "data": review})
+}
+
+func (h *ReviewHandler) Delete(c echo.Context) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid review ID format")
+	}
+
+	ctx := c.Request().Context()
+
+	if err := h.reviewUsecase.Delete(ctx, id, user.ID); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "review deleted successfully"})
+}
+
+func (h *ReviewHandler) Hide(c echo.Context) error {
+	return h.setHidden(c, true)
+}
+
+func (h *ReviewHandler) Unhide(c echo.Context) error {
+	return h.setHidden(c, false)
+}
+
+func (h *ReviewHandler) setHidden(c echo.Context, hidden bool) error {
+	user, ok := httpcontext.GetUserJWT(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized access. Please log in to continue.")
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid review ID format")
+	}
+
+	ctx := c.Request().Context()
+
+	if err := h.reviewUsecase.SetHidden(ctx, id, user.ID, hidden); err != nil {
+		return err
+	}
+
+	if hidden {
+		return c.JSON(http.StatusOK, echo.Map{"message": "review hidden successfully"})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "review is visible again"})
+}
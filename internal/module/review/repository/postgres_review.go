@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"backend-layout/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrReviewNotFound  = errors.New("review not found")
+	ErrReviewDuplicate = errors.New("duplicate entry: book already reviewed")
+)
+
+type postgresReviewRepository struct {
+	conn *pgxpool.Pool
+}
+
+// GetTx implements domain.ReviewRepository.
+func (p *postgresReviewRepository) GetTx(ctx context.Context) (pgx.Tx, error) {
+	tx, err := p.conn.Begin(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// Store implements domain.ReviewRepository.
+func (p *postgresReviewRepository) Store(ctx context.Context, tx pgx.Tx, review *domain.Review) (id int64, err error) {
+	query := `INSERT INTO book_reviews (book_id, user_id, rating, body)
+			  VALUES ($1, $2, $3, $4)
+			  RETURNING id;`
+
+	err = tx.QueryRow(ctx, query, review.BookID, review.UserID, review.Rating, review.Body).Scan(&id)
+
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
+			return 0, ErrReviewDuplicate
+		}
+
+		return 0, fmt.Errorf("failed to insert review: %w", err)
+	}
+
+	return
+}
+
+// GetForUpdate implements domain.ReviewRepository.
+func (p *postgresReviewRepository) GetForUpdate(ctx context.Context, tx pgx.Tx, id int64) (*domain.Review, error) {
+	query := `SELECT id, book_id, user_id, rating, body, hidden_at, hidden_by, created_at, updated_at
+			  FROM book_reviews
+			  WHERE id = $1
+			  FOR UPDATE;`
+
+	var r domain.Review
+
+	err := tx.QueryRow(ctx, query, id).Scan(&r.Id, &r.BookID, &r.UserID, &r.Rating, &r.Body, &r.HiddenAt, &r.HiddenBy, &r.CreatedAt, &r.UpdatedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrReviewNotFound
+		}
+
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// Update implements domain.ReviewRepository.
+func (p *postgresReviewRepository) Update(ctx context.Context, tx pgx.Tx, review *domain.Review) error {
+	query := `UPDATE book_reviews SET rating = $1, body = $2, updated_at = now() WHERE id = $3;`
+
+	row, err := tx.Exec(ctx, query, review.Rating, review.Body, review.Id)
+
+	if err != nil {
+		return err
+	}
+
+	if row.RowsAffected() == 0 {
+		return ErrReviewNotFound
+	}
+
+	return nil
+}
+
+// Delete implements domain.ReviewRepository.
+func (p *postgresReviewRepository) Delete(ctx context.Context, tx pgx.Tx, id int64) error {
+	row, err := tx.Exec(ctx, `DELETE FROM book_reviews WHERE id = $1;`, id)
+
+	if err != nil {
+		return err
+	}
+
+	if row.RowsAffected() == 0 {
+		return ErrReviewNotFound
+	}
+
+	return nil
+}
+
+// SetHidden implements domain.ReviewRepository. A nil hiddenBy makes the review visible again.
+func (p *postgresReviewRepository) SetHidden(ctx context.Context, tx pgx.Tx, id int64, hiddenBy *int64) error {
+	query := `UPDATE book_reviews
+			  SET hidden_at = CASE WHEN $1::INT IS NULL THEN NULL ELSE now() END, hidden_by = $1, updated_at = now()
+			  WHERE id = $2;`
+
+	row, err := tx.Exec(ctx, query, hiddenBy, id)
+
+	if err != nil {
+		return err
+	}
+
+	if row.RowsAffected() == 0 {
+		return ErrReviewNotFound
+	}
+
+	return nil
+}
+
+// FetchVisibleByBookID implements domain.ReviewRepository.
+func (p *postgresReviewRepository) FetchVisibleByBookID(ctx context.Context, bookID int64, params domain.RequestQueryParams) ([]domain.ReviewWithUser, int64, error) {
+	var total int64
+
+	err := p.conn.QueryRow(ctx, `SELECT COUNT(1) FROM book_reviews WHERE book_id = $1 AND hidden_at IS NULL;`, bookID).Scan(&total)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT r.id, r.book_id, r.user_id, u.name, r.rating, r.body, r.hidden_at, r.hidden_by, r.created_at, r.updated_at
+			  FROM book_reviews r
+			  JOIN users u ON u.id = r.user_id
+			  WHERE r.book_id = $1 AND r.hidden_at IS NULL
+			  ORDER BY r.id DESC
+			  LIMIT $2 OFFSET $3;`
+
+	rows, err := p.conn.Query(ctx, query, bookID, params.PerPage, (params.Page-1)*params.PerPage)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer rows.Close()
+
+	result := make([]domain.ReviewWithUser, 0)
+
+	for rows.Next() {
+		r := domain.ReviewWithUser{}
+
+		err = rows.Scan(&r.Id, &r.BookID, &r.UserID, &r.UserName, &r.Rating, &r.Body, &r.HiddenAt, &r.HiddenBy, &r.CreatedAt, &r.UpdatedAt)
+
+		if err != nil {
+			return nil, 0, err
+		}
+
+		result = append(result, r)
+	}
+
+	return result, total, rows.Err()
+}
+
+// HasBorrowed implements domain.ReviewRepository.
+func (p *postgresReviewRepository) HasBorrowed(ctx context.Context, tx pgx.Tx, userID, bookID int64) (bool, error) {
+	query := `SELECT EXISTS(
+				SELECT 1
+				FROM order_details od
+				JOIN orders o ON o.id = od.order_id
+				JOIN books b ON b.id = od.book_id
+				WHERE o.user_id = $1 AND od.book_id = $2 AND o.payment_status = 'Paid' AND b.deleted_at IS NULL
+			  );`
+
+	var exists bool
+
+	err := tx.QueryRow(ctx, query, userID, bookID).Scan(&exists)
+
+	return exists, err
+}
+
+// AdjustBookRating implements domain.ReviewRepository.
+func (p *postgresReviewRepository) AdjustBookRating(ctx context.Context, tx pgx.Tx, bookID int64, countDelta, sumDelta int) error {
+	query := `UPDATE books
+			  SET rating_count = rating_count + $1,
+				  rating_sum = rating_sum + $2,
+				  average_rating = CASE
+					WHEN rating_count + $1 > 0 THEN ROUND((rating_sum + $2)::NUMERIC / (rating_count + $1), 2)
+					ELSE 0
+				  END
+			  WHERE id = $3;`
+
+	row, err := tx.Exec(ctx, query, countDelta, sumDelta, bookID)
+
+	if err != nil {
+		return err
+	}
+
+	if row.RowsAffected() == 0 {
+		return fmt.Errorf("no rows updated for book_id %v", bookID)
+	}
+
+	return nil
+}
+
+func NewPostgresReviewRepository(conn *pgxpool.Pool) domain.ReviewRepository {
+	return &postgresReviewRepository{
+		conn: conn,
+	}
+}
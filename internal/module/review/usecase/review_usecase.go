@@ -0,0 +1,323 @@
+package usecase
+
+import (
+	baseErr "backend-layout/internal/adapter/errors"
+	"backend-layout/internal/domain"
+	"backend-layout/internal/module/review/repository"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+type ReviewUsecase struct {
+	reviewRepo domain.ReviewRepository
+}
+
+// Fetch implements domain.ReviewUsecase.
+func (r *ReviewUsecase) Fetch(ctx context.Context, bookID int64, params domain.RequestQueryParams) ([]domain.ReviewResponse, int64, error) {
+	reviews, total, err := r.reviewRepo.FetchVisibleByBookID(ctx, bookID, params)
+
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("book_id", bookID).Msg("failed to fetch reviews")
+
+		return nil, 0, baseErr.NewInternalServerError("failed to fetch reviews")
+	}
+
+	result := make([]domain.ReviewResponse, len(reviews))
+
+	for i, v := range reviews {
+		result[i] = domain.ReviewToResponse(&v.Review)
+		result[i].UserName = v.UserName
+	}
+
+	return result, total, nil
+}
+
+// Store implements domain.ReviewUsecase.
+func (r *ReviewUsecase) Store(ctx context.Context, input *domain.StoreReviewRequest) (resp domain.ReviewResponse, err error) {
+	tx, err := r.reviewRepo.GetTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to begin transaction")
+		return resp, baseErr.NewInternalServerError("failed to create review")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	borrowed, err := r.reviewRepo.HasBorrowed(ctx, tx, input.UserID, input.BookID)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("user_id", input.UserID).Int64("book_id", input.BookID).Msg("failed to check borrowing history")
+
+		return resp, baseErr.NewInternalServerError("failed to create review")
+	}
+
+	if !borrowed {
+		return resp, baseErr.NewForbiddenError("only books you have borrowed can be reviewed")
+	}
+
+	review := domain.Review{
+		BookID: input.BookID,
+		UserID: input.UserID,
+		Rating: input.Rating,
+		Body:   input.Body,
+	}
+
+	id, err := r.reviewRepo.Store(ctx, tx, &review)
+	if err != nil {
+		if errors.Is(err, repository.ErrReviewDuplicate) {
+			return resp, baseErr.NewConflictError("you have already reviewed this book")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("user_id", input.UserID).Int64("book_id", input.BookID).Msg("failed to store review")
+
+		return resp, baseErr.NewInternalServerError("failed to create review")
+	}
+
+	if err = r.reviewRepo.AdjustBookRating(ctx, tx, input.BookID, 1, input.Rating); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("book_id", input.BookID).Msg("failed to update book rating")
+
+		return resp, baseErr.NewInternalServerError("failed to create review")
+	}
+
+	stored, err := r.reviewRepo.GetForUpdate(ctx, tx, id)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("review_id", id).Msg("failed to get review")
+
+		return resp, baseErr.NewInternalServerError("failed to create review")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to commit transaction")
+
+		return resp, baseErr.NewInternalServerError("failed to create review")
+	}
+
+	return domain.ReviewToResponse(stored), nil
+}
+
+// Update implements domain.ReviewUsecase.
+func (r *ReviewUsecase) Update(ctx context.Context, input *domain.UpdateReviewRequest) (resp domain.ReviewResponse, err error) {
+	tx, err := r.reviewRepo.GetTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to begin transaction")
+		return resp, baseErr.NewInternalServerError("failed to update review")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	review, err := r.getOwnReview(ctx, tx, input.ID, input.UserID)
+	if err != nil {
+		return resp, err
+	}
+
+	oldRating := review.Rating
+
+	review.Rating = input.Rating
+	review.Body = input.Body
+
+	if err = r.reviewRepo.Update(ctx, tx, review); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("review_id", input.ID).Msg("failed to update review")
+
+		return resp, baseErr.NewInternalServerError("failed to update review")
+	}
+
+	// hidden reviews are not part of the aggregates
+	if review.HiddenAt == nil && oldRating != review.Rating {
+		if err = r.reviewRepo.AdjustBookRating(ctx, tx, review.BookID, 0, review.Rating-oldRating); err != nil {
+			log.Error().Err(err).Str("layer", "usecase").Int64("book_id", review.BookID).Msg("failed to update book rating")
+
+			return resp, baseErr.NewInternalServerError("failed to update review")
+		}
+	}
+
+	updated, err := r.reviewRepo.GetForUpdate(ctx, tx, input.ID)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("review_id", input.ID).Msg("failed to get review")
+
+		return resp, baseErr.NewInternalServerError("failed to update review")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to commit transaction")
+
+		return resp, baseErr.NewInternalServerError("failed to update review")
+	}
+
+	return domain.ReviewToResponse(updated), nil
+}
+
+// Delete implements domain.ReviewUsecase.
+func (r *ReviewUsecase) Delete(ctx context.Context, id, userID int64) (err error) {
+	tx, err := r.reviewRepo.GetTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to begin transaction")
+		return baseErr.NewInternalServerError("failed to delete review")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	review, err := r.getOwnReview(ctx, tx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if err = r.reviewRepo.Delete(ctx, tx, id); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("review_id", id).Msg("failed to delete review")
+
+		return baseErr.NewInternalServerError("failed to delete review")
+	}
+
+	if review.HiddenAt == nil {
+		if err = r.reviewRepo.AdjustBookRating(ctx, tx, review.BookID, -1, -review.Rating); err != nil {
+			log.Error().Err(err).Str("layer", "usecase").Int64("book_id", review.BookID).Msg("failed to update book rating")
+
+			return baseErr.NewInternalServerError("failed to delete review")
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to commit transaction")
+
+		return baseErr.NewInternalServerError("failed to delete review")
+	}
+
+	return nil
+}
+
+// SetHidden implements domain.ReviewUsecase.
+func (r *ReviewUsecase) SetHidden(ctx context.Context, id, moderatorID int64, hidden bool) (err error) {
+	verb := "unhide"
+	if hidden {
+		verb = "hide"
+	}
+
+	tx, err := r.reviewRepo.GetTx(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to begin transaction")
+		return baseErr.NewInternalServerError("failed to " + verb + " review")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				log.Error().Err(rbErr).Str("layer", "usecase").Msg("failed to rollback tx")
+			}
+
+			panic(p)
+		} else if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				err = fmt.Errorf("original error: %w, rollback error: %v", err, rbErr)
+			}
+		}
+	}()
+
+	review, err := r.reviewRepo.GetForUpdate(ctx, tx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrReviewNotFound) {
+			return baseErr.NewNotFoundError("review not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("review_id", id).Msg("failed to get review")
+
+		return baseErr.NewInternalServerError("failed to " + verb + " review")
+	}
+
+	if hidden && review.HiddenAt != nil {
+		return baseErr.NewConflictError("review is already hidden")
+	}
+
+	if !hidden && review.HiddenAt == nil {
+		return baseErr.NewConflictError("review is not hidden")
+	}
+
+	var hiddenBy *int64
+	countDelta, sumDelta := 1, review.Rating
+
+	if hidden {
+		hiddenBy = &moderatorID
+		countDelta, sumDelta = -1, -review.Rating
+	}
+
+	if err = r.reviewRepo.SetHidden(ctx, tx, id, hiddenBy); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("review_id", id).Msg("failed to " + verb + " review")
+
+		return baseErr.NewInternalServerError("failed to " + verb + " review")
+	}
+
+	if err = r.reviewRepo.AdjustBookRating(ctx, tx, review.BookID, countDelta, sumDelta); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Int64("book_id", review.BookID).Msg("failed to update book rating")
+
+		return baseErr.NewInternalServerError("failed to " + verb + " review")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Str("layer", "usecase").Msg("failed to commit transaction")
+
+		return baseErr.NewInternalServerError("failed to " + verb + " review")
+	}
+
+	return nil
+}
+
+// getOwnReview locks the review, other users' reviews are reported as not found
+func (r *ReviewUsecase) getOwnReview(ctx context.Context, tx pgx.Tx, id, userID int64) (*domain.Review, error) {
+	review, err := r.reviewRepo.GetForUpdate(ctx, tx, id)
+
+	if err != nil {
+		if errors.Is(err, repository.ErrReviewNotFound) {
+			return nil, baseErr.NewNotFoundError("review not found")
+		}
+
+		log.Error().Err(err).Str("layer", "usecase").Int64("review_id", id).Msg("failed to get review")
+
+		return nil, baseErr.NewInternalServerError("failed to get review")
+	}
+
+	if review.UserID != userID {
+		return nil, baseErr.NewNotFoundError("review not found")
+	}
+
+	return review, nil
+}
+
+func NewReviewUsecase(reviewRepo domain.ReviewRepository) domain.ReviewUsecase {
+	return &ReviewUsecase{reviewRepo: reviewRepo}
+}
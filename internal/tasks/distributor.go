@@ -9,6 +9,20 @@ import (
 type TaskDistributor interface {
 	DistributeTaskSendVerifyEmail(ctx context.Context, payload *PayloadSendVerifyEmail,
 		opts ...asynq.Option) error
+	DistributeTaskProcessBookImage(ctx context.Context, payload *PayloadProcessBookImage,
+		opts ...asynq.Option) error
+	DistributeTaskImportBooks(ctx context.Context, payload *PayloadImportBooks,
+		opts ...asynq.Option) error
+	DistributeTaskExportBooks(ctx context.Context, payload *PayloadExportBooks,
+		opts ...asynq.Option) error
+	DistributeTaskEnrichBookMetadata(ctx context.Context, payload *PayloadEnrichBookMetadata,
+		opts ...asynq.Option) error
+	DistributeTaskSendNotification(ctx context.Context, payload *PayloadSendNotification,
+		opts ...asynq.Option) error
+	DistributeTaskNotifyBookRestocked(ctx context.Context, payload *PayloadNotifyBookRestocked,
+		opts ...asynq.Option) error
+	DistributeTaskProcessBookHolds(ctx context.Context, payload *PayloadProcessBookHolds,
+		opts ...asynq.Option) error
 	Close() error
 }
 
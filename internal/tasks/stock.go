@@ -0,0 +1,34 @@
+package tasks
+
+import (
+	"backend-layout/internal/domain"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+// StockChanged follows up on a stock recount: copies on the shelf go to the hold queue first and the wishlists hear
+// about a book that is back in stock. The recount is already committed when it runs, so a failure is only logged.
+func StockChanged(ctx context.Context, d TaskDistributor, bookID int64, stock domain.BookStock) {
+	if stock.HasHoldsToOffer() {
+		if err := d.DistributeTaskProcessBookHolds(ctx, &PayloadProcessBookHolds{BookID: bookID}); err != nil {
+			log.Error().Err(err).Int64("book_id", bookID).Msg("failed to enqueue hold queue processing")
+		}
+	}
+
+	if stock.Restocked() {
+		NotifyBookRestocked(ctx, d, bookID)
+	}
+}
+
+// NotifyBookRestocked queues the wishlist notifications of a book, at most once an hour
+func NotifyBookRestocked(ctx context.Context, d TaskDistributor, bookID int64) {
+	err := d.DistributeTaskNotifyBookRestocked(ctx, &PayloadNotifyBookRestocked{BookID: bookID}, asynq.Unique(time.Hour))
+
+	if err != nil && !errors.Is(err, asynq.ErrDuplicateTask) {
+		log.Error().Err(err).Int64("book_id", bookID).Msg("failed to enqueue restock notification")
+	}
+}
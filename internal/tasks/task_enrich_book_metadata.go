@@ -0,0 +1,79 @@
+package tasks
+
+import (
+	"backend-layout/internal/domain"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	TaskEnrichBookMetadata = "task:enrich_book_metadata"
+)
+
+// PayloadEnrichBookMetadata limits the run to BookIDs, an empty list checks every book
+type PayloadEnrichBookMetadata struct {
+	BookIDs []int64
+}
+
+func (r *RedisTaskDestributor) DistributeTaskEnrichBookMetadata(ctx context.Context, payload *PayloadEnrichBookMetadata, opts ...asynq.Option) error {
+	jsonPayload, err := json.Marshal(payload)
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload %w", err)
+	}
+
+	task := asynq.NewTask(TaskEnrichBookMetadata, jsonPayload)
+	taskInfo, err := r.client.EnqueueContext(ctx, task, opts...)
+
+	if err != nil {
+		log.Error().
+			Err(err).
+			Int("books", len(payload.BookIDs)).
+			Msg("failed to enqueue task")
+		return fmt.Errorf("failed to enqueue task: %w", err)
+	}
+
+	log.Info().
+		Str("type", task.Type()).
+		Bytes("payload", task.Payload()).
+		Str("queue", taskInfo.Queue).
+		Int("max_retry", taskInfo.MaxRetry).
+		Msg("enqueued task")
+
+	return nil
+}
+
+type EnrichBookMetadataHandler struct {
+	metadataUsecase domain.BookMetadataUsecase
+}
+
+func NewEnrichBookMetadataHandler(metadataUsecase domain.BookMetadataUsecase) *EnrichBookMetadataHandler {
+	return &EnrichBookMetadataHandler{metadataUsecase: metadataUsecase}
+}
+
+func (h *EnrichBookMetadataHandler) ProcessTask(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadEnrichBookMetadata
+
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	result, err := h.metadataUsecase.Enrich(ctx, payload.BookIDs)
+
+	log.Info().
+		Int("checked", result.Checked).
+		Int("updated", result.Updated).
+		Int("not_found", result.NotFound).
+		Int("failed", result.Failed).
+		Msg("book metadata enrichment finished")
+
+	if err != nil {
+		return fmt.Errorf("failed to enrich book metadata: %w", err)
+	}
+
+	return nil
+}
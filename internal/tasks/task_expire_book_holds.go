@@ -0,0 +1,30 @@
+package tasks
+
+import (
+	"backend-layout/internal/domain"
+	"context"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+)
+
+const (
+	TaskExpireBookHolds = "task:expire_book_holds"
+)
+
+type ExpireBookHoldsHandler struct {
+	holdUsecase domain.BookHoldUsecase
+}
+
+// NewExpireBookHoldsHandler passes the copies of holds that were not claimed in time to the next user in line
+func NewExpireBookHoldsHandler(holdUsecase domain.BookHoldUsecase) *ExpireBookHoldsHandler {
+	return &ExpireBookHoldsHandler{holdUsecase: holdUsecase}
+}
+
+func (h *ExpireBookHoldsHandler) ProcessTask(ctx context.Context, task *asynq.Task) error {
+	if err := h.holdUsecase.ExpireReady(ctx); err != nil {
+		return fmt.Errorf("failed to expire holds: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package tasks
+
+import (
+	"backend-layout/internal/domain"
+	"context"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+)
+
+const (
+	TaskExpireUnpaidOrders = "task:expire_unpaid_orders"
+)
+
+type ExpireUnpaidOrdersHandler struct {
+	orderUsecase domain.OrderUsecase
+}
+
+// NewExpireUnpaidOrdersHandler expires orders left unpaid past the payment window and puts their books back on the shelf
+func NewExpireUnpaidOrdersHandler(orderUsecase domain.OrderUsecase) *ExpireUnpaidOrdersHandler {
+	return &ExpireUnpaidOrdersHandler{orderUsecase: orderUsecase}
+}
+
+func (h *ExpireUnpaidOrdersHandler) ProcessTask(ctx context.Context, task *asynq.Task) error {
+	if err := h.orderUsecase.ExpireUnpaid(ctx); err != nil {
+		return fmt.Errorf("failed to expire unpaid orders: %w", err)
+	}
+
+	return nil
+}
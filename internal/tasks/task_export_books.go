@@ -0,0 +1,66 @@
+package tasks
+
+import (
+	"backend-layout/internal/domain"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	TaskExportBooks = "task:export_books"
+)
+
+type PayloadExportBooks struct {
+	ExportID int64
+}
+
+func (r *RedisTaskDestributor) DistributeTaskExportBooks(ctx context.Context, payload *PayloadExportBooks, opts ...asynq.Option) error {
+	jsonPayload, err := json.Marshal(payload)
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload %w", err)
+	}
+
+	task := asynq.NewTask(TaskExportBooks, jsonPayload)
+	taskInfo, err := r.client.EnqueueContext(ctx, task, opts...)
+
+	if err != nil {
+		log.Error().
+			Err(err).
+			Int64("export_id", payload.ExportID).
+			Msg("failed to enqueue task")
+		return fmt.Errorf("failed to enqueue task: %w", err)
+	}
+
+	log.Info().
+		Str("type", task.Type()).
+		Bytes("payload", task.Payload()).
+		Str("queue", taskInfo.Queue).
+		Int("max_retry", taskInfo.MaxRetry).
+		Msg("enqueued task")
+
+	return nil
+}
+
+type ExportBooksHandler struct {
+	exportUsecase domain.BookExportUsecase
+}
+
+func NewExportBooksHandler(exportUsecase domain.BookExportUsecase) *ExportBooksHandler {
+	return &ExportBooksHandler{exportUsecase: exportUsecase}
+}
+
+func (h *ExportBooksHandler) ProcessTask(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadExportBooks
+
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	// an export only reads the catalog, so a failed attempt is simply run again
+	return h.exportUsecase.Process(ctx, payload.ExportID)
+}
@@ -0,0 +1,70 @@
+package tasks
+
+import (
+	"backend-layout/internal/domain"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	TaskImportBooks = "task:import_books"
+)
+
+type PayloadImportBooks struct {
+	ImportID int64
+}
+
+func (r *RedisTaskDestributor) DistributeTaskImportBooks(ctx context.Context, payload *PayloadImportBooks, opts ...asynq.Option) error {
+	jsonPayload, err := json.Marshal(payload)
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload %w", err)
+	}
+
+	task := asynq.NewTask(TaskImportBooks, jsonPayload)
+	taskInfo, err := r.client.EnqueueContext(ctx, task, opts...)
+
+	if err != nil {
+		log.Error().
+			Err(err).
+			Int64("import_id", payload.ImportID).
+			Msg("failed to enqueue task")
+		return fmt.Errorf("failed to enqueue task: %w", err)
+	}
+
+	log.Info().
+		Str("type", task.Type()).
+		Bytes("payload", task.Payload()).
+		Str("queue", taskInfo.Queue).
+		Int("max_retry", taskInfo.MaxRetry).
+		Msg("enqueued task")
+
+	return nil
+}
+
+type ImportBooksHandler struct {
+	importUsecase domain.BookImportUsecase
+}
+
+func NewImportBooksHandler(importUsecase domain.BookImportUsecase) *ImportBooksHandler {
+	return &ImportBooksHandler{importUsecase: importUsecase}
+}
+
+func (h *ImportBooksHandler) ProcessTask(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadImportBooks
+
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	// a retry is safe, an import that got as far as its rows is settled and the retry leaves it be
+	if err := h.importUsecase.Process(ctx, payload.ImportID); err != nil {
+		return fmt.Errorf("import %d failed: %w", payload.ImportID, err)
+	}
+
+	return nil
+}
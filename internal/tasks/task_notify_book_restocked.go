@@ -0,0 +1,65 @@
+package tasks
+
+import (
+	"backend-layout/internal/domain"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	TaskNotifyBookRestocked = "task:notify_book_restocked"
+)
+
+type PayloadNotifyBookRestocked struct {
+	BookID int64
+}
+
+func (r *RedisTaskDestributor) DistributeTaskNotifyBookRestocked(ctx context.Context, payload *PayloadNotifyBookRestocked, opts ...asynq.Option) error {
+	jsonPayload, err := json.Marshal(payload)
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload %w", err)
+	}
+
+	task := asynq.NewTask(TaskNotifyBookRestocked, jsonPayload)
+	taskInfo, err := r.client.EnqueueContext(ctx, task, opts...)
+
+	if err != nil {
+		log.Error().
+			Err(err).
+			Int64("book_id", payload.BookID).
+			Msg("failed to enqueue task")
+		return fmt.Errorf("failed to enqueue task: %w", err)
+	}
+
+	log.Info().
+		Str("type", task.Type()).
+		Bytes("payload", task.Payload()).
+		Str("queue", taskInfo.Queue).
+		Int("max_retry", taskInfo.MaxRetry).
+		Msg("enqueued task")
+
+	return nil
+}
+
+type NotifyBookRestockedHandler struct {
+	readingListUsecase domain.ReadingListUsecase
+}
+
+func NewNotifyBookRestockedHandler(readingListUsecase domain.ReadingListUsecase) *NotifyBookRestockedHandler {
+	return &NotifyBookRestockedHandler{readingListUsecase: readingListUsecase}
+}
+
+func (h *NotifyBookRestockedHandler) ProcessTask(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadNotifyBookRestocked
+
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	return h.readingListUsecase.NotifyRestocked(ctx, payload.BookID)
+}
@@ -0,0 +1,65 @@
+package tasks
+
+import (
+	"backend-layout/internal/domain"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	TaskProcessBookHolds = "task:process_book_holds"
+)
+
+type PayloadProcessBookHolds struct {
+	BookID int64
+}
+
+func (r *RedisTaskDestributor) DistributeTaskProcessBookHolds(ctx context.Context, payload *PayloadProcessBookHolds, opts ...asynq.Option) error {
+	jsonPayload, err := json.Marshal(payload)
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload %w", err)
+	}
+
+	task := asynq.NewTask(TaskProcessBookHolds, jsonPayload)
+	taskInfo, err := r.client.EnqueueContext(ctx, task, opts...)
+
+	if err != nil {
+		log.Error().
+			Err(err).
+			Int64("book_id", payload.BookID).
+			Msg("failed to enqueue task")
+		return fmt.Errorf("failed to enqueue task: %w", err)
+	}
+
+	log.Info().
+		Str("type", task.Type()).
+		Bytes("payload", task.Payload()).
+		Str("queue", taskInfo.Queue).
+		Int("max_retry", taskInfo.MaxRetry).
+		Msg("enqueued task")
+
+	return nil
+}
+
+type ProcessBookHoldsHandler struct {
+	holdUsecase domain.BookHoldUsecase
+}
+
+func NewProcessBookHoldsHandler(holdUsecase domain.BookHoldUsecase) *ProcessBookHoldsHandler {
+	return &ProcessBookHoldsHandler{holdUsecase: holdUsecase}
+}
+
+func (h *ProcessBookHoldsHandler) ProcessTask(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadProcessBookHolds
+
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	return h.holdUsecase.ProcessQueue(ctx, payload.BookID)
+}
@@ -0,0 +1,173 @@
+package tasks
+
+import (
+	"backend-layout/internal/adapter/imaging"
+	"backend-layout/internal/adapter/storage"
+	"backend-layout/internal/domain"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	TaskProcessBookImage = "task:process_book_image"
+)
+
+// BookImageVariants are the renditions generated for every uploaded cover
+var BookImageVariants = []imaging.Variant{
+	{Name: "original", Width: 0},
+	{Name: "thumbnail", Width: 200},
+	{Name: "small", Width: 480},
+	{Name: "medium", Width: 960},
+}
+
+type PayloadProcessBookImage struct {
+	BookID    int64
+	SourceKey string
+}
+
+func (r *RedisTaskDestributor) DistributeTaskProcessBookImage(ctx context.Context, payload *PayloadProcessBookImage, opts ...asynq.Option) error {
+	jsonPayload, err := json.Marshal(payload)
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload %w", err)
+	}
+
+	task := asynq.NewTask(TaskProcessBookImage, jsonPayload)
+	taskInfo, err := r.client.EnqueueContext(ctx, task, opts...)
+
+	if err != nil {
+		log.Error().
+			Err(err).
+			Int64("book_id", payload.BookID).
+			Msg("failed to enqueue task")
+		return fmt.Errorf("failed to enqueue task: %w", err)
+	}
+
+	log.Info().
+		Str("type", task.Type()).
+		Bytes("payload", task.Payload()).
+		Str("queue", taskInfo.Queue).
+		Int("max_retry", taskInfo.MaxRetry).
+		Msg("enqueued task")
+
+	return nil
+}
+
+type ProcessBookImageHandler struct {
+	bookRepo domain.BookRepository
+	storage  storage.Uploader
+}
+
+func NewProcessBookImageHandler(bookRepo domain.BookRepository, storage storage.Uploader) *ProcessBookImageHandler {
+	return &ProcessBookImageHandler{
+		bookRepo: bookRepo,
+		storage:  storage,
+	}
+}
+
+// ProcessTask resizes the uploaded cover into BookImageVariants. Every rendition is
+// re-encoded from decoded pixels, which strips EXIF and other metadata from the source.
+func (h *ProcessBookImageHandler) ProcessTask(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadProcessBookImage
+
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	src, err := h.storage.Open(ctx, payload.SourceKey)
+	if err != nil {
+		return fmt.Errorf("failed to open source image %s: %w", payload.SourceKey, err)
+	}
+
+	img, sourceFormat, err := imaging.Decode(src)
+	src.Close()
+
+	if err != nil {
+		return fmt.Errorf("%v: %w", err, asynq.SkipRetry)
+	}
+
+	baseFormat := imaging.FormatJPEG
+	if sourceFormat == imaging.FormatPNG {
+		baseFormat = imaging.FormatPNG
+	}
+
+	formats := []string{baseFormat}
+	if imaging.WebPAvailable() {
+		formats = append(formats, imaging.FormatWebP)
+	}
+
+	book := &domain.Book{Id: payload.BookID}
+	renditions := make([]domain.BookImageRendition, 0, len(BookImageVariants)*len(formats))
+
+	for _, variant := range BookImageVariants {
+		resized := imaging.Fit(img, variant.Width)
+
+		for _, format := range formats {
+			data, err := imaging.Encode(ctx, resized, format)
+
+			if err != nil {
+				if errors.Is(err, imaging.ErrWebPUnavailable) {
+					continue
+				}
+
+				return fmt.Errorf("failed to encode %s rendition: %w", variant.Name, err)
+			}
+
+			key := fmt.Sprintf("books/%d/%s%s", payload.BookID, variant.Name, imaging.Extension(format))
+
+			url, err := h.storage.Put(ctx, key, bytes.NewReader(data), imaging.ContentType(format))
+			if err != nil {
+				return fmt.Errorf("failed to store %s rendition: %w", variant.Name, err)
+			}
+
+			renditions = append(renditions, domain.BookImageRendition{
+				BookID:  payload.BookID,
+				Variant: variant.Name,
+				Format:  format,
+				Width:   resized.Bounds().Dx(),
+				Height:  resized.Bounds().Dy(),
+				Url:     url,
+			})
+
+			if format != baseFormat {
+				continue
+			}
+
+			switch variant.Name {
+			case "original":
+				book.ImageUrl = url
+			case "thumbnail":
+				book.ThumbnailUrl = url
+			}
+		}
+	}
+
+	tx, err := h.bookRepo.GetTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer tx.Rollback(ctx)
+
+	if err := h.bookRepo.SaveImageRenditions(ctx, tx, book, renditions); err != nil {
+		return fmt.Errorf("failed to save renditions: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	// the source still carries its original metadata, so it is not kept around
+	if err := h.storage.Delete(ctx, payload.SourceKey); err != nil {
+		log.Warn().Err(err).Str("key", payload.SourceKey).Msg("failed to delete source image")
+	}
+
+	log.Info().Int64("book_id", payload.BookID).Int("renditions", len(renditions)).Msg("book image processed")
+	return nil
+}
@@ -0,0 +1,30 @@
+package tasks
+
+import (
+	"backend-layout/internal/domain"
+	"context"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+)
+
+const (
+	TaskProcessOverdueLoans = "task:process_overdue_loans"
+)
+
+type ProcessOverdueLoansHandler struct {
+	orderUsecase domain.OrderUsecase
+}
+
+// NewProcessOverdueLoansHandler reminds borrowers of due loans, flags overdue ones and charges their late fees
+func NewProcessOverdueLoansHandler(orderUsecase domain.OrderUsecase) *ProcessOverdueLoansHandler {
+	return &ProcessOverdueLoansHandler{orderUsecase: orderUsecase}
+}
+
+func (h *ProcessOverdueLoansHandler) ProcessTask(ctx context.Context, task *asynq.Task) error {
+	if err := h.orderUsecase.ProcessOverdueLoans(ctx); err != nil {
+		return fmt.Errorf("failed to process overdue loans: %w", err)
+	}
+
+	return nil
+}
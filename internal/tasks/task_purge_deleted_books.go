@@ -0,0 +1,37 @@
+package tasks
+
+import (
+	"backend-layout/internal/domain"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	TaskPurgeDeletedBooks = "task:purge_deleted_books"
+)
+
+type PurgeDeletedBooksHandler struct {
+	bookUsecase domain.BookUsecase
+	retention   time.Duration
+}
+
+// NewPurgeDeletedBooksHandler purges books that have been soft deleted for longer than retention
+func NewPurgeDeletedBooksHandler(bookUsecase domain.BookUsecase, retention time.Duration) *PurgeDeletedBooksHandler {
+	return &PurgeDeletedBooksHandler{bookUsecase: bookUsecase, retention: retention}
+}
+
+func (h *PurgeDeletedBooksHandler) ProcessTask(ctx context.Context, task *asynq.Task) error {
+	purged, err := h.bookUsecase.PurgeDeleted(ctx, time.Now().Add(-h.retention))
+
+	if err != nil {
+		return fmt.Errorf("failed to purge deleted books: %w", err)
+	}
+
+	log.Info().Int("purged", purged).Msg("purge deleted books finished")
+
+	return nil
+}
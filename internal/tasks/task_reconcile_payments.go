@@ -0,0 +1,30 @@
+package tasks
+
+import (
+	"backend-layout/internal/domain"
+	"context"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+)
+
+const (
+	TaskReconcilePayments = "task:reconcile_payments"
+)
+
+type ReconcilePaymentsHandler struct {
+	paymentUsecase domain.PaymentUsecase
+}
+
+// NewReconcilePaymentsHandler checks the recent orders against the payment gateway and stores a discrepancy report
+func NewReconcilePaymentsHandler(paymentUsecase domain.PaymentUsecase) *ReconcilePaymentsHandler {
+	return &ReconcilePaymentsHandler{paymentUsecase: paymentUsecase}
+}
+
+func (h *ReconcilePaymentsHandler) ProcessTask(ctx context.Context, task *asynq.Task) error {
+	if err := h.paymentUsecase.Reconcile(ctx); err != nil {
+		return fmt.Errorf("failed to reconcile payments: %w", err)
+	}
+
+	return nil
+}
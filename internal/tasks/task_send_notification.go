@@ -0,0 +1,107 @@
+package tasks
+
+import (
+	"backend-layout/internal/adapter/mail"
+	"backend-layout/internal/config"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/gomail.v2"
+)
+
+const (
+	TaskSendNotification = "task:send_notification"
+)
+
+// PayloadSendNotification is a plain message mailed to a user, used by every feature that needs to tell a user something
+type PayloadSendNotification struct {
+	Email   string
+	Name    string
+	Subject string
+	Message string
+}
+
+func (r *RedisTaskDestributor) DistributeTaskSendNotification(ctx context.Context, payload *PayloadSendNotification, opts ...asynq.Option) error {
+	jsonPayload, err := json.Marshal(payload)
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload %w", err)
+	}
+
+	task := asynq.NewTask(TaskSendNotification, jsonPayload)
+	taskInfo, err := r.client.EnqueueContext(ctx, task, opts...)
+
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("email", payload.Email).
+			Str("subject", payload.Subject).
+			Msg("failed to enqueue task")
+		return fmt.Errorf("failed to enqueue task: %w", err)
+	}
+
+	log.Info().
+		Str("type", task.Type()).
+		Bytes("payload", task.Payload()).
+		Str("queue", taskInfo.Queue).
+		Int("max_retry", taskInfo.MaxRetry).
+		Msg("enqueued task")
+
+	return nil
+}
+
+func HandlerSendNotification(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadSendNotification
+
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	body, err := BuildTemplateNotification(&payload)
+	if err != nil {
+		return fmt.Errorf("failed to build notification: %v: %w", err, asynq.SkipRetry)
+	}
+
+	d := mail.InitMail()
+	m := gomail.NewMessage()
+
+	m.SetHeader("From", config.LoadMailConfig().MailEmail)
+	m.SetHeader("To", payload.Email)
+	m.SetHeader("Subject", payload.Subject)
+	m.SetBody("text/html", body)
+
+	if err := d.DialAndSend(m); err != nil {
+		log.Error().Err(err).Msg("failed to send notification")
+		return fmt.Errorf("failed to send email to %s: %w", payload.Email, err)
+	}
+
+	log.Info().Str("subject", payload.Subject).Msg("notification delivery task completed successfully")
+	return nil
+}
+
+func BuildTemplateNotification(payload *PayloadSendNotification) (string, error) {
+	absolutePath, _ := os.Getwd()
+
+	filename := filepath.Join(absolutePath, "/internal/adapter/mail/template/", "notification.templ")
+
+	tmpl, err := template.ParseFiles(filename)
+
+	if err != nil {
+		return "", fmt.Errorf("failed to parse notification template: %w", err)
+	}
+
+	var out bytes.Buffer
+
+	if err := tmpl.Execute(&out, payload); err != nil {
+		return "", fmt.Errorf("failed to execute notification template: %w", err)
+	}
+
+	return out.String(), nil
+}